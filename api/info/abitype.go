@@ -0,0 +1,411 @@
+package infoHandler
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	intTypeRegex        = regexp.MustCompile(`^(u?int)([0-9]*)$`)
+	fixedBytesTypeRegex = regexp.MustCompile(`^bytes([0-9]+)$`)
+)
+
+// ParseAbiType turns a solidity type expression such as "uint96", "bytes32[2]",
+// "address[]" or "(uint256,(bool,address)[])[]" into an abi.Type.
+func ParseAbiType(typeExpr string) (abi.Type, error) {
+	marshaling, err := parseAbiTypeExpr(strings.ReplaceAll(typeExpr, " ", ""), "")
+	if err != nil {
+		return abi.Type{}, err
+	}
+	return abi.NewType(marshaling.Type, "", marshaling.Components)
+}
+
+func parseAbiTypeExpr(typeExpr string, name string) (abi.ArgumentMarshaling, error) {
+	if typeExpr == "" {
+		return abi.ArgumentMarshaling{}, fmt.Errorf("empty type")
+	}
+
+	if strings.HasPrefix(typeExpr, "(") {
+		closing, err := matchingParen(typeExpr, 0)
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		suffix := typeExpr[closing+1:]
+		if err := validateArraySuffix(suffix); err != nil {
+			return abi.ArgumentMarshaling{}, fmt.Errorf("invalid tuple type %s: %v", typeExpr, err)
+		}
+
+		var components []abi.ArgumentMarshaling
+		for i, elem := range SplitTopLevel(typeExpr[1:closing]) {
+			component, err := parseAbiTypeExpr(elem, fmt.Sprintf("field%d", i))
+			if err != nil {
+				return abi.ArgumentMarshaling{}, err
+			}
+			components = append(components, component)
+		}
+		if len(components) == 0 {
+			return abi.ArgumentMarshaling{}, fmt.Errorf("empty tuple type: %s", typeExpr)
+		}
+		return abi.ArgumentMarshaling{Name: name, Type: "tuple" + suffix, Components: components}, nil
+	}
+
+	base := typeExpr
+	suffix := ""
+	if i := strings.Index(typeExpr, "["); i != -1 {
+		base, suffix = typeExpr[:i], typeExpr[i:]
+	}
+	if err := validateArraySuffix(suffix); err != nil {
+		return abi.ArgumentMarshaling{}, fmt.Errorf("invalid type %s: %v", typeExpr, err)
+	}
+
+	if matches := intTypeRegex.FindStringSubmatch(base); matches != nil {
+		size := 256
+		if matches[2] != "" {
+			size, _ = strconv.Atoi(matches[2])
+		}
+		if size < 8 || size > 256 || size%8 != 0 {
+			return abi.ArgumentMarshaling{}, fmt.Errorf("invalid integer size: %s", base)
+		}
+		base = fmt.Sprintf("%s%d", matches[1], size)
+	} else if matches := fixedBytesTypeRegex.FindStringSubmatch(base); matches != nil {
+		size, _ := strconv.Atoi(matches[1])
+		if size < 1 || size > 32 {
+			return abi.ArgumentMarshaling{}, fmt.Errorf("invalid fixed bytes size: %s", base)
+		}
+	} else {
+		switch base {
+		case "address", "bool", "string", "bytes", "function":
+		default:
+			return abi.ArgumentMarshaling{}, fmt.Errorf("unsupported type: %s", base)
+		}
+	}
+
+	return abi.ArgumentMarshaling{Name: name, Type: base + suffix}, nil
+}
+
+func validateArraySuffix(suffix string) error {
+	for suffix != "" {
+		if !strings.HasPrefix(suffix, "[") {
+			return fmt.Errorf("unexpected %q", suffix)
+		}
+		end := strings.Index(suffix, "]")
+		if end == -1 {
+			return fmt.Errorf("unterminated array")
+		}
+		if size := suffix[1:end]; size != "" {
+			n, err := strconv.Atoi(size)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("invalid array size %q", size)
+			}
+		}
+		suffix = suffix[end+1:]
+	}
+	return nil
+}
+
+func matchingParen(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses in %s", s)
+}
+
+// SplitTopLevel splits a comma separated list, ignoring commas nested inside
+// parentheses or brackets.
+func SplitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(parts) > 0 {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+// ParseMethodSignature splits "transfer(address,uint256)" into its name and
+// argument types. A bare method name returns no types.
+func ParseMethodSignature(signature string) (string, []string, error) {
+	signature = strings.TrimSpace(signature)
+	open := strings.Index(signature, "(")
+	if open == -1 {
+		return signature, nil, nil
+	}
+	closing, err := matchingParen(signature, open)
+	if err != nil {
+		return "", nil, err
+	}
+	if closing != len(signature)-1 {
+		return "", nil, fmt.Errorf("unexpected trailing characters in signature: %s", signature)
+	}
+	return signature[:open], SplitTopLevel(signature[open+1 : closing]), nil
+}
+
+// ParseAbiValue converts a query string value into the go value expected by
+// abi.Arguments.Pack for the given type. Arrays and tuples are given as JSON
+// arrays, e.g. `[1,2,3]` or `["0xabc...",[true,"7"]]`.
+func ParseAbiValue(t abi.Type, raw string) (interface{}, error) {
+	var decoded interface{} = raw
+	switch t.T {
+	case abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		decoder := json.NewDecoder(strings.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&decoded); err != nil {
+			return nil, fmt.Errorf("invalid %s value, expected a JSON array: %v", t.String(), err)
+		}
+	}
+
+	value, err := convertAbiValue(t, decoded)
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+func convertAbiValue(t abi.Type, raw interface{}) (reflect.Value, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, err := toBigInt(raw)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid %s: %v", t.String(), err)
+		}
+		if err := checkIntRange(t, n); err != nil {
+			return reflect.Value{}, err
+		}
+		goType := t.GetType()
+		if goType == reflect.TypeOf(&big.Int{}) {
+			return reflect.ValueOf(n), nil
+		}
+		v := reflect.New(goType).Elem()
+		if t.T == abi.UintTy {
+			v.SetUint(n.Uint64())
+		} else {
+			v.SetInt(n.Int64())
+		}
+		return v, nil
+
+	case abi.BoolTy:
+		switch b := raw.(type) {
+		case bool:
+			return reflect.ValueOf(b), nil
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid bool: %s", b)
+			}
+			return reflect.ValueOf(parsed), nil
+		}
+		return reflect.Value{}, fmt.Errorf("invalid bool: %v", raw)
+
+	case abi.StringTy:
+		s, ok := raw.(string)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid string: %v", raw)
+		}
+		return reflect.ValueOf(s), nil
+
+	case abi.AddressTy:
+		s, ok := raw.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return reflect.Value{}, fmt.Errorf("invalid address: %v", raw)
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.BytesTy:
+		data, err := decodeHexValue(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(data), nil
+
+	case abi.FixedBytesTy, abi.FunctionTy:
+		data, err := decodeHexValue(raw)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		v := reflect.New(t.GetType()).Elem()
+		if len(data) > v.Len() {
+			return reflect.Value{}, fmt.Errorf("value too long for %s: %d bytes", t.String(), len(data))
+		}
+		reflect.Copy(v, reflect.ValueOf(data))
+		return v, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		elems, ok := raw.([]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid %s, expected an array", t.String())
+		}
+		var v reflect.Value
+		if t.T == abi.SliceTy {
+			v = reflect.MakeSlice(t.GetType(), len(elems), len(elems))
+		} else {
+			if len(elems) != t.Size {
+				return reflect.Value{}, fmt.Errorf("invalid %s, expected %d elements, got %d", t.String(), t.Size, len(elems))
+			}
+			v = reflect.New(t.GetType()).Elem()
+		}
+		for i, elem := range elems {
+			converted, err := convertAbiValue(*t.Elem, elem)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s[%d]: %v", t.String(), i, err)
+			}
+			v.Index(i).Set(converted)
+		}
+		return v, nil
+
+	case abi.TupleTy:
+		elems, ok := raw.([]interface{})
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid %s, expected an array of tuple fields", t.String())
+		}
+		if len(elems) != len(t.TupleElems) {
+			return reflect.Value{}, fmt.Errorf("invalid %s, expected %d fields, got %d", t.String(), len(t.TupleElems), len(elems))
+		}
+		v := reflect.New(t.GetType()).Elem()
+		for i, elem := range elems {
+			converted, err := convertAbiValue(*t.TupleElems[i], elem)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s.%d: %v", t.String(), i, err)
+			}
+			v.Field(i).Set(converted)
+		}
+		return v, nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("unsupported parameter type: %s", t.String())
+}
+
+func toBigInt(raw interface{}) (*big.Int, error) {
+	var s string
+	switch v := raw.(type) {
+	case string:
+		s = v
+	case json.Number:
+		s = v.String()
+	default:
+		return nil, fmt.Errorf("%v is not a number", raw)
+	}
+
+	n, ok := new(big.Int).SetString(strings.TrimSpace(s), 0)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an integer", s)
+	}
+	return n, nil
+}
+
+func checkIntRange(t abi.Type, n *big.Int) error {
+	if t.T == abi.UintTy {
+		if n.Sign() < 0 || n.BitLen() > t.Size {
+			return fmt.Errorf("value %s out of range for %s", n, t.String())
+		}
+		return nil
+	}
+	limit := new(big.Int).Lsh(common.Big1, uint(t.Size-1))
+	if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+		return fmt.Errorf("value %s out of range for %s", n, t.String())
+	}
+	return nil
+}
+
+func decodeHexValue(raw interface{}) ([]byte, error) {
+	s, ok := raw.(string)
+	if !ok || !(strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")) {
+		return nil, fmt.Errorf("bytes value must start with 0x")
+	}
+	data, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid bytes: %s", s)
+	}
+	return data, nil
+}
+
+// FormatAbiValue converts an unpacked abi value into a JSON friendly form:
+// integers become decimal strings, byte values become 0x hex and tuples
+// become objects keyed by their abi field names.
+func FormatAbiValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case string, bool:
+		return v
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			var buf bytes.Buffer
+			for i := 0; i < rv.Len(); i++ {
+				buf.WriteByte(byte(rv.Index(i).Uint()))
+			}
+			return "0x" + hex.EncodeToString(buf.Bytes())
+		}
+		fallthrough
+	case reflect.Slice:
+		out := make([]interface{}, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			out[i] = FormatAbiValue(rv.Index(i).Interface())
+		}
+		return out
+	case reflect.Struct:
+		out := make(map[string]interface{}, rv.NumField())
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			name := field.Tag.Get("json")
+			if name == "" {
+				name = field.Name
+			}
+			out[name] = FormatAbiValue(rv.Field(i).Interface())
+		}
+		return out
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return FormatAbiValue(rv.Elem().Interface())
+	}
+
+	return fmt.Sprintf("%v", value)
+}
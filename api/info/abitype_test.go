@@ -0,0 +1,86 @@
+package infoHandler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// Values of every kind of type pack and unpack back to what was given.
+func TestAbiValueRoundTrip(t *testing.T) {
+	tests := []struct {
+		typeExpr string
+		raw      string
+		want     string
+	}{
+		{"uint8", "255", `"255"`},
+		{"int24", "-8388608", `"-8388608"`},
+		{"uint", "0x10", `"16"`},
+		{"bytes4", "0x095ea7b3", `"0x095ea7b3"`},
+		{"bytes", "0x", `"0x"`},
+		{"bool", "true", `true`},
+		{"uint16[2]", "[1,2]", `["1","2"]`},
+		{"address[]", `["0x04301b0c3bC192C28DD3CAF345C4aE6E979EC040"]`, `["0x04301b0c3bC192C28DD3CAF345C4aE6E979EC040"]`},
+		{"(uint256,(bool,string)[])", `[7,[[true,"a"]]]`, `{"field0":"7","field1":[{"field0":true,"field1":"a"}]}`},
+	}
+
+	for _, tt := range tests {
+		abiType, err := ParseAbiType(tt.typeExpr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.typeExpr, err)
+			continue
+		}
+		value, err := ParseAbiValue(abiType, tt.raw)
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.typeExpr, tt.raw, err)
+			continue
+		}
+		arguments := abi.Arguments{{Type: abiType}}
+		packed, err := arguments.Pack(value)
+		if err != nil {
+			t.Errorf("%s: pack: %v", tt.typeExpr, err)
+			continue
+		}
+		unpacked, err := arguments.Unpack(packed)
+		if err != nil {
+			t.Errorf("%s: unpack: %v", tt.typeExpr, err)
+			continue
+		}
+		got, _ := json.Marshal(FormatAbiValue(unpacked[0]))
+		if string(got) != tt.want {
+			t.Errorf("%s %s = %s, want %s", tt.typeExpr, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestAbiValueRejects(t *testing.T) {
+	tests := []struct {
+		typeExpr string
+		raw      string
+	}{
+		{"uint8", "256"},
+		{"uint8", "-1"},
+		{"int8", "128"},
+		{"bytes2", "0x010203"},
+		{"uint16[2]", "[1]"},
+		{"(uint256,bool)", `[1]`},
+		{"address", "0x1234"},
+	}
+
+	for _, tt := range tests {
+		abiType, err := ParseAbiType(tt.typeExpr)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.typeExpr, err)
+		}
+		if _, err := ParseAbiValue(abiType, tt.raw); err == nil {
+			t.Errorf("%s %s: want an error", tt.typeExpr, tt.raw)
+		}
+	}
+
+	for _, typeExpr := range []string{"uint7", "uint264", "bytes33", "uint256[0]", "(uint256", "()", "fixed"} {
+		if _, err := ParseAbiType(typeExpr); err == nil {
+			t.Errorf("type %s: want an error", typeExpr)
+		}
+	}
+}
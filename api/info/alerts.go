@@ -0,0 +1,507 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/ratelimit"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/FudgyDRS/valhalla-api/pkg/webhook"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	alertPendingAbove   = "pending-above"
+	alertStakeDrop      = "stake-drop"
+	alertPoolChanged    = "pool-changed"
+	alertEndApproaching = "end-approaching"
+
+	alertEventPrefix = "alert."
+	alertPingEvent   = "ping"
+
+	defaultAlertInterval     = 15 * time.Second
+	defaultAlertStakeWindow  = time.Hour
+	defaultAlertEndBefore    = 24 * time.Hour
+	defaultAlertMaxPerClient = 20
+	maxAlertConditions       = 10
+	maxAlertBodySize         = 64 << 10
+	minAlertSecretLength     = 16
+)
+
+// alertConditionTypes documents the fields each condition type uses.
+//
+//	pending-above    pendingVAL of user in pool-id reaches threshold, fires
+//	                 again after dropping below it
+//	stake-drop       LP staked in pool-id falls drop-percent below its peak
+//	                 over window (default 1h)
+//	pool-changed     allocPoint or depFee of pool-id changes
+//	end-approaching  poolEndTime is less than before (default 24h) away
+var alertConditionTypes = map[string]bool{
+	alertPendingAbove:   true,
+	alertStakeDrop:      true,
+	alertPoolChanged:    true,
+	alertEndApproaching: true,
+}
+
+// alertService holds the subscriptions, the webhook dispatcher and the
+// evaluator. It only runs in the standalone server, see StartAlerts.
+type alertService struct {
+	store      *alertStore
+	dispatcher *webhook.Dispatcher
+	evaluator  *alertEvaluator
+}
+
+var alerts *alertService
+
+// StartAlerts loads the subscriptions from ALERTS_STORE_FILE, in memory only
+// when unset, and evaluates them every ALERTS_INTERVAL. Call it before
+// serving. Vercel never runs it, so the alert routes answer 404 there.
+func StartAlerts() error {
+	config, err := webhook.LoadConfig()
+	if err != nil {
+		return err
+	}
+	config.OnResult = func(delivery webhook.Delivery) {
+		outcome := delivery.Status
+		if outcome == webhook.StatusPending {
+			outcome = "retry"
+		}
+		metrics.WebhookAttempt(outcome)
+	}
+
+	store, err := loadAlertStore(os.Getenv("ALERTS_STORE_FILE"))
+	if err != nil {
+		return err
+	}
+
+	service := &alertService{store: store, dispatcher: webhook.New(config)}
+	service.evaluator = newAlertEvaluator(service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		service.evaluator.run(ctx, envDuration("ALERTS_INTERVAL", defaultAlertInterval))
+	}()
+
+	OnShutdown(func(ctx context.Context) error {
+		cancel()
+		<-done
+		return service.dispatcher.Close(ctx)
+	})
+
+	alerts = service
+	logrus.WithFields(logrus.Fields{"subscriptions": store.count(), "file": store.path}).Info("webhook alerts started")
+	return nil
+}
+
+//curl -X POST http://localhost:8080/api/v1/alerts -H "X-API-Key: ..." -d '{"url":"https://bot.example.com/hook","conditions":[{"type":"pending-above","chain-id":"146","genesis":"0x23Ee13d49e78811d063722D9228547a7dF73E42E","pool-id":"0","user":"0x...","threshold":"1000000000000000000"}]}'
+
+// alertRoutes are served under /api/v1/ by restMux. Subscriptions belong to
+// the API key that created them, anonymous clients cannot use them.
+var alertRoutes = map[string]func(w http.ResponseWriter, r *http.Request, service *alertService, owner string){
+	"POST /api/v1/alerts":                createAlert,
+	"GET /api/v1/alerts":                 listAlerts,
+	"GET /api/v1/alerts/{id}":            getAlert,
+	"DELETE /api/v1/alerts/{id}":         deleteAlert,
+	"GET /api/v1/alerts/{id}/deliveries": listAlertDeliveries,
+	"POST /api/v1/alerts/{id}/test":      testAlert,
+}
+
+func serveAlerts(handler func(w http.ResponseWriter, r *http.Request, service *alertService, owner string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		service := alerts
+		if service == nil {
+			HandleResponse(w, r, nil, utils.ErrNotFound("webhook alerts are not enabled on this deployment"))
+			return
+		}
+		client, ok := r.Context().Value(clientKey{}).(ratelimit.Client)
+		if !ok || client.Tier == ratelimit.AnonymousTier {
+			HandleResponse(w, r, nil, utils.ErrUnauthorized("webhook alerts require an API key"))
+			return
+		}
+		if err := limitRequest(w, r, 1); err != nil {
+			HandleResponse(w, r, nil, err)
+			return
+		}
+		handler(w, r, service, client.Name)
+	}
+}
+
+func createAlert(w http.ResponseWriter, r *http.Request, service *alertService, owner string) {
+	var request CreateAlertRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAlertBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		HandleResponse(w, r, nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid alert body: %v", err)))
+		return
+	}
+
+	response, err := runQuery(r, "create-alert", func(r *http.Request) (interface{}, error) {
+		return service.create(r.Context(), owner, request)
+	})
+	if err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	HandleResponse(w, r, response, nil)
+}
+
+func listAlerts(w http.ResponseWriter, r *http.Request, service *alertService, owner string) {
+	subscriptions := service.store.list(owner)
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	HandleResponse(w, r, subscriptions, nil)
+}
+
+func getAlert(w http.ResponseWriter, r *http.Request, service *alertService, owner string) {
+	subscription, err := service.store.get(owner, r.PathValue("id"))
+	if err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+	subscription.Secret = ""
+	HandleResponse(w, r, subscription, nil)
+}
+
+func deleteAlert(w http.ResponseWriter, r *http.Request, service *alertService, owner string) {
+	id := r.PathValue("id")
+	if err := service.store.delete(owner, id); err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+	service.dispatcher.Forget(id)
+	utils.Log(r.Context()).WithFields(logrus.Fields{"subscription": id, "owner": owner}).Info("alert subscription deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func listAlertDeliveries(w http.ResponseWriter, r *http.Request, service *alertService, owner string) {
+	subscription, err := service.store.get(owner, r.PathValue("id"))
+	if err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+	HandleResponse(w, r, service.dispatcher.Deliveries(subscription.Id), nil)
+}
+
+// testAlert sends a ping so integrators can check their endpoint and
+// signature verification without waiting for a condition.
+func testAlert(w http.ResponseWriter, r *http.Request, service *alertService, owner string) {
+	subscription, err := service.store.get(owner, r.PathValue("id"))
+	if err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+	delivery, err := service.dispatcher.Send(subscription.target(), alertPingEvent, AlertEvent{
+		Event:        alertPingEvent,
+		Subscription: subscription.Id,
+		Condition:    -1,
+		Message:      "test delivery",
+		TriggeredAt:  time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		HandleResponse(w, r, nil, utils.ErrInternal(err.Error()))
+		return
+	}
+	HandleResponse(w, r, delivery, nil)
+}
+
+func (s AlertSubscription) target() webhook.Target {
+	return webhook.Target{Subscription: s.Id, URL: s.Url, Secret: s.Secret}
+}
+
+func (service *alertService) create(ctx context.Context, owner string, request CreateAlertRequest) (AlertSubscription, error) {
+	if err := webhook.ValidateURL(request.Url); err != nil {
+		return AlertSubscription{}, utils.ErrMalformedRequest(err.Error())
+	}
+	if len(request.Conditions) == 0 || len(request.Conditions) > maxAlertConditions {
+		return AlertSubscription{}, utils.ErrMalformedRequest(fmt.Sprintf("an alert needs between 1 and %d conditions", maxAlertConditions))
+	}
+	if request.Secret != "" && len(request.Secret) < minAlertSecretLength {
+		return AlertSubscription{}, utils.ErrMalformedRequest(fmt.Sprintf("secret must be at least %d characters", minAlertSecretLength))
+	}
+	if limit := alertMaxPerClient(); service.store.countOwner(owner) >= limit {
+		return AlertSubscription{}, utils.ErrMalformedRequest(fmt.Sprintf("limit of %d alert subscriptions reached, delete one first", limit))
+	}
+
+	for i := range request.Conditions {
+		if err := validateAlertCondition(ctx, &request.Conditions[i]); err != nil {
+			return AlertSubscription{}, fmt.Errorf("condition %d: %w", i, err)
+		}
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhook.NewSecret(); err != nil {
+			return AlertSubscription{}, utils.ErrInternal(err.Error())
+		}
+	}
+	id, err := newAlertId()
+	if err != nil {
+		return AlertSubscription{}, utils.ErrInternal(err.Error())
+	}
+
+	subscription := AlertSubscription{
+		Id:          id,
+		Owner:       owner,
+		Url:         request.Url,
+		Secret:      secret,
+		Description: request.Description,
+		Conditions:  request.Conditions,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := service.store.add(subscription); err != nil {
+		return AlertSubscription{}, err
+	}
+	logrus.WithFields(logrus.Fields{"subscription": id, "owner": owner, "conditions": len(subscription.Conditions)}).Info("alert subscription created")
+	return subscription, nil
+}
+
+func newAlertId() (string, error) {
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return "", err
+	}
+	return "alrt_" + secret[:12], nil
+}
+
+// validateAlertCondition checks a condition against the chain and normalises
+// it: addresses are checksummed, defaults are filled in and stake-drop
+// records the staked token of its pool.
+func validateAlertCondition(ctx context.Context, condition *AlertCondition) error {
+	if !alertConditionTypes[condition.Type] {
+		return utils.ErrMalformedRequest(fmt.Sprintf("unknown condition type %q, expected pending-above, stake-drop, pool-changed or end-approaching", condition.Type))
+	}
+	if _, err := GetChainInfo(condition.ChainId); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(condition.Genesis) {
+		return utils.ErrInvalidAddress(fmt.Sprintf("invalid genesis address %s", condition.Genesis))
+	}
+	genesis := common.HexToAddress(condition.Genesis)
+	condition.Genesis = genesis.Hex()
+
+	if condition.Type == alertEndApproaching {
+		before, err := parseAlertDuration(condition.Before, defaultAlertEndBefore, "before")
+		if err != nil {
+			return err
+		}
+		condition.Before = before.String()
+		return nil
+	}
+
+	pid, err := strconv.ParseUint(condition.PoolId, 10, 64)
+	if err != nil {
+		return utils.ErrMalformedRequest(fmt.Sprintf("invalid pool-id %q", condition.PoolId))
+	}
+	pools, err := fetchGenesisPools(ctx, condition.ChainId, genesis)
+	if err != nil {
+		return err
+	}
+	if pid >= uint64(len(pools)) {
+		return utils.ErrNotFound(fmt.Sprintf("pid %d does not exist (pool length %d)", pid, len(pools)))
+	}
+
+	switch condition.Type {
+	case alertPendingAbove:
+		if !common.IsHexAddress(condition.User) {
+			return utils.ErrInvalidAddress(fmt.Sprintf("invalid user address %s", condition.User))
+		}
+		condition.User = common.HexToAddress(condition.User).Hex()
+		threshold, ok := new(big.Int).SetString(condition.Threshold, 10)
+		if !ok || threshold.Sign() <= 0 {
+			return utils.ErrMalformedRequest(fmt.Sprintf("invalid threshold %q, expected a positive amount in wei", condition.Threshold))
+		}
+	case alertStakeDrop:
+		if condition.DropPercent <= 0 || condition.DropPercent > 100 {
+			return utils.ErrMalformedRequest("drop-percent must be above 0 and at most 100")
+		}
+		window, err := parseAlertDuration(condition.Window, defaultAlertStakeWindow, "window")
+		if err != nil {
+			return err
+		}
+		condition.Window = window.String()
+		condition.Token = pools[pid].Token.Hex()
+	}
+	return nil
+}
+
+func parseAlertDuration(value string, fallback time.Duration, field string) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < time.Minute || duration > 30*24*time.Hour {
+		return 0, utils.ErrMalformedRequest(fmt.Sprintf("invalid %s %q, expected a duration between 1m and 720h", field, value))
+	}
+	return duration, nil
+}
+
+func alertMaxPerClient() int {
+	value := os.Getenv("ALERTS_MAX_PER_CLIENT")
+	if value == "" {
+		return defaultAlertMaxPerClient
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		logrus.WithField("value", value).Warn("ignoring invalid ALERTS_MAX_PER_CLIENT")
+		return defaultAlertMaxPerClient
+	}
+	return n
+}
+
+// alertStore keeps subscriptions in memory and, when path is set, rewrites
+// the whole file on every change. The file holds the signing secrets.
+type alertStore struct {
+	mu            sync.Mutex
+	path          string
+	subscriptions map[string]AlertSubscription
+}
+
+func loadAlertStore(path string) (*alertStore, error) {
+	store := &alertStore{path: path, subscriptions: make(map[string]AlertSubscription)}
+	if path == "" {
+		return store, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert store: %v", err)
+	}
+	var subscriptions []AlertSubscription
+	if err := json.Unmarshal(data, &subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to parse alert store %s: %v", path, err)
+	}
+	for _, subscription := range subscriptions {
+		store.subscriptions[subscription.Id] = subscription
+	}
+	return store, nil
+}
+
+// save writes a temporary file then renames it, so a crash never leaves a
+// truncated store. s.mu must be held.
+func (s *alertStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create alert store directory: %v", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write alert store: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace alert store: %v", err)
+	}
+	return nil
+}
+
+// sorted lists subscriptions oldest first, s.mu must be held.
+func (s *alertStore) sorted() []AlertSubscription {
+	subscriptions := make([]AlertSubscription, 0, len(s.subscriptions))
+	for _, subscription := range s.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if subscriptions[i].CreatedAt != subscriptions[j].CreatedAt {
+			return subscriptions[i].CreatedAt < subscriptions[j].CreatedAt
+		}
+		return subscriptions[i].Id < subscriptions[j].Id
+	})
+	return subscriptions
+}
+
+func (s *alertStore) add(subscription AlertSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscriptions[subscription.Id] = subscription
+	if err := s.save(); err != nil {
+		delete(s.subscriptions, subscription.Id)
+		return utils.ErrInternal(err.Error())
+	}
+	return nil
+}
+
+func (s *alertStore) delete(owner string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscription, ok := s.subscriptions[id]
+	if !ok || subscription.Owner != owner {
+		return utils.ErrNotFound(fmt.Sprintf("alert subscription %s not found", id))
+	}
+	delete(s.subscriptions, id)
+	if err := s.save(); err != nil {
+		s.subscriptions[id] = subscription
+		return utils.ErrInternal(err.Error())
+	}
+	return nil
+}
+
+// get hides other owners' subscriptions as not found.
+func (s *alertStore) get(owner string, id string) (AlertSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscription, ok := s.subscriptions[id]
+	if !ok || subscription.Owner != owner {
+		return AlertSubscription{}, utils.ErrNotFound(fmt.Sprintf("alert subscription %s not found", id))
+	}
+	return subscription, nil
+}
+
+func (s *alertStore) list(owner string) []AlertSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	subscriptions := []AlertSubscription{}
+	for _, subscription := range s.sorted() {
+		if subscription.Owner == owner {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	return subscriptions
+}
+
+func (s *alertStore) all() []AlertSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sorted()
+}
+
+func (s *alertStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscriptions)
+}
+
+func (s *alertStore) countOwner(owner string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, subscription := range s.subscriptions {
+		if subscription.Owner == owner {
+			n++
+		}
+	}
+	return n
+}
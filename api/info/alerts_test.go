@@ -0,0 +1,148 @@
+package infoHandler
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+)
+
+// A key sees, and can delete, only its own subscriptions.
+func TestAlertStoreOwners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "alerts.json")
+	store, err := loadAlertStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.add(AlertSubscription{Id: "alrt_a", Owner: "alice", CreatedAt: "2024-01-01T00:00:00Z"})
+	store.add(AlertSubscription{Id: "alrt_b", Owner: "bob", CreatedAt: "2024-01-02T00:00:00Z"})
+
+	if list := store.list("alice"); len(list) != 1 || list[0].Id != "alrt_a" {
+		t.Errorf("alice lists %+v", list)
+	}
+	if store.countOwner("alice") != 1 || store.countOwner("carol") != 0 {
+		t.Errorf("counts %d %d", store.countOwner("alice"), store.countOwner("carol"))
+	}
+	if _, err := store.get("alice", "alrt_b"); utils.AsError(err).Type != utils.CodeNotFound {
+		t.Errorf("alice got bob's subscription: %v", err)
+	}
+	if err := store.delete("alice", "alrt_b"); utils.AsError(err).Type != utils.CodeNotFound {
+		t.Errorf("alice deleted bob's subscription: %v", err)
+	}
+
+	reloaded, err := loadAlertStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subscription, err := reloaded.get("bob", "alrt_b"); err != nil || subscription.Owner != "bob" {
+		t.Errorf("bob's subscription after reload: %+v %v", subscription, err)
+	}
+	if err := reloaded.delete("bob", "alrt_b"); err != nil {
+		t.Fatal(err)
+	}
+	if list := reloaded.list("bob"); len(list) != 0 {
+		t.Errorf("bob lists %+v after delete", list)
+	}
+}
+
+func packUint(t *testing.T, abiName, method string, value int64) []byte {
+	t.Helper()
+	data, err := Abis.MustContract(abiName).Methods[method].Outputs.Pack(big.NewInt(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// pending-above fires once when crossing the threshold and again only after
+// dropping below it.
+func TestAlertPendingAbove(t *testing.T) {
+	condition := AlertCondition{Type: alertPendingAbove, PoolId: "0", User: testUser.Hex(), Threshold: "100"}
+	state := &alertState{}
+	now := time.Now()
+
+	for i, step := range []struct {
+		pending int64
+		fired   bool
+	}{
+		{50, false},
+		{100, true},
+		{150, false},
+		{99, false},
+		{120, true},
+	} {
+		_, fired, err := checkAlertCondition(condition, state, packUint(t, AbiGenesis, "pendingVAL", step.pending), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fired != step.fired {
+			t.Errorf("step %d, pending %d: fired %v, want %v", i, step.pending, fired, step.fired)
+		}
+	}
+}
+
+// stake-drop compares with the peak of the window only, and reports a drop
+// once.
+func TestAlertStakeDrop(t *testing.T) {
+	condition := AlertCondition{Type: alertStakeDrop, PoolId: "0", DropPercent: 20, Window: "1h0m0s"}
+	state := &alertState{}
+	start := time.Now()
+
+	for i, step := range []struct {
+		after  time.Duration
+		staked int64
+		fired  bool
+	}{
+		{0, 1000, false},
+		{30 * time.Minute, 900, false},
+		// The peak of 1000 left the window, 900 to 750 is less than 20%
+		{61 * time.Minute, 750, false},
+		{90 * time.Minute, 700, true},
+		// A new window starts at 700
+		{100 * time.Minute, 690, false},
+	} {
+		event, fired, err := checkAlertCondition(condition, state, packUint(t, AbiErc20, "balanceOf", step.staked), start.Add(step.after))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fired != step.fired {
+			t.Errorf("step %d, staked %d: fired %v, want %v", i, step.staked, fired, step.fired)
+		}
+		if fired && (event.Values["peak"] != "900" || event.Values["drop-percent"] != "22.22") {
+			t.Errorf("event values = %v", event.Values)
+		}
+	}
+}
+
+// end-approaching fires once within before of the end, and re-arms when the
+// end is moved back out of range.
+func TestAlertEndApproaching(t *testing.T) {
+	condition := AlertCondition{Type: alertEndApproaching, Before: "24h0m0s"}
+	state := &alertState{}
+	now := time.Unix(1700000000, 0)
+
+	for i, step := range []struct {
+		end   time.Duration
+		fired bool
+	}{
+		{48 * time.Hour, false},
+		{12 * time.Hour, true},
+		{11 * time.Hour, false},
+		// Extended, then approaching again
+		{72 * time.Hour, false},
+		{time.Hour, true},
+		// Ended
+		{-time.Hour, false},
+	} {
+		data := packUint(t, AbiGenesis, "poolEndTime", now.Add(step.end).Unix())
+		_, fired, err := checkAlertCondition(condition, state, data, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fired != step.fired {
+			t.Errorf("step %d, end in %s: fired %v, want %v", i, step.end, fired, step.fired)
+		}
+	}
+}
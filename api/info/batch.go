@@ -0,0 +1,281 @@
+package infoHandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	MaxBatchSize     = 50
+	maxBatchBodySize = 1 << 20
+)
+
+// Execute runs the plan on its own, as a single multicall.
+func (plan *MulticallPlan) Execute(ctx context.Context) (interface{}, error) {
+	responses, errs := ExecuteMulticallPlans(ctx, []*MulticallPlan{plan})
+	return responses[0], errs[0]
+}
+
+// ExecuteMulticallPlans merges every plan for the same chain, however its id
+// is spelled, into a single multicall, so all of them read the same block,
+// then hands each plan back its slice of the results. Responses and errors
+// are returned in plan order.
+func ExecuteMulticallPlans(ctx context.Context, plans []*MulticallPlan) ([]interface{}, []error) {
+	responses := make([]interface{}, len(plans))
+	errs := make([]error, len(plans))
+
+	byChain := make(map[string][]int)
+	for i, plan := range plans {
+		chainId := canonicalChainId(plan.ChainId)
+		byChain[chainId] = append(byChain[chainId], i)
+	}
+
+	var wg sync.WaitGroup
+	for chainId, indexes := range byChain {
+		wg.Add(1)
+		go func(chainId string, indexes []int) {
+			defer wg.Done()
+
+			fail := func(err error) {
+				for _, i := range indexes {
+					errs[i] = err
+				}
+			}
+			defer func() {
+				if rec := recover(); rec != nil {
+					utils.Log(ctx).WithFields(logrus.Fields{"panic": fmt.Sprint(rec), "stack": string(debug.Stack())}).Error("recovered from panic executing multicall plans")
+					fail(utils.ErrInternal(fmt.Sprintf("panic: %v", rec)))
+				}
+			}()
+
+			client, err := GetClientForChain(ctx, chainId)
+			if err != nil {
+				fail(err)
+				return
+			}
+			multicallAddress, err := getMulticallAddress(chainId)
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			var calls []Calls
+			for _, i := range indexes {
+				calls = append(calls, plans[i].Calls...)
+			}
+			utils.Log(ctx).WithFields(logrus.Fields{"chain_id": chainId, "plans": len(indexes), "calls": len(calls)}).Debug("merged multicall plans")
+
+			results, err := MulticallView(ctx, client, multicallAddress, calls)
+			if err != nil {
+				fail(fmt.Errorf("multicall view failed: %w", err))
+				return
+			}
+			if len(results) != len(calls) {
+				fail(utils.ErrInternal(fmt.Sprintf("expected %d multicall results, got %d", len(calls), len(results))))
+				return
+			}
+
+			_, span := tracing.Start(ctx, "plans.decode", attribute.Int("plans", len(indexes)))
+			defer span.End()
+
+			offset := 0
+			for _, i := range indexes {
+				count := len(plans[i].Calls)
+				responses[i], errs[i] = plans[i].Decode(results[offset : offset+count])
+				offset += count
+			}
+		}(chainId, indexes)
+	}
+	wg.Wait()
+
+	return responses, errs
+}
+
+func HandleBatch(w http.ResponseWriter, r *http.Request) {
+	var items []BatchRequestItem
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodySize))
+	decoder.UseNumber()
+	if err := decoder.Decode(&items); err != nil {
+		HandleResponse(w, r, nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid batch body: %v", err)))
+		return
+	}
+	if len(items) == 0 || len(items) > MaxBatchSize {
+		HandleResponse(w, r, nil, utils.ErrMalformedRequest(fmt.Sprintf("batch must contain between 1 and %d requests", MaxBatchSize)))
+		return
+	}
+
+	// A batch costs what its items would cost sent one by one
+	cost := 0
+	for _, item := range items {
+		values := url.Values{}
+		if err := flattenParams(values, "", item.Params); err != nil {
+			cost++
+			continue
+		}
+		cost += queryCost(item.Query, values)
+	}
+	if err := limitRequest(w, r, cost); err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+
+	response, err := runQuery(r, "batch", func(r *http.Request) (interface{}, error) {
+		return ExecuteBatch(r, items), nil
+	})
+	HandleResponse(w, r, response, err)
+}
+
+// ExecuteBatch runs every item of a batch. Multicall backed queries are merged
+// per chain, everything else runs concurrently on its own.
+func ExecuteBatch(r *http.Request, items []BatchRequestItem) []BatchResponseItem {
+	responses := make([]BatchResponseItem, len(items))
+
+	var plans []*MulticallPlan
+	var planIndexes []int
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		responses[i].Id = item.Id
+
+		itemRequest, err := newBatchItemRequest(r, item)
+		if err != nil {
+			responses[i].Error = batchError(utils.ErrMalformedRequest(err.Error()))
+			continue
+		}
+
+		if planner, ok := multicallPlanners[item.Query]; ok {
+			plan, err := planner(itemRequest)
+			if err != nil {
+				responses[i].Error = batchError(err)
+				continue
+			}
+			plans = append(plans, plan)
+			planIndexes = append(planIndexes, i)
+			continue
+		}
+
+		route, ok := queryRoutes[item.Query]
+		if !ok {
+			responses[i].Error = batchError(utils.ErrMalformedRequest(fmt.Sprintf("invalid query: %s", item.Query)))
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, query string, handler QueryHandler, itemRequest *http.Request) {
+			defer wg.Done()
+			defer func() {
+				if rec := recover(); rec != nil {
+					responses[i].Error = batchError(utils.ErrInternal(fmt.Sprintf("panic: %v", rec)))
+				}
+			}()
+
+			result, err := runQuery(itemRequest, query, handler)
+			if err != nil {
+				responses[i].Error = batchError(err)
+				return
+			}
+			responses[i].Result = result
+		}(i, item.Query, route.Handler, itemRequest)
+	}
+
+	if len(plans) > 0 {
+		results, errs := ExecuteMulticallPlans(r.Context(), plans)
+		for j, i := range planIndexes {
+			if errs[j] != nil {
+				responses[i].Error = batchError(errs[j])
+				continue
+			}
+			responses[i].Result = results[j]
+		}
+	}
+	wg.Wait()
+
+	return responses
+}
+
+// newBatchItemRequest turns a batch item into a request the single-query
+// handlers understand: params become the query string (objects inside arrays
+// flatten to "key.field", as with pools.address) and are also sent as the body.
+func newBatchItemRequest(r *http.Request, item BatchRequestItem) (*http.Request, error) {
+	if item.Query == "" {
+		return nil, fmt.Errorf("missing query")
+	}
+
+	values := url.Values{}
+	if err := flattenParams(values, "", item.Params); err != nil {
+		return nil, err
+	}
+	values.Set("query", item.Query)
+
+	body, err := json.Marshal(item.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	itemURL := *r.URL
+	itemURL.RawQuery = values.Encode()
+	itemRequest, err := http.NewRequestWithContext(r.Context(), http.MethodPost, itemURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	itemRequest.Header = r.Header.Clone()
+
+	return itemRequest, nil
+}
+
+func flattenParams(values url.Values, prefix string, value interface{}) error {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := flattenParams(values, join(key), v[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, elem := range v {
+			if err := flattenParams(values, prefix, elem); err != nil {
+				return err
+			}
+		}
+	case string:
+		values.Add(prefix, v)
+	case json.Number:
+		values.Add(prefix, v.String())
+	case bool:
+		values.Add(prefix, fmt.Sprint(v))
+	default:
+		return fmt.Errorf("unsupported value for %s", prefix)
+	}
+
+	return nil
+}
+
+func batchError(err error) interface{} {
+	return utils.AsError(err)
+}
@@ -0,0 +1,98 @@
+package infoHandler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testGenesis = "0x23Ee13d49e78811d063722D9228547a7dF73E42E"
+
+func decodeBatch(t *testing.T, body string) []BatchRequestItem {
+	t.Helper()
+	var items []BatchRequestItem
+	decoder := json.NewDecoder(strings.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&items); err != nil {
+		t.Fatalf("invalid batch: %v", err)
+	}
+	return items
+}
+
+func TestBatchOperatorBatchItem(t *testing.T) {
+	chain := newFakeChain(t)
+	chain.call = genesisCall(common.HexToAddress(testGenesis), 3)
+
+	items := decodeBatch(t, `[{"id": 1, "query": "build-operator-batch", "params": {
+		"chain-id": "146",
+		"genesis": "`+testGenesis+`",
+		"bulk": true,
+		"changes": [{"action": "set", "pid": "1", "allocPoint": "010"}]
+	}}]`)
+	responses := ExecuteBatch(httptest.NewRequest("POST", "/api/info", nil), items)
+
+	if responses[0].Error != nil {
+		t.Fatalf("batch item failed: %+v", responses[0].Error)
+	}
+	batch := responses[0].Result.(BuildOperatorBatchResponse)
+	if len(batch.Diff) != 1 || batch.Diff[0].Field != "allocPoint" || batch.Diff[0].To != "10" {
+		t.Errorf("diff = %+v, want allocPoint 1 -> 10", batch.Diff)
+	}
+}
+
+func TestBatchItemRejectsUnknownFields(t *testing.T) {
+	items := decodeBatch(t, `[{"query": "get-genesis-balances", "params": {"chain-id": "146", "genesis": "`+testGenesis+`", "bogus": "1"}}]`)
+	responses := ExecuteBatch(httptest.NewRequest("POST", "/api/info", nil), items)
+
+	data, _ := json.Marshal(responses[0].Error)
+	if !strings.Contains(string(data), "Unknown fields: bogus") {
+		t.Errorf("error = %s, want the unknown field", data)
+	}
+}
+
+// A batched get-genesis-balances without pools reads every pool, past the
+// limit of a caller's list.
+func TestBatchGenesisBalancesOfEveryPool(t *testing.T) {
+	const pools = 60
+	newFakeChain(t).call = genesisCall(common.HexToAddress(testGenesis), pools)
+
+	items := decodeBatch(t, `[{"query": "get-genesis-balances", "params": {"chain-id": "146", "genesis": "`+testGenesis+`"}}]`)
+	responses := ExecuteBatch(httptest.NewRequest("POST", "/api/info", nil), items)
+
+	if responses[0].Error != nil {
+		t.Fatalf("batch item failed: %+v", responses[0].Error)
+	}
+	balances := responses[0].Result.(GetGenesisBalancesResponse)
+	if len(balances.Pools) != pools || balances.Pools[pools-1].GenesisBalance != "60" {
+		t.Errorf("got %d pools, want %d", len(balances.Pools), pools)
+	}
+}
+
+// Items of the same chain share one multicall, whichever way their chain id
+// is spelled.
+func TestBatchMergesPlansOfAChain(t *testing.T) {
+	chain := newFakeChain(t)
+	chain.call = genesisCall(common.HexToAddress(testGenesis), 3)
+
+	items := decodeBatch(t, `[
+		{"id": 1, "query": "get-genesis-balances", "params": {"chain-id": "146", "genesis": "`+testGenesis+`", "pools": [{"address": "`+fakeToken(0).Hex()+`", "pid": "0"}]}},
+		{"id": 2, "query": "get-genesis-balances", "params": {"chain-id": "0x92", "genesis": "`+testGenesis+`", "pools": [{"address": "`+fakeToken(2).Hex()+`", "pid": "2"}]}}
+	]`)
+	responses := ExecuteBatch(httptest.NewRequest("POST", "/api/info", nil), items)
+
+	for i, want := range []string{"1", "3"} {
+		if responses[i].Error != nil {
+			t.Fatalf("item %d failed: %+v", i, responses[i].Error)
+		}
+		balances := responses[i].Result.(GetGenesisBalancesResponse)
+		if len(balances.Pools) != 1 || balances.Pools[0].GenesisBalance != want {
+			t.Errorf("item %d pools = %+v, want a balance of %s", i, balances.Pools, want)
+		}
+	}
+	if len(chain.blocks) != 1 {
+		t.Errorf("sent %d multicalls, want 1", len(chain.blocks))
+	}
+}
@@ -0,0 +1,278 @@
+package infoHandler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
+)
+
+// A provider is marked unhealthy after this many failed requests in a row and
+// healthy again after its next success.
+const unhealthyAfterFailures = 3
+
+type rpcProvider struct {
+	chainId string
+	url     string
+	// transport sends a request to this provider only
+	transport http.RoundTripper
+	// client sends to this provider first and fails over to the others
+	client *ethclient.Client
+	// probe sends to this provider only, for readiness checks
+	probe    *ethclient.Client
+	failures atomic.Int32
+}
+
+func (p *rpcProvider) healthy() bool {
+	return p.failures.Load() < unhealthyAfterFailures
+}
+
+// clientPool keeps one client per RPC provider, dialed on first use, instead
+// of dialing on every request.
+type clientPool struct {
+	mu        sync.Mutex
+	providers map[string][]*rpcProvider
+}
+
+var rpcClients = &clientPool{providers: make(map[string][]*rpcProvider)}
+
+func init() {
+	OnShutdown(func(context.Context) error {
+		rpcClients.close()
+		return nil
+	})
+}
+
+func shuffle[T any](items []T) []T {
+	shuffled := make([]T, len(items))
+	copy(shuffled, items)
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	r.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+// GetClientForChain returns a pooled client for a random healthy provider of
+// the chain. When every provider is unhealthy one is still returned so that a
+// recovered provider can be noticed. A request the provider fails, see
+// failoverTransport, is sent again to the other healthy providers.
+func GetClientForChain(ctx context.Context, chainId string) (*ethclient.Client, error) {
+	chain, err := GetChainInfo(chainId)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, utils.ErrTimeout(fmt.Errorf("getting client for chain %s: %w", chainId, ctx.Err()))
+	}
+
+	providers := rpcClients.get(chain)
+	if len(providers) == 0 {
+		return nil, utils.ErrRpcUnavailable(fmt.Errorf("no RPC could be dialed for chain %s", chainId))
+	}
+
+	providers = shuffle(providers)
+	for _, provider := range providers {
+		if provider.healthy() {
+			return provider.client, nil
+		}
+	}
+	utils.Log(ctx).WithFields(logrus.Fields{"chain_id": chainId, "provider": metrics.ProviderLabel(providers[0].url)}).Warn("no healthy RPC, using any provider")
+	return providers[0].client, nil
+}
+
+func (pool *clientPool) get(chain ChainInfo) []*rpcProvider {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if providers, ok := pool.providers[chain.ID]; ok {
+		metrics.CacheHit("rpc_client")
+		return providers
+	}
+	metrics.CacheMiss("rpc_client")
+
+	var providers []*rpcProvider
+	var failovers []*failoverTransport
+	for _, rawURL := range chain.RPC {
+		provider := &rpcProvider{chainId: chain.ID, url: rawURL}
+		provider.transport = tracing.RoundTripper(chain.ID, rawURL, metrics.RoundTripper(chain.ID, rawURL, http.DefaultTransport, provider.record))
+		failover := &failoverTransport{first: provider}
+		rpcClient, err := rpc.DialOptions(context.Background(), rawURL, rpc.WithHTTPClient(&http.Client{Transport: failover}))
+		if err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"chain_id": chain.ID, "provider": metrics.ProviderLabel(rawURL)}).Warn("RPC could not be dialed")
+			continue
+		}
+		probeClient, err := rpc.DialOptions(context.Background(), rawURL, rpc.WithHTTPClient(&http.Client{Transport: provider.transport}))
+		if err != nil {
+			rpcClient.Close()
+			logrus.WithError(err).WithFields(logrus.Fields{"chain_id": chain.ID, "provider": metrics.ProviderLabel(rawURL)}).Warn("RPC could not be dialed")
+			continue
+		}
+		provider.client = ethclient.NewClient(rpcClient)
+		provider.probe = ethclient.NewClient(probeClient)
+		metrics.SetProviderHealth(chain.ID, rawURL, true)
+		providers = append(providers, provider)
+		failovers = append(failovers, failover)
+	}
+	for _, failover := range failovers {
+		failover.providers = providers
+	}
+	metrics.SetPoolHealth(chain.ID, len(providers))
+
+	// A chain none of whose RPCs could be dialed is dialed again on its next
+	// request rather than failing until a restart
+	if len(providers) > 0 {
+		pool.providers[chain.ID] = providers
+	}
+	return providers
+}
+
+// failoverTransport sends a JSON-RPC request to its first provider, then to
+// the other healthy ones while the provider fails it: a transport error, a
+// 429 or 5xx status, or no answer in its share of the deadline. Each provider
+// but the last may use half the time left, so one that hangs leaves time for
+// the next. JSON-RPC errors such as reverts come back as 200 and are final.
+type failoverTransport struct {
+	first     *rpcProvider
+	providers []*rpcProvider
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	order := []*rpcProvider{t.first}
+	for _, provider := range shuffle(t.providers) {
+		if provider != t.first && provider.healthy() {
+			order = append(order, provider)
+		}
+	}
+
+	for i := 0; ; i++ {
+		provider := order[i]
+		last := i == len(order)-1
+		ctx, cancel := req.Context(), context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok && !last {
+			ctx, cancel = context.WithDeadline(ctx, time.Now().Add(time.Until(deadline)/2))
+		}
+
+		attempt, err := providerRequest(req.WithContext(ctx), provider.url, body)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp, err := provider.transport.RoundTrip(attempt)
+		failed := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if last || !failed || req.Context().Err() != nil {
+			if resp != nil {
+				resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			} else {
+				cancel()
+			}
+			return resp, err
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+		cancel()
+		logrus.WithFields(logrus.Fields{"chain_id": provider.chainId, "provider": metrics.ProviderLabel(provider.url), "next": metrics.ProviderLabel(order[i+1].url)}).Debug("RPC request failed, failing over")
+	}
+}
+
+// providerRequest is req sent to rawURL with a fresh copy of body.
+func providerRequest(req *http.Request, rawURL string, body []byte) (*http.Request, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	attempt := req.Clone(req.Context())
+	attempt.URL = target
+	attempt.Host = ""
+	attempt.Body = io.NopCloser(bytes.NewReader(body))
+	attempt.ContentLength = int64(len(body))
+	attempt.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return attempt, nil
+}
+
+// cancelOnClose keeps the context of an attempt alive until its response
+// body has been read.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// record tracks consecutive failures and keeps the health gauges current.
+func (p *rpcProvider) record(ok bool) {
+	wasHealthy := p.healthy()
+	if ok {
+		p.failures.Store(0)
+	} else {
+		p.failures.Add(1)
+	}
+	if p.healthy() == wasHealthy {
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{"chain_id": p.chainId, "provider": metrics.ProviderLabel(p.url), "healthy": p.healthy()}).Warn("RPC health changed")
+	metrics.SetProviderHealth(p.chainId, p.url, p.healthy())
+	metrics.SetPoolHealth(p.chainId, rpcClients.healthyCount(p.chainId))
+}
+
+// close closes every pooled client. Clients are dialed again if the pool is
+// used afterwards.
+func (pool *clientPool) close() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for chainId, providers := range pool.providers {
+		for _, provider := range providers {
+			provider.client.Close()
+			provider.probe.Close()
+		}
+		metrics.SetPoolHealth(chainId, 0)
+	}
+	pool.providers = make(map[string][]*rpcProvider)
+}
+
+func (pool *clientPool) healthyCount(chainId string) int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	count := 0
+	for _, provider := range pool.providers[chainId] {
+		if provider.healthy() {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,126 @@
+package infoHandler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func TestClientPoolRedialsAfterDialFailures(t *testing.T) {
+	newFakeChain(t)
+	chain := SupportedChains["146"]
+	working := chain.RPC
+	chain.RPC = []string{"unsupported://rpc"}
+	SupportedChains["146"] = chain
+
+	if _, err := GetClientForChain(context.Background(), "146"); err == nil {
+		t.Fatal("got a client without any RPC that can be dialed")
+	}
+
+	chain.RPC = working
+	SupportedChains["146"] = chain
+	client, err := GetClientForChain(context.Background(), "146")
+	if err != nil {
+		t.Fatalf("failed dials were cached: %v", err)
+	}
+	if id, err := client.ChainID(context.Background()); err != nil || id.Int64() != 146 {
+		t.Errorf("chain id = %v, %v", id, err)
+	}
+}
+
+// useProviders replaces the RPCs of chain 146 with urls, already pointed at
+// a fakeChain by newFakeChain which restores them.
+func useProviders(t *testing.T, urls ...string) {
+	t.Helper()
+	for _, id := range []string{"146", "0x92"} {
+		chain := SupportedChains[id]
+		chain.RPC = urls
+		SupportedChains[id] = chain
+	}
+	rpcClients.close()
+}
+
+// providerClient is the pooled client that sends to url first.
+func providerClient(t *testing.T, url string) *ethclient.Client {
+	t.Helper()
+	for _, provider := range rpcClients.get(SupportedChains["146"]) {
+		if provider.url == url {
+			return provider.client
+		}
+	}
+	t.Fatalf("no provider %s", url)
+	return nil
+}
+
+func TestFailoverOnUnavailableProvider(t *testing.T) {
+	newFakeChain(t)
+	working := SupportedChains["146"].RPC[0]
+	var hits atomic.Int32
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	useProviders(t, broken.URL, working)
+
+	id, err := providerClient(t, broken.URL).ChainID(context.Background())
+	if err != nil || id.Int64() != 146 {
+		t.Fatalf("chain id = %v, %v, want 146 from the next provider", id, err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("broken provider got %d requests, want 1", hits.Load())
+	}
+
+	// With no provider left the error is the last one's
+	useProviders(t, broken.URL)
+	_, err = providerClient(t, broken.URL).ChainID(context.Background())
+	if apiErr := utils.AsError(err); apiErr.Type != utils.CodeRpcUnavailable {
+		t.Errorf("error = %v, want RPC_UNAVAILABLE", err)
+	}
+}
+
+// A provider that does not answer gets half the deadline, the next one the
+// rest.
+func TestFailoverOnHangingProvider(t *testing.T) {
+	newFakeChain(t)
+	working := SupportedChains["146"].RPC[0]
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The connection is only watched for a close once the body is read
+		io.ReadAll(r.Body)
+		<-r.Context().Done()
+	}))
+	defer hanging.Close()
+	useProviders(t, hanging.URL, working)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	block, err := providerClient(t, hanging.URL).BlockNumber(ctx)
+	if err != nil || block != 0x10 {
+		t.Fatalf("block = %d, %v, want 16 from the next provider", block, err)
+	}
+}
+
+// A revert is the answer of the contract, no other provider is asked.
+func TestRevertIsNotFailedOver(t *testing.T) {
+	chain := newFakeChain(t)
+	working := SupportedChains["146"].RPC[0]
+	useProviders(t, working, working+"/second")
+
+	to := common.HexToAddress(testGenesis)
+	_, err := providerClient(t, working).CallContract(context.Background(), ethereum.CallMsg{To: &to, Data: []byte{1, 2, 3, 4}}, nil)
+	if !utils.IsRevert(err) {
+		t.Fatalf("error = %v, want a revert", err)
+	}
+	if len(chain.sent) != 1 {
+		t.Errorf("sent %d calls, want 1", len(chain.sent))
+	}
+}
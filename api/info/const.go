@@ -1,17 +1,93 @@
 package infoHandler
 
 import (
+	"context"
 	"fmt"
-	"math/rand"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/sirupsen/logrus"
 )
 
 const Version string = "Valhalla API v0.0.1"
 
+const (
+	defaultQueryTimeout      = 20 * time.Second
+	defaultRpcAttemptTimeout = 8 * time.Second
+	defaultHistoryLogRange   = 50000
+	// depFee is read as basis points. The genesis exposes no getter for its
+	// denominator, so deposit fees are only reported as estimates
+	defaultDepFeeDenominator = 10000
+	// maxMulticallCalls bounds a single multicallView, larger sets are split
+	maxMulticallCalls = 500
+)
+
+// QueryTimeout bounds a whole query, RPC attempts included. It is read from
+// QUERY_TIMEOUT_<QUERY> (e.g. QUERY_TIMEOUT_GET_GENESIS_BALANCES), then the
+// route's own Timeout, then QUERY_TIMEOUT.
+func QueryTimeout(query string) time.Duration {
+	key := "QUERY_TIMEOUT_" + strings.ToUpper(strings.ReplaceAll(query, "-", "_"))
+	if timeout := envDuration(key, 0); timeout > 0 {
+		return timeout
+	}
+	if route, ok := queryRoutes[query]; ok && route.Timeout > 0 {
+		return route.Timeout
+	}
+	return envDuration("QUERY_TIMEOUT", defaultQueryTimeout)
+}
+
+// RpcAttemptTimeout bounds a single upstream RPC request, from RPC_ATTEMPT_TIMEOUT.
+func RpcAttemptTimeout() time.Duration {
+	return envDuration("RPC_ATTEMPT_TIMEOUT", defaultRpcAttemptTimeout)
+}
+
+// HistoryLogRange is the most blocks read by a single eth_getLogs, from
+// HISTORY_LOG_RANGE. Ranges are halved when a provider refuses them.
+func HistoryLogRange() uint64 {
+	return uint64(envInt("HISTORY_LOG_RANGE", defaultHistoryLogRange))
+}
+
+// DepFeeDenominator is what a pool's depFee is divided by to get the share of
+// a deposit taken as fee, from DEP_FEE_DENOMINATOR for genesis deployments
+// that differ.
+func DepFeeDenominator() int64 {
+	return int64(envInt("DEP_FEE_DENOMINATOR", defaultDepFeeDenominator))
+}
+
+func rpcAttemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, RpcAttemptTimeout())
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		logrus.WithFields(logrus.Fields{"key": key, "value": value}).Warn("ignoring invalid duration, expected e.g. 5s")
+		return fallback
+	}
+	return duration
+}
+
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		logrus.WithFields(logrus.Fields{"key": key, "value": value}).Warn("ignoring invalid count, expected a positive integer")
+		return fallback
+	}
+	return n
+}
+
 type ChainInfo struct {
 	RPC  []string
 	ID   string
@@ -58,14 +134,30 @@ var SupportedChains = map[string]ChainInfo{
 	},
 }
 
+func init() {
+	utils.RegisterValidator("chain", func(value string) error {
+		_, err := GetChainInfo(value)
+		return err
+	})
+}
+
 func GetChainInfo(chainId string) (ChainInfo, error) {
 	chain, exists := SupportedChains[chainId]
 	if !exists {
-		return ChainInfo{}, fmt.Errorf("chain ID %v not supported", chainId)
+		return ChainInfo{}, utils.ErrChainUnsupported(chainId)
 	}
 	return chain, nil
 }
 
+// canonicalChainId is the ID of chainId in SupportedChains, so "146" and
+// "0x92" group together. An unsupported id is returned as given.
+func canonicalChainId(chainId string) string {
+	if chain, err := GetChainInfo(chainId); err == nil {
+		return chain.ID
+	}
+	return chainId
+}
+
 var multicallAddressMap = map[string]string{
 	"0x92": "0xd782fF720cbB9c8337e02013eE3ccBb54B5471D9",
 	"146":  "0xd782fF720cbB9c8337e02013eE3ccBb54B5471D9",
@@ -75,37 +167,5 @@ func getMulticallAddress(chainId string) (common.Address, error) {
 	if multicallAddress, found := multicallAddressMap[chainId]; found {
 		return common.HexToAddress(multicallAddress), nil
 	}
-	return common.Address{}, fmt.Errorf("multicall address could not be found for %v", chainId)
-}
-
-func shuffle(rpcs []string) []string {
-	shuffled := make([]string, len(rpcs))
-	copy(shuffled, rpcs)
-
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	r.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
-	})
-	return shuffled
-}
-
-func GetClientForChain(chainId string) (*ethclient.Client, error) {
-	chain, err := GetChainInfo(chainId)
-	if err != nil {
-		return nil, err
-	}
-
-	rpcs := shuffle(chain.RPC)
-	var lastErr error
-
-	for _, rpc := range rpcs {
-		client, err := ethclient.Dial(rpc)
-		if err == nil {
-			return client, nil
-		}
-		logrus.Warnf("RPC %s failed: %v", rpc, err)
-		lastErr = err
-	}
-
-	return nil, fmt.Errorf("all RPCs failed for chain %s: %v", chainId, lastErr)
+	return common.Address{}, utils.NewError(utils.CodeChainUnsupported, fmt.Sprintf("multicall address could not be found for %v", chainId), nil)
 }
@@ -0,0 +1,251 @@
+package infoHandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+//http://localhost:8080/api/info?query=decode&calldata=0xe2bbb158000000000000000000000000000000000000000000000000000000000000001200000000000000000000000000000000000000000000000000000000000003e8
+//http://localhost:8080/api/info?query=decode&chain-id=146&tx=0x...
+
+func DecodeRequest(r *http.Request) (DecodeResponse, error) {
+	params, err := parseDecodeParams(r)
+	if err != nil {
+		return DecodeResponse{}, err
+	}
+
+	LogDecodeParams(r.Context(), params)
+
+	switch {
+	case params.CallData != "":
+		data, err := utils.HexToBytes(utils.RemoveHex0xPrefix(params.CallData))
+		if err != nil {
+			return DecodeResponse{}, utils.ErrMalformedRequest(fmt.Sprintf("invalid calldata: %v", err))
+		}
+		_, span := tracing.Start(r.Context(), "decode")
+		defer span.End()
+		call := DecodeCallData(data, params.Abi)
+		return DecodeResponse{Call: &call}, nil
+
+	case len(params.Topics) > 0:
+		data, err := utils.HexToBytes(utils.RemoveHex0xPrefix(params.Data))
+		if err != nil {
+			return DecodeResponse{}, utils.ErrMalformedRequest(fmt.Sprintf("invalid log data: %v", err))
+		}
+		_, span := tracing.Start(r.Context(), "decode")
+		defer span.End()
+		decoded := DecodeLog(&types.Log{Topics: params.Topics, Data: data}, params.Abi)
+		return DecodeResponse{Logs: []DecodedLog{decoded}}, nil
+	}
+
+	return decodeTransaction(r.Context(), params)
+}
+
+func decodeTransaction(ctx context.Context, params *DecodeParams) (DecodeResponse, error) {
+	client, err := GetClientForChain(ctx, params.ChainId)
+	if err != nil {
+		return DecodeResponse{}, err
+	}
+
+	hash := params.TxHash
+
+	attemptCtx, cancel := rpcAttemptContext(ctx)
+	defer cancel()
+	tx, _, err := client.TransactionByHash(attemptCtx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return DecodeResponse{}, utils.ErrNotFound(fmt.Sprintf("transaction %s not found", hash.Hex()))
+	}
+	if err != nil {
+		return DecodeResponse{}, fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+	attemptCtx, cancel = rpcAttemptContext(ctx)
+	defer cancel()
+	receipt, err := client.TransactionReceipt(attemptCtx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return DecodeResponse{}, utils.ErrNotFound(fmt.Sprintf("receipt for %s not found, the transaction may be pending", hash.Hex()))
+	}
+	if err != nil {
+		return DecodeResponse{}, fmt.Errorf("failed to fetch receipt: %w", err)
+	}
+
+	decodedTx := &DecodedTransaction{
+		Hash:        tx.Hash().Hex(),
+		Value:       tx.Value().String(),
+		BlockNumber: receipt.BlockNumber.String(),
+		Status:      receipt.Status,
+		GasUsed:     receipt.GasUsed,
+	}
+	if tx.To() != nil {
+		decodedTx.To = tx.To().Hex()
+	} else {
+		decodedTx.To = receipt.ContractAddress.Hex()
+	}
+	if from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx); err == nil {
+		decodedTx.From = from.Hex()
+	} else {
+		utils.Log(ctx).WithError(err).WithField("tx", tx.Hash().Hex()).Warn("failed to recover sender")
+	}
+
+	_, span := tracing.Start(ctx, "decode", attribute.Int("decode.logs", len(receipt.Logs)))
+	defer span.End()
+
+	response := DecodeResponse{
+		Transaction: decodedTx,
+		Logs:        []DecodedLog{},
+	}
+	if len(tx.Data()) >= 4 {
+		call := DecodeCallData(tx.Data(), params.Abi)
+		response.Call = &call
+	}
+	for _, log := range receipt.Logs {
+		response.Logs = append(response.Logs, DecodeLog(log, params.Abi))
+	}
+
+	return response, nil
+}
+
+// DecodeCallData matches the selector against the registry and returns the
+// first candidate whose inputs unpack cleanly. preferred narrows the search to
+// one contract ABI when selectors collide.
+func DecodeCallData(data []byte, preferred string) DecodedCall {
+	if len(data) < 4 {
+		return DecodedCall{Selector: utils.ToHexBytes(data), Error: "calldata shorter than a selector"}
+	}
+
+	decoded := DecodedCall{Selector: utils.ToHexBytes(data[:4])}
+	candidates := Abis.MethodsBySelector(data)
+	if len(candidates) == 0 {
+		decoded.Error = "unknown selector"
+		return decoded
+	}
+
+	var lastErr error
+	for _, ref := range preferFirst(candidates, preferred, func(ref AbiMethodRef) string { return ref.Contract }) {
+		values, err := ref.Method.Inputs.Unpack(data[4:])
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		decoded.Contract = ref.Contract
+		decoded.Method = ref.Method.RawName
+		decoded.Signature = ref.Method.Sig
+		decoded.Arguments = namedArguments(ref.Method.Inputs, values)
+		return decoded
+	}
+
+	decoded.Error = fmt.Sprintf("failed to unpack arguments: %v", lastErr)
+	return decoded
+}
+
+// DecodeLog matches topic0 against the registry and decodes both indexed and
+// non-indexed event arguments in declaration order.
+func DecodeLog(log *types.Log, preferred string) DecodedLog {
+	decoded := DecodedLog{
+		Address:  log.Address.Hex(),
+		LogIndex: log.Index,
+	}
+	if log.Address == (common.Address{}) {
+		decoded.Address = ""
+	}
+	if len(log.Topics) == 0 {
+		decoded.Error = "anonymous log without topics"
+		return decoded
+	}
+	decoded.Topic = log.Topics[0].Hex()
+
+	candidates := Abis.EventsByTopic(log.Topics[0])
+	if len(candidates) == 0 {
+		decoded.Error = "unknown event topic"
+		return decoded
+	}
+
+	var lastErr error
+	for _, ref := range preferFirst(candidates, preferred, func(ref AbiEventRef) string { return ref.Contract }) {
+		arguments, err := decodeEventArguments(ref.Event, log)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		decoded.Contract = ref.Contract
+		decoded.Event = ref.Event.RawName
+		decoded.Signature = ref.Event.Sig
+		decoded.Arguments = arguments
+		return decoded
+	}
+
+	decoded.Error = fmt.Sprintf("failed to unpack event: %v", lastErr)
+	return decoded
+}
+
+func decodeEventArguments(event abi.Event, log *types.Log) ([]DecodedArgument, error) {
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(log.Topics)-1 != len(indexed) {
+		return nil, fmt.Errorf("expected %d indexed topics, got %d", len(indexed), len(log.Topics)-1)
+	}
+
+	topicValues := make(map[string]interface{})
+	if err := abi.ParseTopicsIntoMap(topicValues, indexed, log.Topics[1:]); err != nil {
+		return nil, err
+	}
+	dataValues, err := event.Inputs.NonIndexed().Unpack(log.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := make([]DecodedArgument, 0, len(event.Inputs))
+	dataIndex := 0
+	for _, input := range event.Inputs {
+		argument := DecodedArgument{Name: input.Name, Type: input.Type.String(), Indexed: input.Indexed}
+		if input.Indexed {
+			argument.Value = FormatAbiValue(topicValues[input.Name])
+		} else {
+			argument.Value = FormatAbiValue(dataValues[dataIndex])
+			dataIndex++
+		}
+		arguments = append(arguments, argument)
+	}
+	return arguments, nil
+}
+
+func namedArguments(inputs abi.Arguments, values []interface{}) []DecodedArgument {
+	arguments := make([]DecodedArgument, len(inputs))
+	for i, input := range inputs {
+		arguments[i] = DecodedArgument{
+			Name:  input.Name,
+			Type:  input.Type.String(),
+			Value: FormatAbiValue(values[i]),
+		}
+	}
+	return arguments
+}
+
+func preferFirst[T any](candidates []T, preferred string, contract func(T) string) []T {
+	if preferred == "" {
+		return candidates
+	}
+	ordered := make([]T, 0, len(candidates))
+	var rest []T
+	for _, candidate := range candidates {
+		if contract(candidate) == preferred {
+			ordered = append(ordered, candidate)
+		} else {
+			rest = append(rest, candidate)
+		}
+	}
+	return append(ordered, rest...)
+}
@@ -0,0 +1,128 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var testUser = common.HexToAddress("0x04301b0c3bC192C28DD3CAF345C4aE6E979EC040")
+
+// depositLog is the Deposit of amount to pid by testUser.
+func depositLog(pid, amount int64) *types.Log {
+	event := Abis.MustContract(AbiGenesis).Events["Deposit"]
+	data, _ := event.Inputs.NonIndexed().Pack(big.NewInt(amount))
+	return &types.Log{
+		Address: common.HexToAddress(testGenesis),
+		Topics:  []common.Hash{event.ID, common.BytesToHash(testUser.Bytes()), common.BigToHash(big.NewInt(pid))},
+		Data:    data,
+	}
+}
+
+func argumentsOf(t *testing.T, arguments []DecodedArgument) string {
+	t.Helper()
+	data, err := json.Marshal(arguments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDecodeCallData(t *testing.T) {
+	data, _ := Abis.MustContract(AbiGenesis).Pack("deposit", big.NewInt(18), big.NewInt(1000))
+	call := DecodeCallData(data, "")
+	if call.Contract != AbiGenesis || call.Method != "deposit" || call.Selector != hexutil.Encode(data[:4]) {
+		t.Errorf("call = %+v", call)
+	}
+	if got, want := argumentsOf(t, call.Arguments), `[{"name":"_pid","type":"uint256","value":"18"},{"name":"_amount","type":"uint256","value":"1000"}]`; got != want {
+		t.Errorf("arguments = %s, want %s", got, want)
+	}
+
+	// transfer is in both the erc20 and the pair abi
+	transfer, _ := Abis.MustContract(AbiErc20).Pack("transfer", testUser, big.NewInt(1))
+	if call := DecodeCallData(transfer, AbiPair); call.Contract != AbiPair {
+		t.Errorf("preferred pair, decoded with %s", call.Contract)
+	}
+
+	if call := DecodeCallData([]byte{1, 2, 3, 4}, ""); call.Error != "unknown selector" {
+		t.Errorf("unknown selector: %+v", call)
+	}
+	if call := DecodeCallData(data[:20], ""); call.Error == "" {
+		t.Errorf("truncated arguments decoded: %+v", call)
+	}
+}
+
+func TestDecodeLog(t *testing.T) {
+	decoded := DecodeLog(depositLog(3, 500), "")
+	if decoded.Contract != AbiGenesis || decoded.Event != "Deposit" {
+		t.Fatalf("log = %+v", decoded)
+	}
+	want := `[{"name":"user","type":"address","indexed":true,"value":"` + testUser.Hex() + `"},{"name":"pid","type":"uint256","indexed":true,"value":"3"},{"name":"amount","type":"uint256","value":"500"}]`
+	if got := argumentsOf(t, decoded.Arguments); got != want {
+		t.Errorf("arguments = %s, want %s", got, want)
+	}
+
+	missingTopic := depositLog(3, 500)
+	missingTopic.Topics = missingTopic.Topics[:2]
+	if decoded := DecodeLog(missingTopic, ""); decoded.Error == "" {
+		t.Errorf("log without the pid topic decoded: %+v", decoded)
+	}
+}
+
+// A transaction hash is decoded from the transaction and its receipt, read
+// through the chain's RPC.
+func TestDecodeTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	genesis := common.HexToAddress(testGenesis)
+	data, _ := Abis.MustContract(AbiGenesis).Pack("deposit", big.NewInt(3), big.NewInt(500))
+	tx := types.MustSignNewTx(key, types.LatestSignerForChainID(big.NewInt(146)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(146),
+		Nonce:     1,
+		To:        &genesis,
+		Gas:       100000,
+		GasFeeCap: big.NewInt(1),
+		Data:      data,
+	})
+	log := depositLog(3, 500)
+	log.TxHash = tx.Hash()
+	receipt := &types.Receipt{
+		Type:        tx.Type(),
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      tx.Hash(),
+		BlockNumber: big.NewInt(16),
+		GasUsed:     50000,
+		Logs:        []*types.Log{log},
+	}
+
+	chain := newFakeChain(t)
+	chain.methods["eth_getTransactionByHash"] = func([]json.RawMessage) (interface{}, error) {
+		return tx, nil
+	}
+	chain.methods["eth_getTransactionReceipt"] = func([]json.RawMessage) (interface{}, error) {
+		return receipt, nil
+	}
+
+	response, err := Query(context.Background(), "decode", url.Values{"chain-id": {"146"}, "tx": {tx.Hash().Hex()}})
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	decoded := response.(DecodeResponse)
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	if decoded.Transaction == nil || decoded.Transaction.From != from.Hex() || decoded.Transaction.To != genesis.Hex() || decoded.Transaction.BlockNumber != "16" || decoded.Transaction.GasUsed != 50000 {
+		t.Errorf("transaction = %+v", decoded.Transaction)
+	}
+	if decoded.Call == nil || decoded.Call.Method != "deposit" {
+		t.Errorf("call = %+v", decoded.Call)
+	}
+	if len(decoded.Logs) != 1 || decoded.Logs[0].Event != "Deposit" || decoded.Logs[0].Address != genesis.Hex() {
+		t.Errorf("logs = %+v", decoded.Logs)
+	}
+}
@@ -0,0 +1,358 @@
+package infoHandler
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// alertEvaluator checks every condition once per interval. The chain reads of
+// all conditions are deduplicated and sent as one multicall per chain.
+// Condition state (the stake window, the last pool values) lives in memory,
+// so a restart begins new windows and does not report changes made while the
+// server was down.
+type alertEvaluator struct {
+	service *alertService
+	states  map[string]*alertState
+}
+
+type alertState struct {
+	above      bool
+	samples    []stakeSample
+	allocPoint *big.Int
+	depFee     *big.Int
+	endNotice  bool
+}
+
+type stakeSample struct {
+	at     time.Time
+	amount *big.Int
+}
+
+// alertRead is one multicall sub-call shared by the conditions that need it.
+type alertRead struct {
+	key  string
+	call Calls
+}
+
+func newAlertEvaluator(service *alertService) *alertEvaluator {
+	return &alertEvaluator{service: service, states: make(map[string]*alertState)}
+}
+
+func (e *alertEvaluator) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.evaluate(ctx, time.Now())
+		}
+	}
+}
+
+func (e *alertEvaluator) evaluate(ctx context.Context, now time.Time) {
+	subscriptions := e.service.store.all()
+
+	readsByChain := make(map[string][]alertRead)
+	seen := make(map[string]bool)
+	live := make(map[string]bool)
+	for _, subscription := range subscriptions {
+		for i, condition := range subscription.Conditions {
+			live[alertStateKey(subscription.Id, i)] = true
+			read, err := alertConditionRead(condition)
+			if err != nil {
+				logrus.WithError(err).WithField("subscription", subscription.Id).Warn("skipping invalid alert condition")
+				continue
+			}
+			if !seen[read.key] {
+				seen[read.key] = true
+				readsByChain[condition.ChainId] = append(readsByChain[condition.ChainId], read)
+			}
+		}
+	}
+	// Conditions of deleted subscriptions
+	for key := range e.states {
+		if !live[key] {
+			delete(e.states, key)
+		}
+	}
+	if len(readsByChain) == 0 {
+		return
+	}
+
+	chains := make([]string, 0, len(readsByChain))
+	plans := make([]*MulticallPlan, 0, len(readsByChain))
+	for chainId, reads := range readsByChain {
+		calls := make([]Calls, len(reads))
+		for i, read := range reads {
+			calls[i] = read.call
+		}
+		reads := reads
+		chains = append(chains, chainId)
+		plans = append(plans, &MulticallPlan{
+			ChainId: chainId,
+			Calls:   calls,
+			Decode: func(results []MulticallResult) (interface{}, error) {
+				byKey := make(map[string]MulticallResult, len(results))
+				for i, result := range results {
+					byKey[reads[i].key] = result
+				}
+				return byKey, nil
+			},
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout("alerts"))
+	defer cancel()
+	responses, errs := ExecuteMulticallPlans(ctx, plans)
+
+	results := make(map[string]MulticallResult)
+	failedChains := make(map[string]bool)
+	for i, chainId := range chains {
+		if errs[i] != nil {
+			failedChains[chainId] = true
+			logrus.WithError(errs[i]).WithField("chain_id", chainId).Warn("alert evaluation failed, retrying next interval")
+			continue
+		}
+		for key, result := range responses[i].(map[string]MulticallResult) {
+			results[key] = result
+		}
+	}
+
+	for _, subscription := range subscriptions {
+		for i, condition := range subscription.Conditions {
+			if failedChains[condition.ChainId] {
+				continue
+			}
+			read, err := alertConditionRead(condition)
+			if err != nil {
+				continue
+			}
+			result, ok := results[read.key]
+			if !ok || !result.Success {
+				logrus.WithFields(logrus.Fields{"subscription": subscription.Id, "condition": i}).Debug("alert read reverted")
+				continue
+			}
+
+			key := alertStateKey(subscription.Id, i)
+			state, ok := e.states[key]
+			if !ok {
+				state = &alertState{}
+				e.states[key] = state
+			}
+
+			event, fired, err := checkAlertCondition(condition, state, result.ReturnData, now)
+			if err != nil {
+				logrus.WithError(err).WithFields(logrus.Fields{"subscription": subscription.Id, "condition": i}).Warn("failed to check alert condition")
+				continue
+			}
+			if !fired {
+				continue
+			}
+			e.fire(subscription, i, condition, event, now)
+		}
+	}
+}
+
+func (e *alertEvaluator) fire(subscription AlertSubscription, index int, condition AlertCondition, event AlertEvent, now time.Time) {
+	event.Event = alertEventPrefix + condition.Type
+	event.Subscription = subscription.Id
+	event.Condition = index
+	event.Type = condition.Type
+	event.ChainId = condition.ChainId
+	event.Genesis = condition.Genesis
+	event.PoolId = condition.PoolId
+	event.User = condition.User
+	event.TriggeredAt = now.UTC().Format(time.RFC3339)
+
+	metrics.AlertTriggered(condition.Type)
+	delivery, err := e.service.dispatcher.Send(subscription.target(), event.Event, event)
+	fields := logrus.Fields{"subscription": subscription.Id, "condition": index, "type": condition.Type}
+	if err != nil {
+		logrus.WithError(err).WithFields(fields).Error("failed to queue alert delivery")
+		return
+	}
+	logrus.WithFields(fields).WithField("delivery", delivery.Id).Info("alert triggered")
+}
+
+func alertStateKey(subscription string, index int) string {
+	return fmt.Sprintf("%s/%d", subscription, index)
+}
+
+// alertConditionRead is the sub-call whose result decides the condition.
+func alertConditionRead(condition AlertCondition) (alertRead, error) {
+	genesis := common.HexToAddress(condition.Genesis)
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+	prefix := strings.Join([]string{condition.ChainId, genesis.Hex()}, "/")
+
+	var pid *big.Int
+	if condition.Type != alertEndApproaching {
+		var ok bool
+		if pid, ok = new(big.Int).SetString(condition.PoolId, 10); !ok {
+			return alertRead{}, fmt.Errorf("invalid pool-id %q", condition.PoolId)
+		}
+	}
+
+	switch condition.Type {
+	case alertPendingAbove:
+		user := common.HexToAddress(condition.User)
+		return alertRead{
+			key:  prefix + "/pendingVAL/" + pid.String() + "/" + user.Hex(),
+			call: Calls{contractAddress: genesis, abi: parsedGenesisABI, method: "pendingVAL", params: []interface{}{pid, user}},
+		}, nil
+	case alertStakeDrop:
+		token := common.HexToAddress(condition.Token)
+		return alertRead{
+			key:  condition.ChainId + "/" + token.Hex() + "/balanceOf/" + genesis.Hex(),
+			call: Calls{contractAddress: token, abi: Abis.MustContract(AbiErc20), method: "balanceOf", params: genesis},
+		}, nil
+	case alertPoolChanged:
+		return alertRead{
+			key:  prefix + "/poolInfo/" + pid.String(),
+			call: Calls{contractAddress: genesis, abi: parsedGenesisABI, method: "poolInfo", params: pid},
+		}, nil
+	case alertEndApproaching:
+		return alertRead{
+			key:  prefix + "/poolEndTime",
+			call: Calls{contractAddress: genesis, abi: parsedGenesisABI, method: "poolEndTime", params: nil},
+		}, nil
+	}
+	return alertRead{}, fmt.Errorf("unknown condition type %q", condition.Type)
+}
+
+// checkAlertCondition updates state with the latest read and reports whether
+// the condition fired. Conditions fire on transitions, not on every interval
+// they hold.
+func checkAlertCondition(condition AlertCondition, state *alertState, data []byte, now time.Time) (AlertEvent, bool, error) {
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+
+	switch condition.Type {
+	case alertPendingAbove:
+		pending, err := unpackUint(parsedGenesisABI.Unpack("pendingVAL", data))
+		if err != nil {
+			return AlertEvent{}, false, err
+		}
+		threshold, _ := new(big.Int).SetString(condition.Threshold, 10)
+		above := pending.Cmp(threshold) >= 0
+		fired := above && !state.above
+		state.above = above
+		return AlertEvent{
+			Message: fmt.Sprintf("pendingVAL of %s in pool %s reached %s (threshold %s)", condition.User, condition.PoolId, pending, threshold),
+			Values:  map[string]string{"pending-val": pending.String(), "threshold": condition.Threshold},
+		}, fired, nil
+
+	case alertStakeDrop:
+		staked, err := unpackUint(Abis.MustContract(AbiErc20).Unpack("balanceOf", data))
+		if err != nil {
+			return AlertEvent{}, false, err
+		}
+		window, _ := time.ParseDuration(condition.Window)
+		samples := state.samples[:0]
+		for _, sample := range state.samples {
+			if now.Sub(sample.at) <= window {
+				samples = append(samples, sample)
+			}
+		}
+		state.samples = append(samples, stakeSample{at: now, amount: staked})
+
+		peak := staked
+		for _, sample := range state.samples {
+			if sample.amount.Cmp(peak) > 0 {
+				peak = sample.amount
+			}
+		}
+		if peak.Sign() == 0 {
+			return AlertEvent{}, false, nil
+		}
+		drop, _ := new(big.Float).Quo(
+			new(big.Float).SetInt(new(big.Int).Mul(new(big.Int).Sub(peak, staked), big.NewInt(100))),
+			new(big.Float).SetInt(peak),
+		).Float64()
+		if drop < condition.DropPercent {
+			return AlertEvent{}, false, nil
+		}
+		// Start a new window so the same drop is reported once
+		state.samples = []stakeSample{{at: now, amount: staked}}
+		return AlertEvent{
+			Message: fmt.Sprintf("LP staked in pool %s dropped %.2f%% within %s, from %s to %s", condition.PoolId, drop, window, peak, staked),
+			Values: map[string]string{
+				"peak":         peak.String(),
+				"staked":       staked.String(),
+				"drop-percent": fmt.Sprintf("%.2f", drop),
+				"window":       condition.Window,
+			},
+		}, true, nil
+
+	case alertPoolChanged:
+		var info PoolInfo
+		if err := parsedGenesisABI.UnpackIntoInterface(&info, "poolInfo", data); err != nil {
+			return AlertEvent{}, false, fmt.Errorf("failed to unpack poolInfo: %v", err)
+		}
+		first := state.allocPoint == nil
+		previousAlloc, previousFee := state.allocPoint, state.depFee
+		state.allocPoint, state.depFee = info.AllocPoint, info.DepFee
+		if first {
+			return AlertEvent{}, false, nil
+		}
+
+		values := make(map[string]string)
+		var changes []string
+		if previousAlloc.Cmp(info.AllocPoint) != 0 {
+			values["alloc-point-from"], values["alloc-point-to"] = previousAlloc.String(), info.AllocPoint.String()
+			changes = append(changes, fmt.Sprintf("allocPoint %s -> %s", previousAlloc, info.AllocPoint))
+		}
+		if previousFee.Cmp(info.DepFee) != 0 {
+			values["dep-fee-from"], values["dep-fee-to"] = previousFee.String(), info.DepFee.String()
+			changes = append(changes, fmt.Sprintf("depFee %s -> %s", previousFee, info.DepFee))
+		}
+		if len(changes) == 0 {
+			return AlertEvent{}, false, nil
+		}
+		sort.Strings(changes)
+		return AlertEvent{
+			Message: fmt.Sprintf("pool %s changed: %s", condition.PoolId, strings.Join(changes, ", ")),
+			Values:  values,
+		}, true, nil
+
+	case alertEndApproaching:
+		end, err := unpackUint(parsedGenesisABI.Unpack("poolEndTime", data))
+		if err != nil {
+			return AlertEvent{}, false, err
+		}
+		before, _ := time.ParseDuration(condition.Before)
+		remaining := time.Unix(end.Int64(), 0).Sub(now)
+		approaching := remaining > 0 && remaining <= before
+		fired := approaching && !state.endNotice
+		// Re-armed if the end is moved back out of range
+		state.endNotice = approaching || (state.endNotice && remaining <= 0)
+		return AlertEvent{
+			Message: fmt.Sprintf("pools end in %s, at %s", remaining.Round(time.Minute), time.Unix(end.Int64(), 0).UTC().Format(time.RFC3339)),
+			Values:  map[string]string{"pool-end-time": end.String(), "remaining-seconds": fmt.Sprint(int64(remaining.Seconds()))},
+		}, fired, nil
+	}
+	return AlertEvent{}, false, utils.ErrInternal(fmt.Sprintf("unknown condition type %q", condition.Type))
+}
+
+func unpackUint(values []interface{}, err error) (*big.Int, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("expected one value, got %d", len(values))
+	}
+	value, ok := values[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("expected uint256, got %T", values[0])
+	}
+	return value, nil
+}
@@ -0,0 +1,481 @@
+package infoHandler
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+const (
+	graphqlPath            = "/graphql"
+	graphqlSchemaPath      = "/graphql/schema.graphql"
+	defaultGraphqlMaxCalls = 2000
+	defaultGraphqlMaxDepth = 10
+)
+
+//go:embed schema.graphql
+var graphqlSDL string
+
+// The schema is bundled, a typo in it should stop the build of the binary
+// rather than fail every request.
+var graphqlSchema = gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: graphqlSDL})
+
+//http://localhost:8080/graphql?query={genesis(chainId:"146",address:"0x23Ee13d49e78811d063722D9228547a7dF73E42E"){poolLength pools{pid allocPoint pair{reserve0 reserve1}}}}
+
+// HandleGraphQL executes a GraphQL query, see schema.graphql. Fields resolve
+// to contract reads that are deduplicated and run in rounds: every read that
+// is known in a round goes into one multicall per chain, and reads that need
+// an earlier result (a pool's token, a pair's token0) go into the next round.
+// A query only makes the calls its selection needs.
+func HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	request, err := parseGraphQLRequest(r)
+	if err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+
+	document, errs := gqlparser.LoadQuery(graphqlSchema, request.Query)
+	if len(errs) > 0 {
+		writeGraphQLResponse(w, http.StatusBadRequest, GraphQLResponse{Errors: graphqlRequestErrors(errs)})
+		return
+	}
+	operation, err := graphqlOperation(document, request.OperationName)
+	if err != nil {
+		writeGraphQLResponse(w, http.StatusBadRequest, GraphQLResponse{Errors: graphqlRequestErrors(gqlerror.List{gqlerror.Wrap(err)})})
+		return
+	}
+	variables, err := validator.VariableValues(graphqlSchema, operation, request.Variables)
+	if err != nil {
+		writeGraphQLResponse(w, http.StatusBadRequest, GraphQLResponse{Errors: graphqlRequestErrors(gqlerror.List{gqlerror.WrapIfUnwrapped(err)})})
+		return
+	}
+
+	depth, cost := graphqlShape(operation.SelectionSet, variables)
+	if maxDepth := graphqlLimit("GRAPHQL_MAX_DEPTH", defaultGraphqlMaxDepth); depth > maxDepth {
+		err := gqlerror.Errorf("query is %d levels deep, the limit is %d", depth, maxDepth)
+		writeGraphQLResponse(w, http.StatusBadRequest, GraphQLResponse{Errors: graphqlRequestErrors(gqlerror.List{err})})
+		return
+	}
+	if err := limitRequest(w, r, cost); err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+
+	response, err := runQuery(r, "graphql", func(r *http.Request) (interface{}, error) {
+		return executeGraphQL(r.Context(), operation, variables), nil
+	})
+	if err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+	writeGraphQLResponse(w, http.StatusOK, response)
+}
+
+// GraphQLSchemaHandler serves the schema in SDL. Introspection queries are not
+// supported, tools can load this file instead.
+func GraphQLSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	setCacheControl(w, 300)
+	w.Write([]byte(graphqlSDL))
+}
+
+func writeGraphQLResponse(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// graphqlRequestErrors tags errors raised before execution, which leave no
+// data, with the same code as any other malformed request.
+func graphqlRequestErrors(errs gqlerror.List) gqlerror.List {
+	for _, err := range errs {
+		if err.Extensions == nil {
+			err.Extensions = map[string]interface{}{}
+		}
+		err.Extensions["code"] = utils.CodeMalformedRequest
+	}
+	return errs
+}
+
+func graphqlOperation(document *ast.QueryDocument, name string) (*ast.OperationDefinition, error) {
+	if name != "" {
+		if operation := document.Operations.ForName(name); operation != nil {
+			return operation, nil
+		}
+		return nil, fmt.Errorf("operation %q not found", name)
+	}
+	if len(document.Operations) != 1 {
+		return nil, fmt.Errorf("operationName is required when the document has %d operations", len(document.Operations))
+	}
+	return document.Operations[0], nil
+}
+
+func graphqlLimit(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// graphqlShape measures how deep a selection goes and weighs it for rate
+// limiting: 1, plus allPoolsCost for every list of pools or positions without
+// pids, or one per pid when they are given.
+func graphqlShape(selections ast.SelectionSet, variables map[string]interface{}) (depth int, cost int) {
+	cost = 1
+	var walk func(selections ast.SelectionSet, level int)
+	walk = func(selections ast.SelectionSet, level int) {
+		for _, selection := range selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				if level > depth {
+					depth = level
+				}
+				if selection.Name == "pools" || selection.Name == "positions" {
+					if pids, ok := selection.ArgumentMap(variables)["pids"].([]interface{}); ok {
+						cost += len(pids)
+					} else {
+						cost += allPoolsCost
+					}
+				}
+				walk(selection.SelectionSet, level+1)
+			case *ast.InlineFragment:
+				walk(selection.SelectionSet, level)
+			case *ast.FragmentSpread:
+				if selection.Definition != nil {
+					walk(selection.Definition.SelectionSet, level)
+				}
+			}
+		}
+	}
+	walk(selections, 1)
+	return depth, cost
+}
+
+// gqlObject is a JSON object that keeps the order of the selection, as the
+// spec requires for response fields.
+type gqlObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newGqlObject() *gqlObject {
+	return &gqlObject{values: make(map[string]interface{})}
+}
+
+func (o *gqlObject) reserve(key string) bool {
+	if _, ok := o.values[key]; ok {
+		return false
+	}
+	o.keys = append(o.keys, key)
+	o.values[key] = nil
+	return true
+}
+
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// gqlField is a field being resolved: its arguments, its path in the
+// response and where to put its value.
+type gqlField struct {
+	*ast.Field
+	args map[string]interface{}
+	path ast.Path
+	set  func(value interface{})
+}
+
+type gqlResolver interface {
+	typeName() string
+	resolve(e *gqlExecutor, f gqlField)
+}
+
+type gqlRead struct {
+	chainId string
+	call    Calls
+	data    []byte
+	err     error
+	done    bool
+	waiters []func(data []byte, err error)
+}
+
+type gqlExecutor struct {
+	ctx       context.Context
+	variables map[string]interface{}
+	reads     map[string]*gqlRead
+	pending   []*gqlRead
+	maxCalls  int
+	overLimit bool
+	errors    gqlerror.List
+}
+
+func executeGraphQL(ctx context.Context, operation *ast.OperationDefinition, variables map[string]interface{}) GraphQLResponse {
+	e := &gqlExecutor{
+		ctx:       ctx,
+		variables: variables,
+		reads:     make(map[string]*gqlRead),
+		maxCalls:  graphqlLimit("GRAPHQL_MAX_CALLS", defaultGraphqlMaxCalls),
+	}
+
+	data := newGqlObject()
+	e.resolveObject(data, gqlQuery{}, operation.SelectionSet, nil)
+	rounds := e.run()
+
+	utils.Log(ctx).WithFields(logrus.Fields{"rounds": rounds, "calls": len(e.reads), "errors": len(e.errors)}).Debug("executed graphql query")
+
+	if e.overLimit {
+		return GraphQLResponse{Errors: gqlerror.List{{
+			Message:    fmt.Sprintf("query needs more than %d contract calls, select fewer fields or pools", e.maxCalls),
+			Extensions: map[string]interface{}{"code": utils.CodeMalformedRequest},
+		}}}
+	}
+	return GraphQLResponse{Data: data, Errors: e.errors}
+}
+
+// run executes the pending reads round by round until no callback asks for
+// more, and returns the number of rounds.
+func (e *gqlExecutor) run() int {
+	rounds := 0
+	for len(e.pending) > 0 && !e.overLimit {
+		rounds++
+		batch := e.pending
+		e.pending = nil
+
+		var plans []*MulticallPlan
+		var planReads [][]*gqlRead
+		byChain := make(map[string]int)
+		for _, read := range batch {
+			i, ok := byChain[read.chainId]
+			if !ok {
+				i = len(plans)
+				byChain[read.chainId] = i
+				plans = append(plans, &MulticallPlan{
+					ChainId: read.chainId,
+					Decode: func(results []MulticallResult) (interface{}, error) {
+						return results, nil
+					},
+				})
+				planReads = append(planReads, nil)
+			}
+			plans[i].Calls = append(plans[i].Calls, read.call)
+			planReads[i] = append(planReads[i], read)
+		}
+
+		responses, errs := ExecuteMulticallPlans(e.ctx, plans)
+		for i, reads := range planReads {
+			results, _ := responses[i].([]MulticallResult)
+			for j, read := range reads {
+				switch {
+				case errs[i] != nil:
+					read.err = errs[i]
+				case !results[j].Success:
+					read.err = utils.ErrCallReverted(fmt.Errorf("%s reverted on %s", read.call.method, read.call.contractAddress.Hex()))
+				default:
+					read.data = results[j].ReturnData
+				}
+				read.done = true
+			}
+		}
+
+		for _, read := range batch {
+			waiters := read.waiters
+			read.waiters = nil
+			for _, then := range waiters {
+				then(read.data, read.err)
+			}
+		}
+	}
+	return rounds
+}
+
+// read queues call for the next round, or hands back its result when the
+// same call was already made. Identical calls share one read.
+func (e *gqlExecutor) read(chainId string, call Calls, then func(data []byte, err error)) {
+	callData, err := GetCallBytes(call.abi, call.method, call.params)
+	if err != nil {
+		then(nil, utils.ErrInternal(fmt.Sprintf("failed to pack %s: %v", call.method, err)))
+		return
+	}
+	key := chainId + "/" + call.contractAddress.Hex() + "/" + hex.EncodeToString(callData)
+
+	read, ok := e.reads[key]
+	if !ok {
+		if len(e.reads) >= e.maxCalls {
+			e.overLimit = true
+			return
+		}
+		read = &gqlRead{chainId: chainId, call: call}
+		e.reads[key] = read
+		e.pending = append(e.pending, read)
+	}
+	if read.done {
+		then(read.data, read.err)
+		return
+	}
+	read.waiters = append(read.waiters, then)
+}
+
+// value resolves f to an output of call.
+func (e *gqlExecutor) value(f gqlField, chainId string, call Calls, output int) {
+	e.read(chainId, call, func(data []byte, err error) {
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		values, err := call.abi.Unpack(call.method, data)
+		if err != nil {
+			e.fail(f, fmt.Errorf("failed to unpack %s: %v", call.method, err))
+			return
+		}
+		if output >= len(values) {
+			e.fail(f, fmt.Errorf("%s returned %d values", call.method, len(values)))
+			return
+		}
+		f.set(gqlScalar(values[output]))
+	})
+}
+
+// object resolves f to an object of resolver.
+func (e *gqlExecutor) object(f gqlField, resolver gqlResolver) {
+	obj := newGqlObject()
+	f.set(obj)
+	e.resolveObject(obj, resolver, f.SelectionSet, f.path)
+}
+
+// list resolves f to a list with an object per resolver.
+func (e *gqlExecutor) list(f gqlField, resolvers []gqlResolver) {
+	list := make([]interface{}, len(resolvers))
+	for i, resolver := range resolvers {
+		obj := newGqlObject()
+		list[i] = obj
+		e.resolveObject(obj, resolver, f.SelectionSet, appendPath(f.path, ast.PathIndex(i)))
+	}
+	f.set(list)
+}
+
+func (e *gqlExecutor) resolveObject(obj *gqlObject, resolver gqlResolver, selections ast.SelectionSet, path ast.Path) {
+	for _, field := range e.collectFields(selections, resolver.typeName()) {
+		key := field.Alias
+		if !obj.reserve(key) {
+			continue
+		}
+		f := gqlField{
+			Field: field,
+			path:  appendPath(path, ast.PathName(key)),
+			set:   func(value interface{}) { obj.values[key] = value },
+		}
+		if field.Name == "__typename" {
+			f.set(resolver.typeName())
+			continue
+		}
+		f.args = field.ArgumentMap(e.variables)
+		resolver.resolve(e, f)
+	}
+}
+
+// collectFields flattens fragments and merges fields selected twice under the
+// same response key, skipping what @skip and @include leave out.
+func (e *gqlExecutor) collectFields(selections ast.SelectionSet, typeName string) []*ast.Field {
+	var fields []*ast.Field
+	index := make(map[string]int)
+
+	var collect func(selections ast.SelectionSet)
+	collect = func(selections ast.SelectionSet) {
+		for _, selection := range selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				if !e.included(selection.Directives) {
+					continue
+				}
+				if i, ok := index[selection.Alias]; ok {
+					merged := *fields[i]
+					merged.SelectionSet = append(append(ast.SelectionSet{}, merged.SelectionSet...), selection.SelectionSet...)
+					fields[i] = &merged
+					continue
+				}
+				index[selection.Alias] = len(fields)
+				fields = append(fields, selection)
+			case *ast.InlineFragment:
+				if !e.included(selection.Directives) || (selection.TypeCondition != "" && selection.TypeCondition != typeName) {
+					continue
+				}
+				collect(selection.SelectionSet)
+			case *ast.FragmentSpread:
+				if !e.included(selection.Directives) || selection.Definition == nil || selection.Definition.TypeCondition != typeName {
+					continue
+				}
+				collect(selection.Definition.SelectionSet)
+			}
+		}
+	}
+	collect(selections)
+	return fields
+}
+
+func (e *gqlExecutor) included(directives ast.DirectiveList) bool {
+	if skip := directives.ForName("skip"); skip != nil {
+		if value, _ := skip.ArgumentMap(e.variables)["if"].(bool); value {
+			return false
+		}
+	}
+	if include := directives.ForName("include"); include != nil {
+		if value, _ := include.ArgumentMap(e.variables)["if"].(bool); !value {
+			return false
+		}
+	}
+	return true
+}
+
+// fail records err for f, which stays null.
+func (e *gqlExecutor) fail(f gqlField, err error) {
+	apiErr := utils.AsError(err)
+	message := apiErr.Details
+	if message == "" {
+		message = apiErr.Message
+	}
+
+	graphqlErr := &gqlerror.Error{
+		Message:    message,
+		Path:       f.path,
+		Extensions: map[string]interface{}{"code": apiErr.Type, "retryable": apiErr.Retryable},
+	}
+	if f.Position != nil {
+		graphqlErr.Locations = []gqlerror.Location{{Line: f.Position.Line, Column: f.Position.Column}}
+	}
+	e.errors = append(e.errors, graphqlErr)
+}
+
+func appendPath(path ast.Path, element ast.PathElement) ast.Path {
+	return append(path[:len(path):len(path)], element)
+}
@@ -0,0 +1,123 @@
+package infoHandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/ratelimit"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// shapeOf parses query and measures its single operation.
+func shapeOf(t *testing.T, query string, variables map[string]interface{}) (int, int) {
+	t.Helper()
+	document, errs := gqlparser.LoadQuery(graphqlSchema, query)
+	if len(errs) > 0 {
+		t.Fatalf("invalid query: %v", errs)
+	}
+	values, err := validator.VariableValues(graphqlSchema, document.Operations[0], variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graphqlShape(document.Operations[0].SelectionSet, values)
+}
+
+func TestGraphqlShape(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		depth     int
+		cost      int
+	}{
+		{"one field", `{token(chainId:"146",address:"0x1"){symbol}}`, nil, 2, 1},
+		{"every pool", `{genesis(chainId:"146",address:"0x1"){pools{pid}}}`, nil, 3, 1 + allPoolsCost},
+		{"pids", `{genesis(chainId:"146",address:"0x1"){pools(pids:[0,1,2]){pid}}}`, nil, 3, 4},
+		{"pids variable", `query($pids:[Int!]){genesis(chainId:"146",address:"0x1"){pools(pids:$pids){pid}}}`, map[string]interface{}{"pids": []interface{}{0, 1}}, 3, 3},
+		{"positions", `{user(chainId:"146",address:"0x1"){positions(genesis:"0x2"){pool{pools:genesis{pools{pid}}}}}}`, nil, 6, 1 + 2*allPoolsCost},
+		{"fragments", `{genesis(chainId:"146",address:"0x1"){...G}} fragment G on Genesis{pools{... on Pool{pair{token0{symbol}}}}}`, nil, 5, 1 + allPoolsCost},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			depth, cost := shapeOf(t, test.query, test.variables)
+			if depth != test.depth || cost != test.cost {
+				t.Errorf("depth %d cost %d, want %d %d", depth, cost, test.depth, test.cost)
+			}
+		})
+	}
+}
+
+func postGraphQL(query string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(GraphQLRequest{Query: query})
+	w := httptest.NewRecorder()
+	Handler(w, httptest.NewRequest("POST", graphqlPath, strings.NewReader(string(body))))
+	return w
+}
+
+// nestedPools is a query depth levels deep, going back and forth between a
+// pool and its genesis.
+func nestedPools(depth int) string {
+	var nest func(level int, onPool bool) string
+	nest = func(level int, onPool bool) string {
+		switch {
+		case level == depth && onPool:
+			return "pid"
+		case level == depth:
+			return "poolLength"
+		case onPool:
+			return "genesis{" + nest(level+1, false) + "}"
+		}
+		return "pool(pid:0){" + nest(level+1, true) + "}"
+	}
+	return `{pool(chainId:"146",genesis:"0x1",pid:0){` + nest(2, true) + "}}"
+}
+
+func TestGraphqlMaxDepth(t *testing.T) {
+	t.Setenv("GRAPHQL_MAX_DEPTH", "4")
+	if depth, _ := shapeOf(t, nestedPools(5), nil); depth != 5 {
+		t.Fatalf("nestedPools(5) is %d deep", depth)
+	}
+
+	w := postGraphQL(nestedPools(5))
+	var response GraphQLResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusBadRequest || len(response.Errors) != 1 || !strings.Contains(response.Errors[0].Message, "5 levels deep, the limit is 4") {
+		t.Fatalf("status %d %s, want the depth refused", w.Code, w.Body.String())
+	}
+	if response.Errors[0].Extensions["code"] != string(utils.CodeMalformedRequest) {
+		t.Errorf("code = %v", response.Errors[0].Extensions["code"])
+	}
+}
+
+// A query is charged its cost before it runs, so a client cannot list every
+// pool for the price of one request.
+func TestGraphqlCostIsRateLimited(t *testing.T) {
+	config := ratelimit.DefaultConfig()
+	config.Tiers[ratelimit.AnonymousTier] = ratelimit.Tier{Rate: 0.001, Burst: 2*allPoolsCost - 1}
+	useLimiter(t, config)
+	chain := newFakeChain(t)
+	allPools := `{genesis(chainId:"146",address:"` + testGenesis + `"){pools{pid}}}`
+
+	if w := postGraphQL(allPools); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(allPoolsCost-2) {
+		t.Fatalf("first query: status %d, %s remaining", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+	sent := len(chain.sent)
+	w := postGraphQL(allPools)
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), string(utils.CodeRateLimited)) {
+		t.Fatalf("status %d %s, want rate limited", w.Code, w.Body.String())
+	}
+	if len(chain.sent) != sent {
+		t.Errorf("a refused query sent %d calls", len(chain.sent)-sent)
+	}
+
+	// Listed pids cost one each
+	if w := postGraphQL(`{genesis(chainId:"146",address:"` + testGenesis + `"){pools(pids:[0]){pid}}}`); w.Code != http.StatusOK {
+		t.Errorf("query of one pid: status %d %s", w.Code, w.Body.String())
+	}
+}
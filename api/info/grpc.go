@@ -0,0 +1,398 @@
+package infoHandler
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	valhallav1 "github.com/FudgyDRS/valhalla-api/pkg/pb/valhalla/v1"
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const defaultGrpcAddr = "127.0.0.1:9090"
+
+// grpcCodes maps error types to the gRPC code clients should branch on.
+var grpcCodes = map[utils.ErrorCode]codes.Code{
+	utils.CodeMalformedRequest: codes.InvalidArgument,
+	utils.CodeInvalidAddress:   codes.InvalidArgument,
+	utils.CodeChainUnsupported: codes.InvalidArgument,
+	utils.CodeNotFound:         codes.NotFound,
+	utils.CodeUnauthorized:     codes.Unauthenticated,
+	utils.CodeRateLimited:      codes.ResourceExhausted,
+	utils.CodeCallReverted:     codes.FailedPrecondition,
+	utils.CodeRpcUnavailable:   codes.Unavailable,
+	utils.CodeTimeout:          codes.DeadlineExceeded,
+	utils.CodeInternal:         codes.Internal,
+}
+
+// StartGRPC serves InfoService with server reflection on GRPC_LISTEN_ADDR
+// (127.0.0.1:9090 by default, "off" to disable), apart from the HTTP server.
+// Calls are charged to the API key in their x-api-key or authorization
+// metadata, or to the peer's IP, with the limits of the HTTP API. The server
+// stops gracefully on Shutdown.
+func StartGRPC() error {
+	addr := os.Getenv("GRPC_LISTEN_ADDR")
+	if addr == "" {
+		addr = defaultGrpcAddr
+	}
+	if addr == "off" {
+		logrus.Info("gRPC server disabled")
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	server := NewGRPCServer()
+
+	go func() {
+		logrus.WithField("addr", listener.Addr().String()).Info("gRPC server listening")
+		if err := server.Serve(listener); err != nil {
+			logrus.WithError(err).Error("gRPC server stopped")
+		}
+	}()
+
+	OnShutdown(func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			server.Stop()
+			return fmt.Errorf("gRPC server did not drain: %w", ctx.Err())
+		}
+	})
+	return nil
+}
+
+// NewGRPCServer returns a server with InfoService and reflection registered.
+func NewGRPCServer() *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcInterceptor))
+	valhallav1.RegisterInfoServiceServer(server, &grpcInfoServer{})
+	reflection.Register(server)
+	return server
+}
+
+// grpcInterceptor gives every call a request id, echoed in the x-request-id
+// header, a span and a log line, rate limits it and turns errors into
+// statuses.
+func grpcInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (response interface{}, err error) {
+	var requestId string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get("x-request-id"); len(ids) > 0 {
+			requestId = ids[0]
+		}
+	}
+	ctx = utils.ContextWithRequestId(ctx, requestId)
+	grpc.SetHeader(ctx, metadata.Pairs("x-request-id", utils.RequestId(ctx)))
+
+	ctx, span := tracing.Start(ctx, info.FullMethod)
+	start := time.Now()
+	defer func() {
+		if rec := recover(); rec != nil {
+			utils.Log(ctx).WithFields(logrus.Fields{"panic": fmt.Sprint(rec), "stack": string(debug.Stack())}).Error("recovered from panic")
+			response, err = nil, utils.ErrInternal(fmt.Sprintf("panic: %v", rec))
+		}
+		if err != nil {
+			err = grpcStatus(ctx, err)
+		}
+		tracing.End(span, err)
+		utils.Log(ctx).WithFields(logrus.Fields{
+			"method":   info.FullMethod,
+			"code":     status.Code(err).String(),
+			"duration": time.Since(start).String(),
+		}).Info("grpc request")
+	}()
+
+	if err := grpcLimit(ctx, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// grpcHeaders are the metadata keys read as HTTP headers to identify a client.
+var grpcHeaders = []string{"x-api-key", "authorization", "x-forwarded-for", "x-real-ip"}
+
+// grpcLimit charges a call like limitRequest charges a request, the
+// X-RateLimit-* headers are sent as lower case metadata.
+func grpcLimit(ctx context.Context, req interface{}) error {
+	r := (&http.Request{Header: http.Header{}}).WithContext(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range grpcHeaders {
+			for _, value := range md.Get(key) {
+				r.Header.Add(key, value)
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+	}
+
+	client, err := identify(r)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	err = charge(ctx, client, grpcCost(req), header)
+	md := metadata.MD{}
+	for key, values := range header {
+		md.Append(key, values...)
+	}
+	grpc.SetHeader(ctx, md)
+	return err
+}
+
+// grpcCost weighs a call as the query it runs.
+func grpcCost(req interface{}) int {
+	switch req := req.(type) {
+	case *valhallav1.ListGenesisPoolsRequest, *valhallav1.GetUserPortfolioRequest:
+		return allPoolsCost
+	case *valhallav1.GetGenesisBalancesRequest:
+		if len(req.Pools) == 0 {
+			return allPoolsCost
+		}
+		return len(req.Pools)
+	case *valhallav1.GetGenesisPairRequest:
+		return queryCost("get-genesis-pair", nil)
+	}
+	return 1
+}
+
+// grpcStatus converts err like HandleResponse does, with the error type,
+// request id and whether to retry in an ErrorInfo detail.
+func grpcStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	apiErr := utils.AsError(err)
+	if apiErr.Code >= 500 {
+		utils.Log(ctx).WithError(apiErr).WithField("type", apiErr.Type).Error("grpc request failed")
+	}
+
+	code, ok := grpcCodes[apiErr.Type]
+	if !ok {
+		code = codes.Internal
+	}
+	message := apiErr.Details
+	if message == "" {
+		message = apiErr.Message
+	}
+
+	st := status.New(code, message)
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: string(apiErr.Type),
+		Domain: "valhalla-api",
+		Metadata: map[string]string{
+			"request-id": utils.RequestId(ctx),
+			"retryable":  strconv.FormatBool(apiErr.Retryable),
+		},
+	})
+	if detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+type grpcInfoServer struct {
+	valhallav1.UnimplementedInfoServiceServer
+}
+
+func (s *grpcInfoServer) GetVersion(ctx context.Context, req *valhallav1.GetVersionRequest) (*valhallav1.GetVersionResponse, error) {
+	response, err := Query(ctx, "version", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return &valhallav1.GetVersionResponse{Version: response.(utils.VersionResponse).Version}, nil
+}
+
+func (s *grpcInfoServer) ListGenesisPools(ctx context.Context, req *valhallav1.ListGenesisPoolsRequest) (*valhallav1.ListGenesisPoolsResponse, error) {
+	pools, err := ListGenesisPools(ctx, req.ChainId, req.Genesis)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]common.Address, len(pools))
+	for pid, pool := range pools {
+		tokens[pid] = pool.Token
+	}
+	metadata, err := ResolveTokens(ctx, req.ChainId, tokens, false)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &valhallav1.ListGenesisPoolsResponse{Pools: make([]*valhallav1.GenesisPool, len(pools))}
+	for pid, pool := range pools {
+		rewardTokens := make([]string, len(pool.GaugeInfo.RewardTokens))
+		for i, token := range pool.GaugeInfo.RewardTokens {
+			rewardTokens[i] = token.Hex()
+		}
+		response.Pools[pid] = &valhallav1.GenesisPool{
+			Pid:                 uint64(pid),
+			Token:               pool.Token.Hex(),
+			DepFee:              pool.DepFee.String(),
+			AllocPoint:          pool.AllocPoint.String(),
+			LastRewardTime:      pool.LastRewardTime.String(),
+			AccValhallaPerShare: pool.AccValhallaPerShare.String(),
+			IsStarted:           pool.IsStarted,
+			IsGauge:             pool.GaugeInfo.IsGauge,
+			Gauge:               pool.GaugeInfo.Gauge.Hex(),
+			RewardTokens:        rewardTokens,
+			PoolValhallaPerSec:  pool.PoolValhallaPerSec.String(),
+			TokenMetadata:       grpcTokenMetadata(tokenRef(metadata, pool.Token)),
+		}
+	}
+	return response, nil
+}
+
+func (s *grpcInfoServer) GetGenesisBalances(ctx context.Context, req *valhallav1.GetGenesisBalancesRequest) (*valhallav1.GetGenesisBalancesResponse, error) {
+	values := url.Values{"chain-id": {req.ChainId}, "genesis": {req.Genesis}}
+	if req.User != "" {
+		values.Set("user", req.User)
+	}
+
+	// Addresses are looked up for every pool or for none
+	withAddress := 0
+	for _, pool := range req.Pools {
+		values.Add("pools.pid", strconv.FormatUint(pool.Pid, 10))
+		if pool.Address != "" {
+			values.Add("pools.address", pool.Address)
+			withAddress++
+		}
+	}
+	if withAddress != 0 && withAddress != len(req.Pools) {
+		return nil, utils.ErrMalformedRequest("give the address of every pool or of none")
+	}
+
+	response, err := Query(ctx, "get-genesis-balances", values)
+	if err != nil {
+		return nil, err
+	}
+	return &valhallav1.GetGenesisBalancesResponse{Pools: grpcPoolBalances(response.(GetGenesisBalancesResponse).Pools)}, nil
+}
+
+func (s *grpcInfoServer) GetGenesisPair(ctx context.Context, req *valhallav1.GetGenesisPairRequest) (*valhallav1.GetGenesisPairResponse, error) {
+	values := url.Values{"chain-id": {req.ChainId}, "genesis": {req.Genesis}, "pair": {req.Pair}}
+	for key, value := range map[string]string{"base": req.Base, "quote": req.Quote, "user": req.User} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if req.Pid != nil {
+		values.Set("pid", strconv.FormatUint(*req.Pid, 10))
+	}
+
+	response, err := Query(ctx, "get-genesis-pair", values)
+	if err != nil {
+		return nil, err
+	}
+	pair := response.(GetGenesisPairResponse)
+	return &valhallav1.GetGenesisPairResponse{
+		Pair:             pair.PairAddress,
+		TotalSupply:      pair.PairTotalSupply,
+		Pid:              grpcPid(pair.PoolId),
+		BaseBalance:      pair.BaseBalance,
+		QuoteBalance:     pair.QuoteBalance,
+		GenesisBalance:   pair.GenesisBalance,
+		UserBalance:      pair.UserBalance,
+		UserStake:        pair.UserStake,
+		UserReward:       pair.UserReward,
+		UserBaseBalance:  pair.UserBaseBalance,
+		UserQuoteBalance: pair.UserQuoteBalance,
+		TokenMetadata:    grpcTokenMetadata(pair.TokenMetadata),
+		BaseMetadata:     grpcTokenMetadata(pair.BaseMetadata),
+		QuoteMetadata:    grpcTokenMetadata(pair.QuoteMetadata),
+	}, nil
+}
+
+func (s *grpcInfoServer) GetUserPortfolio(ctx context.Context, req *valhallav1.GetUserPortfolioRequest) (*valhallav1.GetUserPortfolioResponse, error) {
+	if req.User == "" {
+		return nil, utils.ErrMalformedRequest("user is required")
+	}
+	values := url.Values{"chain-id": {req.ChainId}, "genesis": {req.Genesis}, "user": {req.User}}
+
+	response, err := Query(ctx, "get-genesis-balances", values)
+	if err != nil {
+		return nil, err
+	}
+
+	portfolio := &valhallav1.GetUserPortfolioResponse{User: req.User, Positions: []*valhallav1.PoolBalance{}}
+	totalReward := new(big.Int)
+	for _, pool := range grpcPoolBalances(response.(GetGenesisBalancesResponse).Pools) {
+		if isZeroAmount(pool.UserStake) && isZeroAmount(pool.UserReward) && isZeroAmount(pool.UserBalance) {
+			continue
+		}
+		if reward, ok := new(big.Int).SetString(pool.UserReward, 10); ok {
+			totalReward.Add(totalReward, reward)
+		}
+		portfolio.Positions = append(portfolio.Positions, pool)
+	}
+	portfolio.TotalReward = totalReward.String()
+	return portfolio, nil
+}
+
+func grpcPoolBalances(pools []GetGenesisBalanceResponse) []*valhallav1.PoolBalance {
+	balances := make([]*valhallav1.PoolBalance, len(pools))
+	for i, pool := range pools {
+		balances[i] = &valhallav1.PoolBalance{
+			Token:          pool.Token,
+			Pid:            grpcPid(pool.PoolId),
+			GenesisBalance: pool.GenesisBalance,
+			UserBalance:    pool.UserBalance,
+			UserStake:      pool.UserStake,
+			UserReward:     pool.UserReward,
+			TokenMetadata:  grpcTokenMetadata(pool.TokenMetadata),
+		}
+	}
+	return balances
+}
+
+func grpcTokenMetadata(metadata *TokenMetadata) *valhallav1.TokenMetadata {
+	if metadata == nil {
+		return nil
+	}
+	token := &valhallav1.TokenMetadata{
+		Address:     metadata.Address,
+		Name:        metadata.Name,
+		Symbol:      metadata.Symbol,
+		TotalSupply: metadata.TotalSupply,
+		DisplayName: metadata.DisplayName,
+		Logo:        metadata.Logo,
+	}
+	if metadata.Decimals != nil {
+		decimals := uint32(*metadata.Decimals)
+		token.Decimals = &decimals
+	}
+	return token
+}
+
+func grpcPid(pid string) uint64 {
+	n, _ := strconv.ParseUint(pid, 10, 64)
+	return n
+}
+
+func isZeroAmount(amount string) bool {
+	n, ok := new(big.Int).SetString(amount, 10)
+	return !ok || n.Sign() == 0
+}
@@ -0,0 +1,139 @@
+package infoHandler
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+
+	valhallav1 "github.com/FudgyDRS/valhalla-api/pkg/pb/valhalla/v1"
+	"github.com/FudgyDRS/valhalla-api/pkg/ratelimit"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// grpcClient serves NewGRPCServer in memory for the test.
+func grpcClient(t *testing.T) valhallav1.InfoServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return valhallav1.NewInfoServiceClient(conn)
+}
+
+// Every error type has its own gRPC code, with the type, request id and
+// whether to retry in an ErrorInfo detail.
+func TestGrpcCodes(t *testing.T) {
+	for _, kind := range utils.ErrorCatalogue() {
+		if _, ok := grpcCodes[kind.Type]; !ok {
+			t.Errorf("%s has no gRPC code", kind.Type)
+		}
+	}
+
+	ctx := utils.ContextWithRequestId(context.Background(), "req-1")
+	tests := []struct {
+		err       error
+		code      codes.Code
+		reason    utils.ErrorCode
+		retryable string
+	}{
+		{utils.ErrMalformedRequest("bad pid"), codes.InvalidArgument, utils.CodeMalformedRequest, "false"},
+		{utils.ErrChainUnsupported("1"), codes.InvalidArgument, utils.CodeChainUnsupported, "false"},
+		{utils.ErrNotFound("no pool"), codes.NotFound, utils.CodeNotFound, "false"},
+		{utils.ErrUnauthorized("no key"), codes.Unauthenticated, utils.CodeUnauthorized, "false"},
+		{utils.ErrRateLimited("spent"), codes.ResourceExhausted, utils.CodeRateLimited, "true"},
+		{utils.ErrRpcUnavailable(errors.New("down")), codes.Unavailable, utils.CodeRpcUnavailable, "true"},
+		{context.DeadlineExceeded, codes.DeadlineExceeded, utils.CodeTimeout, "true"},
+		{errors.New("unexpected"), codes.Internal, utils.CodeInternal, "false"},
+	}
+	for _, test := range tests {
+		st := status.Convert(grpcStatus(ctx, test.err))
+		if st.Code() != test.code {
+			t.Errorf("%v: code %s, want %s", test.err, st.Code(), test.code)
+			continue
+		}
+		if len(st.Details()) != 1 {
+			t.Errorf("%v: details %v", test.err, st.Details())
+			continue
+		}
+		info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		if !ok || info.Reason != string(test.reason) || info.Metadata["request-id"] != "req-1" || info.Metadata["retryable"] != test.retryable {
+			t.Errorf("%v: error info %v", test.err, st.Details()[0])
+		}
+	}
+
+	// A status is passed through
+	if st := status.Convert(grpcStatus(ctx, status.Error(codes.Aborted, "aborted"))); st.Code() != codes.Aborted {
+		t.Errorf("status became %s", st.Code())
+	}
+}
+
+// uint256 amounts go over the wire as decimal strings, whole.
+func TestGrpcUint256Strings(t *testing.T) {
+	genesis := common.HexToAddress(testGenesis)
+	large, _ := new(big.Int).SetString("115792089237316195423570985008687907853269984665640564039457584007913129639935", 10)
+	base := genesisCall(genesis, 1)
+	newFakeChain(t).call = func(to common.Address, data []byte) ([]byte, bool) {
+		if to == fakeToken(0) && methodName(AbiErc20, data) == "balanceOf" {
+			out, _ := Abis.MustContract(AbiErc20).Methods["balanceOf"].Outputs.Pack(large)
+			return out, true
+		}
+		return base(to, data)
+	}
+
+	response, err := grpcClient(t).GetGenesisBalances(context.Background(), &valhallav1.GetGenesisBalancesRequest{
+		ChainId: "146",
+		Genesis: testGenesis,
+		Pools:   []*valhallav1.PoolRef{{Pid: 0, Address: fakeToken(0).Hex()}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Pools) != 1 || response.Pools[0].GenesisBalance != large.String() {
+		t.Errorf("pools = %v, want a genesis balance of 2^256-1", response.Pools)
+	}
+}
+
+// Calls are charged to the key in their metadata, as HTTP requests are.
+func TestGrpcRateLimit(t *testing.T) {
+	config := ratelimit.DefaultConfig()
+	config.RequireKey = true
+	config.Tiers["test"] = ratelimit.Tier{Rate: 0.001, Burst: 2}
+	config.Keys = []ratelimit.Key{{Name: "backend", Tier: "test", Key: "secret-key"}}
+	useLimiter(t, config)
+	client := grpcClient(t)
+
+	if _, err := client.GetVersion(context.Background(), &valhallav1.GetVersionRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call without a key: %v, want unauthenticated", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "secret-key")
+	var header metadata.MD
+	if _, err := client.GetVersion(ctx, &valhallav1.GetVersionRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatal(err)
+	}
+	if remaining := header.Get("x-ratelimit-remaining"); len(remaining) != 1 || remaining[0] != "1" {
+		t.Errorf("x-ratelimit-remaining = %v, want 1", remaining)
+	}
+	client.GetVersion(ctx, &valhallav1.GetVersionRequest{})
+	if _, err := client.GetVersion(ctx, &valhallav1.GetVersionRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("third call: %v, want resource exhausted", err)
+	}
+}
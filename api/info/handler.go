@@ -1,54 +1,353 @@
 package infoHandler
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
 	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+type QueryHandler func(r *http.Request) (interface{}, error)
+
+// QueryRoute is a ?query= entry point. Params, Body and Response hold zero
+// values of the types the query binds and returns, used to describe the API.
+// Timeout, when set, replaces QUERY_TIMEOUT for this query. Cost weighs the
+// query for rate limiting from its query string, 1 when unset.
+type QueryRoute struct {
+	Handler  QueryHandler
+	Summary  string
+	Params   interface{}
+	Body     interface{}
+	Response interface{}
+	Timeout  time.Duration
+	Cost     func(values url.Values) int
+}
+
+// queryRoutes maps every ?query= value to its service function.
+var queryRoutes = map[string]QueryRoute{
+	"version": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return VersionRequest(r)
+		},
+		Summary:  "API version",
+		Response: utils.VersionResponse{},
+	},
+	"get-genesis-balances": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return GetGenesisBalances(r)
+		},
+		Summary:  "Genesis and user balances, stakes and pending rewards for a set of pools",
+		Params:   GetGenesisBalancesParams{},
+		Response: GetGenesisBalancesResponse{},
+		Cost:     poolsCost,
+	},
+	"get-genesis-pair": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return GetGenesisPairBalance(r)
+		},
+		Summary:  "Pair reserves, genesis stake and user position for a single pool",
+		Params:   GetGenesisPairParams{},
+		Response: GetGenesisPairResponse{},
+		Cost:     fixedCost(2),
+	},
+	"get-tokens": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return GetTokens(r)
+		},
+		Summary:  "Name, symbol, decimals and total supply of tokens, with local display names and logos",
+		Params:   GetTokensParams{},
+		Response: GetTokensResponse{},
+		Cost:     tokensCost,
+	},
+	"export-user-history": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return ExportUserHistory(r)
+		},
+		Summary:  "Deposits, withdrawals and reward claims of a user across every pool, as CSV or NDJSON, with an estimate of deposit fees",
+		Params:   ExportUserHistoryParams{},
+		Response: ExportUserHistoryResponse{},
+		Timeout:  2 * time.Minute,
+		Cost:     fixedCost(allPoolsCost),
+	},
+	"call": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return ContractCallRequest(r)
+		},
+		Summary:  "Read any contract method with typed params and decoded outputs",
+		Params:   ContractCallParams{},
+		Response: ContractCallResponse{},
+	},
+	"decode": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return DecodeRequest(r)
+		},
+		Summary:  "Decode calldata, an event log or every log of a transaction",
+		Params:   DecodeParams{},
+		Response: DecodeResponse{},
+	},
+	"inspect": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return InspectRequest(r)
+		},
+		Summary:  "Code presence, proxy slots and raw storage of an address",
+		Params:   InspectParams{},
+		Response: InspectResponse{},
+	},
+	"errors": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return utils.ErrorCatalogue(), nil
+		},
+		Summary:  "Catalogue of error types with their HTTP status and whether to retry",
+		Response: []utils.ErrorKind{},
+	},
+	"build-operator-batch": {
+		Handler: func(r *http.Request) (interface{}, error) {
+			return BuildOperatorBatch(r)
+		},
+		Summary:  "Diff a genesis operator change set against chain state and build a Safe batch",
+		Timeout:  45 * time.Second,
+		Cost:     fixedCost(allPoolsCost),
+		Body:     BuildOperatorBatchParams{},
+		Response: BuildOperatorBatchResponse{},
+	},
+}
+
+// multicallPlanners lists the queries whose calls can be merged with others
+// into a single multicall when they arrive in the same batch.
+var multicallPlanners = map[string]func(r *http.Request) (*MulticallPlan, error){
+	"get-genesis-balances": planGenesisBalances,
+	"get-genesis-pair":     planGenesisPair,
+}
+
+// Vercel serves Handler without running main, so logging is configured here
+// as well. main configures it again once flags are parsed.
+func init() {
+	if err := utils.SetupLogging("json"); err != nil {
+		logrus.WithError(err).Error("invalid logging config, using defaults")
+	}
+}
+
 func Handler(w http.ResponseWriter, r *http.Request) {
+	metrics.Instrument(queryLabel, tracing.Middleware(queryLabel, http.HandlerFunc(handle))).ServeHTTP(w, r)
+}
+
+// queryLabel names the query of a request for metrics, using route names and
+// REST patterns so the label set stays bounded.
+func queryLabel(r *http.Request) string {
+	switch r.URL.Path {
+	case "/api/openapi.json":
+		return "openapi"
+	case "/api/docs":
+		return "docs"
+	case "/healthz":
+		return "healthz"
+	case "/readyz":
+		return "readyz"
+	case streamPath:
+		return "stream"
+	case graphqlPath:
+		return "graphql"
+	case graphqlSchemaPath:
+		return "graphql-schema"
+	}
+
+	if strings.HasPrefix(r.URL.Path, restPrefix) {
+		if _, pattern := restMux.Handler(r); pattern != "" {
+			return pattern
+		}
+		return "unknown"
+	}
+
+	query := r.URL.Query().Get("query")
+	if query == "" && r.Method == http.MethodPost {
+		return "batch"
+	}
+	if _, ok := queryRoutes[query]; ok {
+		return query
+	}
+	return "unknown"
+}
+
+// loadAbisOnce loads the abi directory for deployments without main. A
+// malformed file is logged and the bundled abis are served.
+var loadAbisOnce = sync.OnceFunc(func() {
+	if err := LoadAbis(); err != nil {
+		logrus.WithError(err).Error("failed to load the abi directory")
+	}
+})
+
+func handle(w http.ResponseWriter, r *http.Request) {
+	r = utils.WithRequestId(w, r)
+	tracing.Annotate(r.Context(), attribute.String("request.id", utils.RequestId(r.Context())))
+
 	defer func() {
 		if rec := recover(); rec != nil {
-			log.Printf("\nRecovered from panic: %v", rec)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			utils.Log(r.Context()).WithFields(logrus.Fields{"panic": fmt.Sprint(rec), "stack": string(debug.Stack())}).Error("recovered from panic")
+			HandleResponse(w, r, nil, utils.ErrInternal(fmt.Sprintf("panic: %v", rec)))
 		}
 	}()
 
+	loadAbisOnce()
+
+	// Vercel routes the docs and probe paths to this function as well
+	switch r.URL.Path {
+	case "/api/openapi.json":
+		OpenAPIHandler(w, r)
+		return
+	case "/api/docs":
+		DocsHandler(w, r)
+		return
+	case "/healthz":
+		HealthHandler(w, r)
+		return
+	case "/readyz":
+		ReadyHandler(w, r)
+		return
+	case graphqlSchemaPath:
+		GraphQLSchemaHandler(w, r)
+		return
+	}
+
 	handlerWithCORS := utils.EnableCORS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		query := r.URL.Query()
-		var response interface{}
-		var err error
 
 		w.Header().Set("Content-Type", "application/json")
-		switch query.Get("query") {
-		case "version":
-			response, err = VersionRequest(r)
-			HandleResponse(w, r, response, err)
+
+		r, err := authenticate(r)
+		if err != nil {
+			HandleResponse(w, r, nil, err)
 			return
-		case "get-genesis-balances":
-			response, err = GetGenesisBalances(r)
-			HandleResponse(w, r, response, err)
+		}
+
+		if strings.HasPrefix(r.URL.Path, restPrefix) {
+			HandleRest(w, r)
 			return
-		case "get-genesis-pair":
-			response, err = GetGenesisPairBalance(r)
-			HandleResponse(w, r, response, err)
+		}
+
+		if r.URL.Path == streamPath {
+			HandleStream(w, r)
+			return
+		}
+
+		if r.URL.Path == graphqlPath {
+			HandleGraphQL(w, r)
+			return
+		}
+
+		// A POST without ?query= carries a JSON array of batched requests
+		if r.Method == http.MethodPost && query.Get("query") == "" {
+			HandleBatch(w, r)
+			return
+		}
+
+		route, ok := queryRoutes[query.Get("query")]
+		if !ok {
+			HandleResponse(w, r, nil, utils.ErrMalformedRequest("Invalid query parameter"))
 			return
-		default:
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(utils.ErrMalformedRequest("Invalid query parameter"))
+		}
+
+		if err := limitRequest(w, r, queryCost(query.Get("query"), query)); err != nil {
+			HandleResponse(w, r, nil, err)
 			return
 		}
+
+		response, err := runQuery(r, query.Get("query"), route.Handler)
+		HandleResponse(w, r, response, err)
 	}))
 
 	handlerWithCORS.ServeHTTP(w, r)
 }
 
+// queryResolvers fill the parameters of a query that can be read from chain,
+// for callers that run queries in-process. The REST routes set their own.
+var queryResolvers = map[string]func(ctx context.Context, values url.Values) error{
+	"get-genesis-balances": resolveGenesisPools,
+	"get-genesis-pair":     resolveGenesisPair,
+}
+
+// Query runs a query of queryRoutes in-process, with values as its query
+// string, under the same timeout as over HTTP. Parameters that can be read
+// from chain may be left out, as with the REST routes: every pool of the
+// genesis, the tokens and pid of a pair.
+func Query(ctx context.Context, query string, values url.Values) (interface{}, error) {
+	route, ok := queryRoutes[query]
+	if !ok {
+		return nil, utils.ErrMalformedRequest(fmt.Sprintf("unknown query %s", query))
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/api/info", nil)
+	if err != nil {
+		return nil, utils.ErrInternal(err.Error())
+	}
+
+	return runQuery(r, query, func(r *http.Request) (interface{}, error) {
+		if resolve := queryResolvers[query]; resolve != nil {
+			if err := resolve(r.Context(), values); err != nil {
+				return nil, err
+			}
+		}
+		r.URL.RawQuery = values.Encode()
+		return route.Handler(r)
+	})
+}
+
+// runQuery runs handler under the query's deadline, see QueryTimeout. The
+// request context is cancelled when the client disconnects, which stops any
+// RPC still in flight. Failures past the deadline are reported as TIMEOUT
+// whatever error the RPC layer surfaced.
+func runQuery(r *http.Request, query string, handler QueryHandler) (interface{}, error) {
+	timeout := QueryTimeout(query)
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	response, err := handler(r.WithContext(ctx))
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, utils.ErrTimeout(fmt.Errorf("%s exceeded %s: %w", query, timeout, err))
+	}
+	return response, err
+}
+
+// encodedResponse is a response written in its own format, as a download,
+// instead of JSON. Batches still embed it as JSON.
+type encodedResponse interface {
+	ContentType() string
+	Filename() string
+	Encode(w io.Writer) error
+}
+
+// HandleResponse writes the response, or the error with the HTTP status of
+// its type. Errors that are not a utils.Error are classified by AsError.
 func HandleResponse(w http.ResponseWriter, r *http.Request, response interface{}, err error) {
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(err)
+		apiErr := utils.AsError(err)
+		apiErr.RequestId = utils.RequestId(r.Context())
+		if apiErr.Code >= 500 {
+			utils.Log(r.Context()).WithError(apiErr).WithField("status", apiErr.Code).Error("request failed")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(int(apiErr.Code))
+		json.NewEncoder(w).Encode(apiErr)
+		return
+	}
+
+	if encoded, ok := response.(encodedResponse); ok {
+		w.Header().Set("Content-Type", encoded.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", encoded.Filename()))
+		if err := encoded.Encode(w); err != nil {
+			utils.Log(r.Context()).WithError(err).Error("failed to write response")
+		}
 		return
 	}
 
@@ -0,0 +1,212 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultReadyTimeout     = 5 * time.Second
+	defaultReadyCacheTTL    = 5 * time.Second
+	defaultReadyMaxBlockLag = 20
+)
+
+//http://localhost:8080/healthz
+
+// HealthHandler answers liveness probes. It touches no RPC, a process that
+// can serve it should not be restarted.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(LivenessResponse{Status: "ok", Version: Version})
+}
+
+//http://localhost:8080/readyz
+
+// ReadyHandler answers readiness probes with the state of every RPC of every
+// chain, and 503 when a chain has no healthy provider. Results are cached for
+// READY_CACHE_TTL so frequent probes do not multiply upstream calls.
+func ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	response := readiness.get(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if response.Status != "ready" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+type readinessCache struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	response  ReadinessResponse
+}
+
+var readiness = &readinessCache{}
+
+func (c *readinessCache) get(ctx context.Context) ReadinessResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checkedAt) < envDuration("READY_CACHE_TTL", defaultReadyCacheTTL) {
+		metrics.CacheHit("readiness")
+		return c.response
+	}
+	metrics.CacheMiss("readiness")
+
+	// Detached from the probe so a probe hanging up does not cache a failure
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), envDuration("READY_TIMEOUT", defaultReadyTimeout))
+	defer cancel()
+
+	c.response = checkReadiness(ctx)
+	c.checkedAt = time.Now()
+	return c.response
+}
+
+func checkReadiness(ctx context.Context) ReadinessResponse {
+	// SupportedChains lists some chains under several ids
+	chains := make(map[string]ChainInfo)
+	for _, chain := range SupportedChains {
+		chains[chain.ID] = chain
+	}
+	ids := make([]string, 0, len(chains))
+	for id := range chains {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	response := ReadinessResponse{
+		Status:    "ready",
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+		Chains:    make([]ChainReadiness, len(ids)),
+	}
+
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(i int, chain ChainInfo) {
+			defer wg.Done()
+			response.Chains[i] = checkChain(ctx, chain)
+		}(i, chains[id])
+	}
+	wg.Wait()
+
+	for _, chain := range response.Chains {
+		if chain.Healthy == 0 {
+			response.Status = "unavailable"
+			logrus.WithField("chain_id", chain.ChainId).Warn("no healthy RPC provider")
+		}
+	}
+	return response
+}
+
+func checkChain(ctx context.Context, chain ChainInfo) ChainReadiness {
+	readiness := ChainReadiness{ChainId: chain.ID, Name: chain.Name}
+
+	multicallAddress, err := getMulticallAddress(chain.ID)
+	if err != nil {
+		return readiness
+	}
+	readiness.Multicall = multicallAddress.Hex()
+
+	providers := rpcClients.get(chain)
+	readiness.Providers = make([]RpcReadiness, len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider *rpcProvider) {
+			defer wg.Done()
+			readiness.Providers[i] = checkProvider(ctx, chain, multicallAddress, provider)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	// Lag is measured against the best provider serving the right chain
+	for _, provider := range readiness.Providers {
+		if provider.ChainIdOk && provider.LatestBlock > readiness.BestBlock {
+			readiness.BestBlock = provider.LatestBlock
+		}
+	}
+	maxLag := readyMaxBlockLag()
+	for i := range readiness.Providers {
+		provider := &readiness.Providers[i]
+		if provider.LatestBlock > 0 {
+			provider.Lag = readiness.BestBlock - provider.LatestBlock
+		}
+		provider.Healthy = provider.Reachable && provider.ChainIdOk && provider.MulticallCode &&
+			provider.LatestBlock > 0 && provider.Lag <= maxLag
+		if provider.Healthy {
+			readiness.Healthy++
+		}
+	}
+
+	return readiness
+}
+
+// checkProvider stops at the first failing check, later ones depend on it.
+// It reads through the provider's probe client, as the pooled one would fail
+// over to another provider.
+func checkProvider(ctx context.Context, chain ChainInfo, multicallAddress common.Address, provider *rpcProvider) RpcReadiness {
+	status := RpcReadiness{Provider: metrics.ProviderLabel(provider.url)}
+	start := time.Now()
+	defer func() { status.LatencyMs = time.Since(start).Milliseconds() }()
+
+	attemptCtx, cancel := rpcAttemptContext(ctx)
+	chainId, err := provider.probe.ChainID(attemptCtx)
+	cancel()
+	if err != nil {
+		status.Error = utils.Redact(err.Error())
+		return status
+	}
+	status.Reachable = true
+	status.ChainId = chainId.String()
+	expected, ok := new(big.Int).SetString(chain.ID, 0)
+	status.ChainIdOk = ok && expected.Cmp(chainId) == 0
+	if !status.ChainIdOk {
+		status.Error = "provider serves chain " + status.ChainId
+		return status
+	}
+
+	attemptCtx, cancel = rpcAttemptContext(ctx)
+	status.LatestBlock, err = provider.probe.BlockNumber(attemptCtx)
+	cancel()
+	if err != nil {
+		status.Error = utils.Redact(err.Error())
+		return status
+	}
+
+	status.MulticallCode, err = hasCodeViaMulticall(ctx, provider.probe, chain.ID, multicallAddress)
+	if err != nil {
+		status.Error = utils.Redact(err.Error())
+	} else if !status.MulticallCode {
+		status.Error = "multicall contract has no code"
+	}
+	return status
+}
+
+func readyMaxBlockLag() uint64 {
+	value := os.Getenv("READY_MAX_BLOCK_LAG")
+	if value == "" {
+		return defaultReadyMaxBlockLag
+	}
+	lag, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		logrus.WithField("value", value).Warn("ignoring invalid READY_MAX_BLOCK_LAG, expected blocks")
+		return defaultReadyMaxBlockLag
+	}
+	return lag
+}
@@ -0,0 +1,152 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// multicallCode answers getExtcodesize on the multicall contract.
+func multicallCode(size int64) func(common.Address, []byte) ([]byte, bool) {
+	return func(to common.Address, data []byte) ([]byte, bool) {
+		if methodName(AbiMulticall, data) != "getExtcodesize" {
+			return nil, false
+		}
+		out, _ := Abis.MustContract(AbiMulticall).Methods["getExtcodesize"].Outputs.Pack(big.NewInt(size))
+		return out, true
+	}
+}
+
+// getReadiness serves /readyz without its cache.
+func getReadiness(t *testing.T) (int, ReadinessResponse) {
+	t.Helper()
+	saved := readiness
+	readiness = &readinessCache{}
+	defer func() { readiness = saved }()
+
+	w := httptest.NewRecorder()
+	ReadyHandler(w, httptest.NewRequest("GET", "/readyz", nil))
+	var response ReadinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid readiness %s: %v", w.Body.String(), err)
+	}
+	return w.Code, response
+}
+
+// Each provider is probed on its own, a failing one is not hidden by the
+// failover to a working one.
+func TestReadinessProbesEachProvider(t *testing.T) {
+	chain := newFakeChain(t)
+	chain.call = multicallCode(100)
+	working := SupportedChains["146"].RPC[0]
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer broken.Close()
+	useProviders(t, broken.URL, working)
+
+	code, response := getReadiness(t)
+	if code != http.StatusOK || response.Status != "ready" {
+		t.Fatalf("status %d %s, want ready with one healthy provider", code, response.Status)
+	}
+	providers := response.Chains[0].Providers
+	if len(providers) != 2 || providers[0].Reachable || providers[0].Healthy || !providers[1].Healthy {
+		t.Errorf("providers = %+v, want the first unreachable", providers)
+	}
+	if response.Chains[0].Healthy != 1 {
+		t.Errorf("healthy = %d, want 1", response.Chains[0].Healthy)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	w := httptest.NewRecorder()
+	HealthHandler(w, httptest.NewRequest("GET", "/healthz", nil))
+
+	var response LivenessResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.Status != "ok" || response.Version != Version {
+		t.Errorf("liveness %d %+v", w.Code, response)
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestReadinessUnavailable(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(chain *fakeChain)
+		error string
+	}{
+		{"no multicall code", func(chain *fakeChain) {
+			chain.call = multicallCode(0)
+		}, "multicall contract has no code"},
+		{"wrong chain", func(chain *fakeChain) {
+			chain.call = multicallCode(100)
+			chain.methods["eth_chainId"] = func([]json.RawMessage) (interface{}, error) { return "0x1", nil }
+		}, "provider serves chain 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.setup(newFakeChain(t))
+
+			code, response := getReadiness(t)
+			if code != http.StatusServiceUnavailable || response.Status != "unavailable" {
+				t.Fatalf("status %d %s, want unavailable", code, response.Status)
+			}
+			if provider := response.Chains[0].Providers[0]; provider.Healthy || provider.Error != test.error {
+				t.Errorf("provider = %+v, want error %q", provider, test.error)
+			}
+		})
+	}
+}
+
+// A provider more than READY_MAX_BLOCK_LAG blocks behind the best one is
+// unhealthy.
+func TestReadinessBlockLag(t *testing.T) {
+	t.Setenv("READY_MAX_BLOCK_LAG", "20")
+	behind := newFakeChain(t)
+	behind.call = multicallCode(100)
+	ahead := &fakeChain{call: multicallCode(100), methods: map[string]func([]json.RawMessage) (interface{}, error){
+		"eth_blockNumber": func([]json.RawMessage) (interface{}, error) { return "0x30", nil },
+	}}
+	server := httptest.NewServer(ahead)
+	defer server.Close()
+	useProviders(t, SupportedChains["146"].RPC[0], server.URL)
+
+	code, response := getReadiness(t)
+	if code != http.StatusOK {
+		t.Fatalf("status %d, want ready", code)
+	}
+	chain := response.Chains[0]
+	if chain.BestBlock != 0x30 || chain.Healthy != 1 {
+		t.Errorf("chain = %+v, want best block 48 and one healthy provider", chain)
+	}
+	if lagging := chain.Providers[0]; lagging.Healthy || lagging.Lag != 0x30-0x10 {
+		t.Errorf("lagging provider = %+v", lagging)
+	}
+}
+
+// Probes within READY_CACHE_TTL are answered from the last check.
+func TestReadinessIsCached(t *testing.T) {
+	chain := newFakeChain(t)
+	chain.call = multicallCode(100)
+	var checks atomic.Int32
+	chain.methods["eth_chainId"] = func([]json.RawMessage) (interface{}, error) {
+		checks.Add(1)
+		return "0x92", nil
+	}
+
+	cache := &readinessCache{}
+	first := cache.get(context.Background())
+	second := cache.get(context.Background())
+	if checks.Load() != 1 || first.CheckedAt != second.CheckedAt {
+		t.Errorf("checked %d times, want 1", checks.Load())
+	}
+}
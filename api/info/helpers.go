@@ -2,13 +2,17 @@ package infoHandler
 
 import (
 	"context"
-	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
-	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
 	"github.com/FudgyDRS/valhalla-api/pkg/utils"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -16,26 +20,44 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func DialClient(jsonrpc string) (*ethclient.Client, error) {
 	client, err := ethclient.Dial(jsonrpc)
 	if err != nil {
-		err_ := fmt.Errorf("client connection failed: %v", err)
+		err_ := fmt.Errorf("client connection failed: %w", err)
 		logrus.Error(err_.Error())
 		return nil, err_
 	}
 	return client, nil
 }
 
-func ViewFunction(client *ethclient.Client, contractAddress common.Address, parsedABI abi.ABI, methodName string, args ...interface{}) ([]byte, error) {
+type blockKey struct{}
+
+// WithBlock makes the contract reads done with ctx read the state at block
+// instead of the latest one. The RPC must serve archive state for old blocks.
+func WithBlock(ctx context.Context, block *big.Int) context.Context {
+	return context.WithValue(ctx, blockKey{}, block)
+}
+
+// BlockFromContext returns the block set by WithBlock, nil for the latest.
+func BlockFromContext(ctx context.Context) *big.Int {
+	block, _ := ctx.Value(blockKey{}).(*big.Int)
+	return block
+}
+
+func ViewFunction(ctx context.Context, client *ethclient.Client, contractAddress common.Address, parsedABI abi.ABI, methodName string, args ...interface{}) ([]byte, error) {
 	data, err := parsedABI.Pack(methodName, args...)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx, cancel := rpcAttemptContext(ctx)
+	defer cancel()
+
 	callMsg := ethereum.CallMsg{To: &contractAddress, Data: data}
-	result, err := client.CallContract(context.Background(), callMsg, nil)
+	result, err := client.CallContract(ctx, callMsg, BlockFromContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -56,32 +78,20 @@ func GetCallBytes(parsedABI abi.ABI, methodName string, args ...interface{}) ([]
 		}
 		return true
 	}
-	method, ok := parsedABI.Methods[methodName]
-	if !ok {
-		logrus.Errorf("Method %s not found in ABI", methodName)
+	if _, ok := parsedABI.Methods[methodName]; !ok {
+		return nil, fmt.Errorf("method %s not found in ABI", methodName)
 	}
-	logrus.Infof("Method inputs: %#v", method.Inputs)
 
 	var data []byte
 	var err error
 	if !isArgsEmpty(args) {
-		logrus.Info(methodName)
-		logrus.Info(args)
 		if len(args) == 1 {
 			if inner, ok := args[0].([]interface{}); ok {
 				args = inner // unwrap it
 			}
 		}
-		for i, arg := range args {
-			logrus.Infof("arg[%d] type: %T, value: %#v", i, arg, arg)
-		}
-
 		data, err = parsedABI.Pack(methodName, args...)
 	} else {
-		for i, arg := range args {
-			logrus.Infof("arg[%d] type: %T, value: %#v", i, arg, arg)
-		}
-
 		data, err = parsedABI.Pack(methodName)
 	}
 
@@ -93,7 +103,6 @@ func createCall(parsedABI abi.ABI, contractAddress common.Address, methodName st
 	if err != nil {
 		return Call{}, fmt.Errorf("bytes for call %v failed: %v", methodName, err.Error())
 	}
-	fmt.Printf("\ninternal calldata: \n%v\n", callData)
 
 	return Call{
 		Target:   contractAddress,
@@ -101,24 +110,24 @@ func createCall(parsedABI abi.ABI, contractAddress common.Address, methodName st
 	}, nil
 }
 
-func ExtCodeSize(client *ethclient.Client, address common.Address) ([]byte, int, error) {
-	ctx := context.Background()
-	code, err := client.CodeAt(ctx, address, nil) // nil block number for the latest state
+func ExtCodeSize(ctx context.Context, client *ethclient.Client, address common.Address) ([]byte, int, error) {
+	ctx, cancel := rpcAttemptContext(ctx)
+	defer cancel()
+
+	code, err := client.CodeAt(ctx, address, BlockFromContext(ctx))
 	if err != nil {
-		return nil, 0, fmt.Errorf("geth client failed to get extcodesize: %v", err)
+		return nil, 0, fmt.Errorf("geth client failed to get extcodesize: %w", err)
 	}
 	return code, len(code), nil
 }
 
-func GetStorageAt(client *ethclient.Client, address common.Address, slot int64) ([]byte, error) {
-	slotHash := common.BigToHash(common.Big1)
-	if slot != 0 {
-		slotHash = common.BigToHash(big.NewInt(int64(slot)))
-	}
+func GetStorageAt(ctx context.Context, client *ethclient.Client, address common.Address, slot common.Hash) ([]byte, error) {
+	ctx, cancel := rpcAttemptContext(ctx)
+	defer cancel()
 
-	storage, err := client.StorageAt(context.Background(), address, slotHash, nil)
+	storage, err := client.StorageAt(ctx, address, slot, BlockFromContext(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get storage: %+v", err.Error())
+		return nil, fmt.Errorf("failed to get storage: %w", err)
 	}
 
 	return storage, nil
@@ -146,14 +155,18 @@ func ConstructCallData(methodName string, params []utils.Parameter) ([]byte, err
 func createMethodSignature(methodName string, params []utils.Parameter) string {
 	var types []string
 	for _, param := range params {
-		types = append(types, param.Type)
+		// Use the canonical form so that e.g. "uint" hashes as "uint256"
+		if abiType, err := ParseAbiType(param.Type); err == nil {
+			types = append(types, abiType.String())
+		} else {
+			types = append(types, param.Type)
+		}
 	}
 	return fmt.Sprintf("%s(%s)", methodName, strings.Join(types, ","))
 }
 
 func packParameters(params []utils.Parameter) ([]byte, error) {
 	if len(params) == 0 {
-		logrus.Error("param count 0")
 		return []byte{}, nil
 	}
 
@@ -161,20 +174,19 @@ func packParameters(params []utils.Parameter) ([]byte, error) {
 	var values []interface{}
 
 	for _, param := range params {
-		abiType, err := abi.NewType(param.Type, "", nil)
+		abiType, err := ParseAbiType(param.Type)
 		if err != nil {
 			return nil, fmt.Errorf("invalid type %s: %v", param.Type, err)
 		}
 		arguments = append(arguments, abi.Argument{Type: abiType})
 
-		value, err := parseParameterValue(param.Type, param.Value)
+		value, err := ParseAbiValue(abiType, param.Value)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse parameter value: %v", err)
 		}
 		values = append(values, value)
 	}
 
-	logrus.Error(values)
 	packed, err := arguments.Pack(values...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pack values: %v", err)
@@ -183,88 +195,103 @@ func packParameters(params []utils.Parameter) ([]byte, error) {
 	return packed, nil
 }
 
-func parseParameterValue(paramType, paramValue string) (interface{}, error) {
-	switch paramType {
-	case "uint256":
-		val, ok := new(big.Int).SetString(paramValue, 10)
-		if !ok {
-			return nil, fmt.Errorf("invalid uint256: %s", paramValue)
-		}
-		return val, nil
-
-	case "address":
-		return common.HexToAddress(paramValue), nil
-
-	case "bytes":
-		if !strings.HasPrefix(paramValue, "0x") {
-			return nil, fmt.Errorf("bytes value must start with 0x")
-		}
-		data, err := hex.DecodeString(paramValue[2:])
-		if err != nil {
-			return nil, fmt.Errorf("invalid bytes: %s", paramValue)
-		}
-		return data, nil
-
-	case "bool":
-		return paramValue == "true", nil
-
-	case "string":
-		return paramValue, nil
-
-	default:
-		return nil, fmt.Errorf("unsupported parameter type: %s", paramType)
-	}
-}
-
+// CallContract sends callData, as built by ConstructCallData, to
+// contractAddress.
 func CallContract(
+	ctx context.Context,
 	client *ethclient.Client,
 	contractAddress common.Address,
-	methodName string,
-	params []utils.Parameter,
+	callData []byte,
 ) ([]byte, error) {
-	fmt.Printf("\n current params in callcontract: %v", params)
-	callData, err := ConstructCallData(methodName, params)
-	if err != nil {
-		return nil, fmt.Errorf("failed to construct call data: %v", err)
-	}
-
 	msg := ethereum.CallMsg{
 		To:   &contractAddress,
 		Data: callData,
 	}
 
-	result, err := client.CallContract(context.Background(), msg, nil)
+	ctx, cancel := rpcAttemptContext(ctx)
+	defer cancel()
+
+	result, err := client.CallContract(ctx, msg, BlockFromContext(ctx))
 	if err != nil {
-		return nil, fmt.Errorf("contract call failed: %v", err)
+		return nil, fmt.Errorf("contract call failed: %w", err)
 	}
 
 	return result, nil
 }
 
-func MulticallView(client *ethclient.Client, multicallAddress common.Address, calls []Calls) ([]MulticallResult, error) {
+// MulticallView reads calls through the multicall contract. Sets larger than
+// maxMulticallCalls, such as every pool of a big genesis, are split into
+// concurrent multicalls pinned to the same block.
+func MulticallView(ctx context.Context, client *ethclient.Client, multicallAddress common.Address, calls []Calls) ([]MulticallResult, error) {
+	if len(calls) <= maxMulticallCalls {
+		return multicallView(ctx, client, multicallAddress, calls)
+	}
+
+	if BlockFromContext(ctx) == nil {
+		attemptCtx, cancel := rpcAttemptContext(ctx)
+		block, err := client.BlockNumber(attemptCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch the latest block: %w", err)
+		}
+		ctx = WithBlock(ctx, new(big.Int).SetUint64(block))
+	}
+
+	chunks := make([][]MulticallResult, (len(calls)+maxMulticallCalls-1)/maxMulticallCalls)
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i := range chunks {
+		start := i * maxMulticallCalls
+		end := min(start+maxMulticallCalls, len(calls))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chunks[i], errs[i] = multicallView(ctx, client, multicallAddress, calls[start:end])
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return slices.Concat(chunks...), nil
+}
+
+func multicallView(ctx context.Context, client *ethclient.Client, multicallAddress common.Address, calls []Calls) (results []MulticallResult, err error) {
+	ctx, span := tracing.Start(ctx, "multicall", attribute.Int("multicall.calls", len(calls)))
+	defer func() { tracing.End(span, err) }()
+
+	_, buildSpan := tracing.Start(ctx, "multicall.build")
 	var multicallViewInput []Call
-	fmt.Print("\ngot multicall far0\n")
 	for _, call := range calls {
 		c, err := createCall(call.abi, call.contractAddress, call.method, call.params)
 		if err != nil {
+			tracing.End(buildSpan, err)
 			return nil, fmt.Errorf("failed to create call: %v", err)
 		}
 
 		multicallViewInput = append(multicallViewInput, c)
 	}
+	buildSpan.End()
 
-	parsedJSON, _ := abi.JSON(strings.NewReader(contractAbiMulticall))
-	returnData, err := ViewFunction(client, multicallAddress, parsedJSON, "multicallView", multicallViewInput)
+	parsedJSON := Abis.MustContract(AbiMulticall)
+	returnData, err := ViewFunction(ctx, client, multicallAddress, parsedJSON, "multicallView", multicallViewInput)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute multicallView: %v", err)
+		metrics.ObserveMulticall(len(calls), 0, err)
+		return nil, fmt.Errorf("failed to execute multicallView: %w", err)
 	}
 
+	_, decodeSpan := tracing.Start(ctx, "multicall.decode")
+	defer decodeSpan.End()
+
 	data, err := parsedJSON.Unpack("multicallView", returnData)
 	if err != nil {
+		metrics.ObserveMulticall(len(calls), 0, err)
 		return nil, fmt.Errorf("failed to unpack multicallView result: %v", err)
 	}
 
-	var results []MulticallResult
 	for _, v := range data {
 		for _, vv := range v.([]struct {
 			Success    bool   "json:\"success\""
@@ -277,121 +304,266 @@ func MulticallView(client *ethclient.Client, multicallAddress common.Address, ca
 		}
 	}
 
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
+		}
+	}
+	metrics.ObserveMulticall(len(calls), failed, nil)
+	span.SetAttributes(attribute.Int("multicall.failed_calls", failed))
+
 	return results, nil
 }
 
 func parseGenesisParams(r *http.Request) (*GetGenesisBalancesParams, error) {
-	q := r.URL.Query()
+	params := &GetGenesisBalancesParams{}
+	if err := utils.ParseAndValidateParams(r, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
 
-	chainID := q.Get("chain-id")
-	genesis := q.Get("genesis")
+func parseGenesisPairParams(r *http.Request) (*GetGenesisPairParams, error) {
+	params := &GetGenesisPairParams{}
+	if err := utils.ParseAndValidateParams(r, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
 
-	user := q.Get("user")
-	if user == "" {
-		user = "0x0000000000000000000000000000000000000000"
-	} else {
-		matched, err := regexp.MatchString(`^0x[0-9a-fA-F]{40}$`, user)
-		if err != nil {
-			return nil, fmt.Errorf("internal regex error: %v", err)
-		}
-		if !matched {
-			return nil, fmt.Errorf("invalid user address: %s", user)
+func parsePairTwapParams(r *http.Request) (*GetPairTwapParams, error) {
+	params := &GetPairTwapParams{}
+	if err := utils.ParseAndValidateParams(r, params); err != nil {
+		return nil, err
+	}
+	if params.Granularity == nil {
+		params.Granularity = big.NewInt(defaultTwapGranularity)
+	}
+	if params.Granularity.Sign() == 0 {
+		return nil, utils.ErrMalformedRequest("granularity must be at least 1")
+	}
+	if params.AmountIn != nil && params.AmountIn.Sign() == 0 {
+		return nil, utils.ErrMalformedRequest("amount-in must be positive")
+	}
+	return params, nil
+}
+
+func parseTokensParams(r *http.Request) (*GetTokensParams, error) {
+	params := &GetTokensParams{}
+	if err := utils.ParseAndValidateParams(r, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+func parseExportUserHistoryParams(r *http.Request) (*ExportUserHistoryParams, error) {
+	params := &ExportUserHistoryParams{}
+	if err := utils.ParseAndValidateParams(r, params); err != nil {
+		return nil, err
+	}
+	switch params.Format {
+	case "":
+		params.Format = "csv"
+	case "csv", "ndjson":
+	default:
+		return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid format %s, expected csv or ndjson", params.Format))
+	}
+	for _, block := range []*big.Int{params.FromBlock, params.ToBlock} {
+		if block != nil && !block.IsUint64() {
+			return nil, utils.ErrMalformedRequest(fmt.Sprintf("block %s is out of range", block))
 		}
 	}
+	if params.FromBlock != nil && params.ToBlock != nil && params.FromBlock.Cmp(params.ToBlock) > 0 {
+		return nil, utils.ErrMalformedRequest("from-block is after to-block")
+	}
+	return params, nil
+}
 
-	// Handle nested params manually
-	addresses := q["pools.address"]
-	pids := q["pools.pid"]
+func parseOperatorBatchParams(r *http.Request) (*BuildOperatorBatchParams, error) {
+	if r.Body == nil {
+		return nil, utils.ErrMalformedRequest("missing request body")
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid request body: %v", err))
+	}
+	var bodyFields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &bodyFields); err != nil {
+		return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid request body: %v", err))
+	}
 
-	if len(addresses) != len(pids) {
-		return nil, fmt.Errorf("mismatched pool addresses and pids")
+	params := &BuildOperatorBatchParams{}
+	if err := utils.ParseAndValidateParams(withoutBodyFields(r, bodyFields), params); err != nil {
+		return nil, err
+	}
+	// Body values take precedence over the query string
+	if err := json.Unmarshal(body, params); err != nil {
+		return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid request body: %v", err))
 	}
 
-	pools := make([]PoolParams, len(addresses))
-	for i := 0; i < len(addresses); i++ {
-		pools[i] = PoolParams{
-			Address: addresses[i],
-			PoolId:  pids[i],
-		}
+	if _, err := GetChainInfo(params.ChainId); err != nil {
+		return nil, utils.ErrMalformedRequest(err.Error())
+	}
+	if !common.IsHexAddress(params.GenesisAddress) {
+		return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid genesis address: %s", params.GenesisAddress))
+	}
+	if params.SafeAddress != "" && !common.IsHexAddress(params.SafeAddress) {
+		return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid safe address: %s", params.SafeAddress))
+	}
+	if len(params.Changes) == 0 {
+		return nil, utils.ErrMalformedRequest("change set is empty")
 	}
 
-	params := &GetGenesisBalancesParams{
-		ChainId:        chainID,
-		Pools:          pools,
-		GenesisAddress: genesis,
-		UserAddress:    user,
+	for i, change := range params.Changes {
+		if change.Token != "" && !common.IsHexAddress(change.Token) {
+			return nil, utils.ErrMalformedRequest(fmt.Sprintf("change %d: invalid token address: %s", i, change.Token))
+		}
+		if change.Address != "" && !common.IsHexAddress(change.Address) {
+			return nil, utils.ErrMalformedRequest(fmt.Sprintf("change %d: invalid address: %s", i, change.Address))
+		}
+		for _, token := range change.RewardTokens {
+			if !common.IsHexAddress(token) {
+				return nil, utils.ErrMalformedRequest(fmt.Sprintf("change %d: invalid reward token address: %s", i, token))
+			}
+		}
 	}
 
 	return params, nil
 }
 
-func parseGenesisPairParams(r *http.Request) (*GetGenesisPairParams, error) {
-	q := r.URL.Query()
-	chainID := q.Get("chain-id")
-	genesis := q.Get("genesis")
-	pair := q.Get("pair")
-	base := q.Get("base")
-	quote := q.Get("quote")
-	user := q.Get("user")
-	poolId := q.Get("pid")
-
-	validateAddress := func(address, fieldName string) error {
-		if address == "" {
-			return nil
+// withoutBodyFields drops the query parameters that repeat a field of the
+// JSON body, flattened or not, as batch items send their params both ways.
+// The body wins over the query string anyway.
+func withoutBodyFields(r *http.Request, bodyFields map[string]json.RawMessage) *http.Request {
+	query := r.URL.Query()
+	for key := range query {
+		root, _, _ := strings.Cut(key, ".")
+		if _, ok := bodyFields[root]; ok {
+			query.Del(key)
 		}
-		matched, err := regexp.MatchString(`^0x[0-9a-fA-F]{40}$`, address)
-		if err != nil {
-			return fmt.Errorf("internal regex error: %v", err)
+	}
+
+	stripped := r.Clone(r.Context())
+	stripped.URL.RawQuery = query.Encode()
+	return stripped
+}
+
+func parseContractCallParams(r *http.Request) (*ContractCallParams, error) {
+	params := &ContractCallParams{}
+	if err := utils.ParseAndValidateParams(r, params); err != nil {
+		return nil, err
+	}
+
+	methodName, signatureTypes, err := ParseMethodSignature(params.Method)
+	if err != nil {
+		return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid method signature: %v", err))
+	}
+	if methodName == "" {
+		return nil, utils.ErrMalformedRequest("missing method")
+	}
+
+	// Types may come from the signature instead of params.type
+	if strings.Contains(params.Method, "(") {
+		if len(signatureTypes) != len(params.Params) {
+			return nil, utils.ErrMalformedRequest(fmt.Sprintf("method signature takes %d params, got %d", len(signatureTypes), len(params.Params)))
 		}
-		if !matched {
-			return fmt.Errorf("invalid %s address: %s", fieldName, address)
+		for i, paramType := range signatureTypes {
+			if params.Params[i].Type != "" && params.Params[i].Type != paramType {
+				return nil, utils.ErrMalformedRequest(fmt.Sprintf("params.type[%d] does not match method signature", i))
+			}
+			params.Params[i].Type = paramType
+		}
+	}
+	for i, param := range params.Params {
+		if param.Type == "" {
+			return nil, utils.ErrMalformedRequest(fmt.Sprintf("missing params.type[%d]", i))
 		}
-		return nil
 	}
+	params.Method = methodName
 
-	if user == "" {
-		user = "0x0000000000000000000000000000000000000000"
-	} else {
-		if err := validateAddress(user, "user"); err != nil {
-			return nil, err
+	var returns []string
+	for _, ret := range params.Returns {
+		ret = strings.TrimSpace(ret)
+		if strings.HasPrefix(ret, "(") && strings.HasSuffix(ret, ")") {
+			if closing, err := matchingParen(ret, 0); err == nil && closing == len(ret)-1 {
+				ret = ret[1 : len(ret)-1]
+			}
 		}
+		returns = append(returns, SplitTopLevel(ret)...)
 	}
+	params.Returns = returns
+
+	return params, nil
+}
 
-	// Validate genesis, base, and pair addresses
-	if err := validateAddress(genesis, "genesis"); err != nil {
+func parseDecodeParams(r *http.Request) (*DecodeParams, error) {
+	params := &DecodeParams{}
+	if err := utils.ParseAndValidateParams(r, params); err != nil {
 		return nil, err
 	}
-	if err := validateAddress(base, "base"); err != nil {
-		return nil, err
+	params.Abi = strings.ToLower(params.Abi)
+
+	provided := 0
+	for _, set := range []bool{params.CallData != "", len(params.Topics) > 0, params.TxHash != (common.Hash{})} {
+		if set {
+			provided++
+		}
 	}
-	if err := validateAddress(pair, "pair"); err != nil {
-		return nil, err
+	if provided != 1 {
+		return nil, utils.ErrMalformedRequest("exactly one of calldata, topics or tx must be provided")
 	}
 
-	// Handle nested params manually
-	addresses := q["pools.address"]
-	pids := q["pools.pid"]
-	if len(addresses) != len(pids) {
-		return nil, fmt.Errorf("mismatched pool addresses and pids")
+	if params.Abi != "" {
+		if _, ok := Abis.Contract(params.Abi); !ok {
+			return nil, utils.ErrMalformedRequest(fmt.Sprintf("unknown abi: %s", params.Abi))
+		}
+	}
+	if params.TxHash != (common.Hash{}) && params.ChainId == "" {
+		return nil, utils.ErrMalformedRequest("chain-id is required to decode a transaction")
 	}
 
-	pools := make([]PoolParams, len(addresses))
-	for i := 0; i < len(addresses); i++ {
-		pools[i] = PoolParams{
-			Address: addresses[i],
-			PoolId:  pids[i],
-		}
+	return params, nil
+}
+
+func parseInspectParams(r *http.Request) (*InspectParams, error) {
+	params := &InspectParams{}
+	if err := utils.ParseAndValidateParams(r, params); err != nil {
+		return nil, err
 	}
+	return params, nil
+}
 
-	params := &GetGenesisPairParams{
-		ChainId:        chainID,
-		GenesisAddress: genesis,
-		PairAddress:    pair,
-		BaseAddress:    base,
-		QuoteAddress:   quote,
-		UserAddress:    user,
-		PoolId:         poolId,
+func parseGraphQLRequest(r *http.Request) (*GraphQLRequest, error) {
+	request := &GraphQLRequest{}
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			decoder := json.NewDecoder(strings.NewReader(variables))
+			decoder.UseNumber()
+			if err := decoder.Decode(&request.Variables); err != nil {
+				return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid variables: %v", err))
+			}
+		}
+	case http.MethodPost:
+		if r.Body == nil {
+			return nil, utils.ErrMalformedRequest("missing request body")
+		}
+		// Numbers stay json.Number so Int variables are not parsed as floats
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		if err := decoder.Decode(request); err != nil {
+			return nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid request body: %v", err))
+		}
+	default:
+		return nil, utils.ErrMalformedRequest("graphql accepts GET and POST")
 	}
 
-	return params, nil
+	if strings.TrimSpace(request.Query) == "" {
+		return nil, utils.ErrMalformedRequest("query is required")
+	}
+	return request, nil
 }
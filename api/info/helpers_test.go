@@ -0,0 +1,46 @@
+package infoHandler
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMulticallViewSplitsLargeSets(t *testing.T) {
+	const pools = maxMulticallCalls + 20
+	genesis := common.HexToAddress(testGenesis)
+	chain := newFakeChain(t)
+	chain.call = genesisCall(genesis, pools)
+
+	client, err := GetClientForChain(context.Background(), "146")
+	if err != nil {
+		t.Fatal(err)
+	}
+	multicall, _ := getMulticallAddress("146")
+	calls := make([]Calls, pools)
+	for pid := range calls {
+		calls[pid] = Calls{contractAddress: fakeToken(pid), abi: Abis.MustContract(AbiErc20), method: "balanceOf", params: genesis}
+	}
+
+	results, err := MulticallView(context.Background(), client, multicall, calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != pools {
+		t.Fatalf("got %d results, want %d", len(results), pools)
+	}
+	for pid, result := range results {
+		if !result.Success || new(big.Int).SetBytes(result.ReturnData).Int64() != int64(pid+1) {
+			t.Fatalf("result %d = %+v, want balance %d", pid, result, pid+1)
+		}
+	}
+
+	if len(chain.blocks) != 2 {
+		t.Fatalf("%d multicalls, want 2", len(chain.blocks))
+	}
+	if chain.blocks[0] != "0x10" || chain.blocks[1] != "0x10" {
+		t.Errorf("multicalls read blocks %v, want both at 0x10", chain.blocks)
+	}
+}
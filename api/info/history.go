@@ -0,0 +1,389 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// Below this many blocks a refused log range is an error rather than
+	// halved again
+	minHistoryLogRange = 100
+	headerBatchSize    = 100
+)
+
+// historyEvents maps the genesis events of a user to the event column. Each
+// of them indexes the user as its first topic.
+var historyEvents = map[string]string{
+	"Deposit":           "deposit",
+	"Withdraw":          "withdraw",
+	"EmergencyWithdraw": "emergency-withdraw",
+	"RewardPaid":        "reward",
+}
+
+var userHistoryColumns = []string{"timestamp", "block", "tx-hash", "log-index", "event", "pool-id", "token", "token-symbol", "amount", "amount-decimal", "fee-estimate", "fee-estimate-decimal"}
+
+//http://localhost:8080/api/info?query=export-user-history&chain-id=146&genesis=0x23Ee13d49e78811d063722D9228547a7dF73E42E&user=0x...&format=csv
+
+// ExportUserHistory lists the deposits, withdrawals, emergency withdrawals and
+// reward claims of a user across every pool of a genesis, oldest first. The
+// deposit fee is an estimate: it applies the pool's current depFee, as the
+// genesis keeps no past values, over DepFeeDenominator.
+func ExportUserHistory(r *http.Request) (ExportUserHistoryResponse, error) {
+	params, err := parseExportUserHistoryParams(r)
+	if err != nil {
+		return ExportUserHistoryResponse{}, err
+	}
+
+	LogExportUserHistoryParams(r.Context(), params)
+
+	ctx := r.Context()
+
+	client, err := GetClientForChain(ctx, params.ChainId)
+	if err != nil {
+		return ExportUserHistoryResponse{}, err
+	}
+
+	toBlock, err := historyToBlock(ctx, client, params.ToBlock)
+	if err != nil {
+		return ExportUserHistoryResponse{}, err
+	}
+	var fromBlock uint64
+	if params.FromBlock != nil {
+		fromBlock = params.FromBlock.Uint64()
+	} else if fromBlock, err = deploymentBlock(ctx, client, params.GenesisAddress, toBlock); err != nil {
+		return ExportUserHistoryResponse{}, err
+	}
+	if fromBlock > toBlock {
+		return ExportUserHistoryResponse{}, utils.ErrMalformedRequest(fmt.Sprintf("from-block %d is after block %d", fromBlock, toBlock))
+	}
+
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+	logs, err := fetchUserLogs(ctx, client, parsedGenesisABI, params.GenesisAddress, params.UserAddress, fromBlock, toBlock)
+	if err != nil {
+		return ExportUserHistoryResponse{}, err
+	}
+
+	response := ExportUserHistoryResponse{
+		Format:    params.Format,
+		User:      params.UserAddress.Hex(),
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Rows:      []UserHistoryRow{},
+	}
+	if len(logs) == 0 {
+		return response, nil
+	}
+
+	events := make(map[common.Hash]string, len(historyEvents))
+	for name := range historyEvents {
+		events[parsedGenesisABI.Events[name].ID] = name
+	}
+
+	pools, err := fetchGenesisPools(ctx, params.ChainId, params.GenesisAddress)
+	if err != nil {
+		return ExportUserHistoryResponse{}, err
+	}
+
+	// Rewards are paid in the genesis token, which is only read when claimed
+	var rewardToken common.Address
+	var tokens []common.Address
+	blocks := make(map[uint64]struct{})
+	denominator := big.NewInt(DepFeeDenominator())
+	for _, log := range logs {
+		blocks[log.BlockNumber] = struct{}{}
+		if events[log.Topics[0]] != "RewardPaid" {
+			if pid := log.Topics[2].Big(); pid.IsInt64() && pid.Int64() < int64(len(pools)) {
+				tokens = append(tokens, pools[pid.Int64()].Token)
+			}
+			continue
+		}
+		if rewardToken == (common.Address{}) {
+			returnData, err := ViewFunction(ctx, client, params.GenesisAddress, parsedGenesisABI, "valhalla")
+			if err != nil {
+				return ExportUserHistoryResponse{}, fmt.Errorf("failed to read the reward token: %w", err)
+			}
+			out, err := parsedGenesisABI.Unpack("valhalla", returnData)
+			if err != nil {
+				return ExportUserHistoryResponse{}, fmt.Errorf("failed to unpack the reward token: %w", err)
+			}
+			rewardToken = out[0].(common.Address)
+			tokens = append(tokens, rewardToken)
+		}
+	}
+
+	metadata, err := ResolveTokens(ctx, params.ChainId, tokens, false)
+	if err != nil {
+		return ExportUserHistoryResponse{}, fmt.Errorf("failed to read token metadata: %w", err)
+	}
+	times, err := fetchBlockTimes(ctx, client, blocks)
+	if err != nil {
+		return ExportUserHistoryResponse{}, err
+	}
+
+	for _, log := range logs {
+		name := events[log.Topics[0]]
+		amount, err := unpackUint(parsedGenesisABI.Unpack(name, log.Data))
+		if err != nil {
+			return ExportUserHistoryResponse{}, fmt.Errorf("failed to unpack %s at %s: %w", name, log.TxHash.Hex(), err)
+		}
+
+		row := UserHistoryRow{
+			Timestamp: time.Unix(int64(times[log.BlockNumber]), 0).UTC().Format(time.RFC3339),
+			Block:     log.BlockNumber,
+			TxHash:    log.TxHash.Hex(),
+			LogIndex:  log.Index,
+			Event:     historyEvents[name],
+			Amount:    amount.String(),
+		}
+
+		fee := new(big.Int)
+		token := rewardToken
+		if name != "RewardPaid" {
+			pid := log.Topics[2].Big()
+			row.PoolId = pid.String()
+			token = common.Address{}
+			if pid.IsInt64() && pid.Int64() < int64(len(pools)) {
+				pool := pools[pid.Int64()]
+				token = pool.Token
+				if name == "Deposit" {
+					fee.Mul(amount, pool.DepFee).Div(fee, denominator)
+				}
+			}
+		}
+		row.FeeEstimate = fee.String()
+
+		if token != (common.Address{}) {
+			row.Token = token.Hex()
+			row.TokenSymbol = metadata[token].Symbol
+			if decimals := metadata[token].Decimals; decimals != nil {
+				row.AmountDecimal = utils.FormatUnits(amount, *decimals)
+				row.FeeEstimateDecimal = utils.FormatUnits(fee, *decimals)
+			}
+		}
+		response.Rows = append(response.Rows, row)
+	}
+	return response, nil
+}
+
+// historyToBlock is the last block to export: to-block, the block of the
+// context or the latest one.
+func historyToBlock(ctx context.Context, client *ethclient.Client, toBlock *big.Int) (uint64, error) {
+	if toBlock != nil {
+		return toBlock.Uint64(), nil
+	}
+	if block := BlockFromContext(ctx); block != nil {
+		return block.Uint64(), nil
+	}
+
+	attemptCtx, cancel := rpcAttemptContext(ctx)
+	defer cancel()
+	latest, err := client.BlockNumber(attemptCtx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch the latest block: %w", err)
+	}
+	return latest, nil
+}
+
+// deploymentBlock finds the first block at which address has code with a
+// binary search, which needs an RPC serving historical state.
+func deploymentBlock(ctx context.Context, client *ethclient.Client, address common.Address, toBlock uint64) (uint64, error) {
+	hasCode := func(block uint64) (bool, error) {
+		_, size, err := ExtCodeSize(WithBlock(ctx, new(big.Int).SetUint64(block)), client, address)
+		if err != nil {
+			return false, fmt.Errorf("failed to find the deployment block of %s, give from-block instead: %w", address.Hex(), err)
+		}
+		return size > 0, nil
+	}
+
+	deployed, err := hasCode(toBlock)
+	if err != nil {
+		return 0, err
+	}
+	if !deployed {
+		return 0, utils.ErrNotFound(fmt.Sprintf("no contract at %s at block %d", address.Hex(), toBlock))
+	}
+
+	low, high := uint64(0), toBlock
+	for low < high {
+		mid := low + (high-low)/2
+		deployed, err := hasCode(mid)
+		if err != nil {
+			return 0, err
+		}
+		if deployed {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return low, nil
+}
+
+// fetchUserLogs reads the history events of user in ranges of at most
+// HistoryLogRange blocks. Providers cap the blocks or the logs of a single
+// request, so a refused range is halved and tried again.
+func fetchUserLogs(ctx context.Context, client *ethclient.Client, parsedGenesisABI abi.ABI, genesis common.Address, user common.Address, fromBlock uint64, toBlock uint64) ([]types.Log, error) {
+	var topics []common.Hash
+	for name := range historyEvents {
+		topics = append(topics, parsedGenesisABI.Events[name].ID)
+	}
+	query := ethereum.FilterQuery{
+		Addresses: []common.Address{genesis},
+		Topics:    [][]common.Hash{topics, {common.BytesToHash(user.Bytes())}},
+	}
+
+	var logs []types.Log
+	blockRange := HistoryLogRange()
+	for start := fromBlock; ; {
+		end := toBlock
+		if toBlock-start >= blockRange {
+			end = start + blockRange - 1
+		}
+		query.FromBlock, query.ToBlock = new(big.Int).SetUint64(start), new(big.Int).SetUint64(end)
+
+		attemptCtx, cancel := rpcAttemptContext(ctx)
+		found, err := client.FilterLogs(attemptCtx, query)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil && blockRange > minHistoryLogRange {
+				blockRange /= 2
+				continue
+			}
+			return nil, fmt.Errorf("failed to fetch genesis logs of blocks %d to %d: %w", start, end, err)
+		}
+		for _, log := range found {
+			if !log.Removed && len(log.Topics) >= historyTopics(parsedGenesisABI, log.Topics) {
+				logs = append(logs, log)
+			}
+		}
+
+		if end == toBlock {
+			return logs, nil
+		}
+		start = end + 1
+	}
+}
+
+// historyTopics is the number of topics a log needs to be read as the history
+// event its first topic names: the signature, the user and, but for
+// RewardPaid, the pid. Logs with fewer, from a contract that does not index
+// them, are skipped.
+func historyTopics(parsedGenesisABI abi.ABI, topics []common.Hash) int {
+	if len(topics) > 0 && topics[0] == parsedGenesisABI.Events["RewardPaid"].ID {
+		return 2
+	}
+	return 3
+}
+
+// fetchBlockTimes reads the timestamp of blocks with batched
+// eth_getBlockByNumber requests.
+func fetchBlockTimes(ctx context.Context, client *ethclient.Client, blocks map[uint64]struct{}) (map[uint64]uint64, error) {
+	numbers := make([]uint64, 0, len(blocks))
+	for block := range blocks {
+		numbers = append(numbers, block)
+	}
+
+	times := make(map[uint64]uint64, len(numbers))
+	for start := 0; start < len(numbers); start += headerBatchSize {
+		batch := numbers[start:min(start+headerBatchSize, len(numbers))]
+		headers := make([]*struct {
+			Timestamp hexutil.Uint64 `json:"timestamp"`
+		}, len(batch))
+		elems := make([]rpc.BatchElem, len(batch))
+		for i, block := range batch {
+			elems[i] = rpc.BatchElem{
+				Method: "eth_getBlockByNumber",
+				Args:   []interface{}{hexutil.EncodeUint64(block), false},
+				Result: &headers[i],
+			}
+		}
+
+		attemptCtx, cancel := rpcAttemptContext(ctx)
+		err := client.Client().BatchCallContext(attemptCtx, elems)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch block headers: %w", err)
+		}
+		for i, elem := range elems {
+			if elem.Error != nil {
+				return nil, fmt.Errorf("failed to fetch block %d: %w", batch[i], elem.Error)
+			}
+			if headers[i] == nil {
+				return nil, fmt.Errorf("block %d not found", batch[i])
+			}
+			times[batch[i]] = uint64(headers[i].Timestamp)
+		}
+	}
+	return times, nil
+}
+
+func (response ExportUserHistoryResponse) ContentType() string {
+	if response.Format == "ndjson" {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func (response ExportUserHistoryResponse) Filename() string {
+	return fmt.Sprintf("valhalla-history-%s.%s", response.User, response.Format)
+}
+
+// Encode writes one row per line, with a header line for CSV.
+func (response ExportUserHistoryResponse) Encode(w io.Writer) error {
+	if response.Format == "ndjson" {
+		encoder := json.NewEncoder(w)
+		for _, row := range response.Rows {
+			if err := encoder.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write(userHistoryColumns)
+	for _, row := range response.Rows {
+		writer.Write([]string{
+			row.Timestamp,
+			fmt.Sprint(row.Block),
+			row.TxHash,
+			fmt.Sprint(row.LogIndex),
+			row.Event,
+			row.PoolId,
+			row.Token,
+			csvText(row.TokenSymbol),
+			row.Amount,
+			row.AmountDecimal,
+			row.FeeEstimate,
+			row.FeeEstimateDecimal,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText keeps spreadsheets from evaluating a token symbol, which anyone can
+// choose, as a formula.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,69 @@
+package infoHandler
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestExportUserHistory(t *testing.T) {
+	useTokenRegistry(t, maxCachedTokens)
+	genesis := common.HexToAddress(testGenesis)
+	user := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	deposit := Abis.MustContract(AbiGenesis).Events["Deposit"].ID
+
+	chain := newFakeChain(t)
+	chain.call = genesisCall(genesis, 2)
+	amount := common.LeftPadBytes(big.NewInt(50000).Bytes(), 32)
+	chain.methods["eth_getLogs"] = func(params []json.RawMessage) (interface{}, error) {
+		log := func(index int, topics ...common.Hash) map[string]interface{} {
+			return map[string]interface{}{
+				"address":          genesis,
+				"topics":           topics,
+				"data":             hexutil.Bytes(amount),
+				"blockNumber":      "0x5",
+				"transactionHash":  common.BigToHash(big.NewInt(int64(index))),
+				"transactionIndex": "0x0",
+				"blockHash":        common.Hash{1},
+				"logIndex":         hexutil.EncodeUint64(uint64(index)),
+				"removed":          false,
+			}
+		}
+		return []interface{}{
+			log(0, deposit, common.BytesToHash(user.Bytes()), common.BigToHash(big.NewInt(1))),
+			// A Deposit without its pid topic is skipped
+			log(1, deposit, common.BytesToHash(user.Bytes())),
+		}, nil
+	}
+	chain.methods["eth_getBlockByNumber"] = func(params []json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"timestamp": "0x6553f100"}, nil
+	}
+
+	t.Setenv("DEP_FEE_DENOMINATOR", "1000")
+	r := httptest.NewRequest("GET", "/api/info?chain-id=146&genesis="+testGenesis+"&user="+user.Hex()+"&from-block=1&to-block=9", nil)
+	response, err := ExportUserHistory(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Rows) != 1 {
+		t.Fatalf("got %d rows, want 1: %+v", len(response.Rows), response.Rows)
+	}
+	// depFee 100 of 1000
+	if row := response.Rows[0]; row.PoolId != "1" || row.Token != fakeToken(1).Hex() || row.FeeEstimate != "5000" {
+		t.Errorf("row = %+v", row)
+	}
+
+	var csv bytes.Buffer
+	if err := response.Encode(&csv); err != nil {
+		t.Fatal(err)
+	}
+	if header, _, _ := strings.Cut(csv.String(), "\n"); !strings.HasSuffix(header, "fee-estimate,fee-estimate-decimal") {
+		t.Errorf("csv header = %s", header)
+	}
+}
@@ -0,0 +1,161 @@
+package infoHandler
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+var (
+	// bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1)
+	eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// bytes32(uint256(keccak256("eip1967.proxy.admin")) - 1)
+	eip1967AdminSlot = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	// bytes32(uint256(keccak256("eip1967.proxy.beacon")) - 1)
+	eip1967BeaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+	// keccak256("PROXIABLE")
+	eip1822ProxiableSlot = common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7")
+)
+
+//http://localhost:8080/api/info?query=inspect&chain-id=146&address=0x23Ee13d49e78811d063722D9228547a7dF73E42E&slot=0&slot=0x1
+
+func InspectRequest(r *http.Request) (InspectResponse, error) {
+	params, err := parseInspectParams(r)
+	if err != nil {
+		return InspectResponse{}, err
+	}
+
+	LogInspectParams(r.Context(), params)
+
+	ctx := r.Context()
+
+	client, err := GetClientForChain(ctx, params.ChainId)
+	if err != nil {
+		return InspectResponse{}, err
+	}
+
+	address := params.Address
+	code, codeSize, err := ExtCodeSize(ctx, client, address)
+	if err != nil {
+		return InspectResponse{}, err
+	}
+
+	response := InspectResponse{
+		Address:  address.Hex(),
+		Kind:     "eoa",
+		CodeSize: codeSize,
+		CodeHash: crypto.Keccak256Hash(code).Hex(),
+	}
+	if codeSize > 0 {
+		response.Kind = "contract"
+	}
+
+	if params.ExpectedCodeHash != (common.Hash{}) {
+		matches := response.CodeHash == params.ExpectedCodeHash.Hex()
+		response.CodeHashMatches = &matches
+	}
+
+	if codeSize > 0 {
+		proxy, err := detectProxy(ctx, client, params.ChainId, address)
+		if err != nil {
+			return InspectResponse{}, err
+		}
+		response.Proxy = proxy
+	}
+
+	for _, slot := range params.Slots {
+		slotHash := common.BigToHash(slot)
+		value, err := GetStorageAt(ctx, client, address, slotHash)
+		if err != nil {
+			return InspectResponse{}, err
+		}
+		response.Slots = append(response.Slots, InspectSlot{
+			Slot:  slotHash.Hex(),
+			Value: common.BytesToHash(value).Hex(),
+		})
+	}
+
+	return response, nil
+}
+
+func detectProxy(ctx context.Context, client *ethclient.Client, chainId string, address common.Address) (*InspectProxy, error) {
+	readAddressSlot := func(slot common.Hash) (common.Address, error) {
+		value, err := GetStorageAt(ctx, client, address, slot)
+		if err != nil {
+			return common.Address{}, err
+		}
+		return common.BytesToAddress(value), nil
+	}
+
+	implementation, err := readAddressSlot(eip1967ImplementationSlot)
+	if err != nil {
+		return nil, err
+	}
+	admin, err := readAddressSlot(eip1967AdminSlot)
+	if err != nil {
+		return nil, err
+	}
+	beacon, err := readAddressSlot(eip1967BeaconSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	var proxy *InspectProxy
+	switch {
+	case implementation != (common.Address{}) || beacon != (common.Address{}):
+		proxy = &InspectProxy{Standard: "EIP-1967"}
+	default:
+		proxiable, err := readAddressSlot(eip1822ProxiableSlot)
+		if err != nil {
+			return nil, err
+		}
+		if proxiable == (common.Address{}) {
+			return nil, nil
+		}
+		implementation = proxiable
+		proxy = &InspectProxy{Standard: "EIP-1822"}
+	}
+
+	if implementation != (common.Address{}) {
+		proxy.Implementation = implementation.Hex()
+		hasCode, err := hasCodeViaMulticall(ctx, client, chainId, implementation)
+		if err != nil {
+			return nil, err
+		}
+		proxy.ImplementationHasCode = hasCode
+	}
+	if admin != (common.Address{}) {
+		proxy.Admin = admin.Hex()
+	}
+	if beacon != (common.Address{}) {
+		proxy.Beacon = beacon.Hex()
+	}
+
+	return proxy, nil
+}
+
+// hasCodeViaMulticall uses the multicall contract's getExtcodesize so the
+// check runs against the same contract the rest of the API reads through.
+func hasCodeViaMulticall(ctx context.Context, client *ethclient.Client, chainId string, address common.Address) (bool, error) {
+	multicallAddress, err := getMulticallAddress(chainId)
+	if err != nil {
+		return false, err
+	}
+
+	parsedMulticallABI := Abis.MustContract(AbiMulticall)
+	returnData, err := ViewFunction(ctx, client, multicallAddress, parsedMulticallABI, "getExtcodesize", address)
+	if err != nil {
+		return false, fmt.Errorf("getExtcodesize call failed: %w", err)
+	}
+	size, err := parsedMulticallABI.Unpack("getExtcodesize", returnData)
+	if err != nil {
+		return false, fmt.Errorf("failed to unpack getExtcodesize: %v", err)
+	}
+
+	return size[0].(*big.Int).Sign() > 0, nil
+}
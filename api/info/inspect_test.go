@@ -0,0 +1,105 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeContracts answers eth_getCode and eth_getStorageAt from code and
+// storage, and records the storage slots read.
+func fakeContracts(chain *fakeChain, code map[common.Address][]byte, storage map[common.Hash]common.Hash) *[]common.Hash {
+	var mu sync.Mutex
+	var slots []common.Hash
+	chain.methods["eth_getCode"] = func(params []json.RawMessage) (interface{}, error) {
+		var address common.Address
+		json.Unmarshal(params[0], &address)
+		return hexutil.Bytes(code[address]), nil
+	}
+	chain.methods["eth_getStorageAt"] = func(params []json.RawMessage) (interface{}, error) {
+		var slot common.Hash
+		json.Unmarshal(params[1], &slot)
+		mu.Lock()
+		slots = append(slots, slot)
+		mu.Unlock()
+		return storage[slot], nil
+	}
+	return &slots
+}
+
+func TestInspectProxy(t *testing.T) {
+	proxy := common.HexToAddress(testGenesis)
+	implementation := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	admin := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+	code := []byte{0x60, 0x80}
+
+	chain := newFakeChain(t)
+	slots := fakeContracts(chain, map[common.Address][]byte{proxy: code}, map[common.Hash]common.Hash{
+		eip1967ImplementationSlot: common.BytesToHash(implementation.Bytes()),
+		eip1967AdminSlot:          common.BytesToHash(admin.Bytes()),
+		{}:                        common.BigToHash(big.NewInt(42)),
+	})
+	// getExtcodesize of the implementation, through the multicall contract
+	chain.call = func(to common.Address, data []byte) ([]byte, bool) {
+		if methodName(AbiMulticall, data) != "getExtcodesize" {
+			return nil, false
+		}
+		out, _ := Abis.MustContract(AbiMulticall).Methods["getExtcodesize"].Outputs.Pack(big.NewInt(100))
+		return out, true
+	}
+
+	values := url.Values{
+		"chain-id":         {"146"},
+		"address":          {proxy.Hex()},
+		"slot":             {"0", "0x1"},
+		"expect-code-hash": {crypto.Keccak256Hash(code).Hex()},
+	}
+	response, err := Query(context.Background(), "inspect", values)
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+	inspected := response.(InspectResponse)
+
+	if inspected.Kind != "contract" || inspected.CodeSize != 2 || inspected.CodeHashMatches == nil || !*inspected.CodeHashMatches {
+		t.Errorf("inspect = %+v", inspected)
+	}
+	if p := inspected.Proxy; p == nil || p.Standard != "EIP-1967" || p.Implementation != implementation.Hex() || !p.ImplementationHasCode || p.Admin != admin.Hex() || p.Beacon != "" {
+		t.Errorf("proxy = %+v", inspected.Proxy)
+	}
+
+	// Slot 0 is read as slot 0
+	if len(inspected.Slots) != 2 || inspected.Slots[0].Slot != (common.Hash{}).Hex() || inspected.Slots[0].Value != common.BigToHash(big.NewInt(42)).Hex() {
+		t.Errorf("slots = %+v", inspected.Slots)
+	}
+	if inspected.Slots[1].Slot != common.BigToHash(big.NewInt(1)).Hex() || inspected.Slots[1].Value != (common.Hash{}).Hex() {
+		t.Errorf("slot 1 = %+v", inspected.Slots[1])
+	}
+	read := map[common.Hash]bool{}
+	for _, slot := range *slots {
+		read[slot] = true
+	}
+	if !read[common.Hash{}] || !read[common.BigToHash(big.NewInt(1))] {
+		t.Errorf("read slots %v, want 0 and 1", *slots)
+	}
+}
+
+func TestInspectAccount(t *testing.T) {
+	chain := newFakeChain(t)
+	fakeContracts(chain, nil, nil)
+
+	response, err := Query(context.Background(), "inspect", url.Values{"chain-id": {"146"}, "address": {testUser.Hex()}})
+	if err != nil {
+		t.Fatalf("inspect failed: %v", err)
+	}
+	inspected := response.(InspectResponse)
+	if inspected.Kind != "eoa" || inspected.CodeSize != 0 || inspected.Proxy != nil || inspected.CodeHash != crypto.Keccak256Hash(nil).Hex() {
+		t.Errorf("inspect = %+v", inspected)
+	}
+}
@@ -0,0 +1,131 @@
+package infoHandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/ratelimit"
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// allPoolsCost is charged for genesis queries that list every pool, as the
+// pool count is only known once the chain has been read.
+const allPoolsCost = 10
+
+// rateLimiter is built on first use since Vercel never runs main. A broken
+// config is logged and replaced by the defaults rather than failing requests.
+var rateLimiter = sync.OnceValue(func() *ratelimit.Limiter {
+	config, err := ratelimit.LoadConfig()
+	if err == nil {
+		var limiter *ratelimit.Limiter
+		if limiter, err = ratelimit.New(config); err == nil {
+			return limiter
+		}
+	}
+	logrus.WithError(err).Error("invalid rate limit config, using defaults")
+	limiter, _ := ratelimit.New(ratelimit.DefaultConfig())
+	return limiter
+})
+
+type clientKey struct{}
+
+// authenticate resolves the API key or IP the request is charged to and
+// stores it on the context for limitRequest.
+func authenticate(r *http.Request) (*http.Request, error) {
+	client, err := identify(r)
+	if err != nil {
+		return r, err
+	}
+	return r.WithContext(context.WithValue(r.Context(), clientKey{}, client)), nil
+}
+
+// identify resolves the client of r, a missing or unknown key is
+// unauthorized.
+func identify(r *http.Request) (ratelimit.Client, error) {
+	client, err := rateLimiter().Identify(r)
+	if err != nil {
+		if errors.Is(err, ratelimit.ErrMissingKey) || errors.Is(err, ratelimit.ErrInvalidKey) {
+			return ratelimit.Client{}, utils.ErrUnauthorized(err.Error())
+		}
+		return ratelimit.Client{}, err
+	}
+
+	tracing.Annotate(r.Context(), attribute.String("client.name", client.Name), attribute.String("client.tier", client.Tier))
+	return client, nil
+}
+
+// limitRequest charges cost tokens to the request's client and sets the
+// X-RateLimit-* headers. Requests that were not authenticated are not limited.
+func limitRequest(w http.ResponseWriter, r *http.Request, cost int) error {
+	client, ok := r.Context().Value(clientKey{}).(ratelimit.Client)
+	if !ok {
+		return nil
+	}
+	return charge(r.Context(), client, cost, w.Header())
+}
+
+// charge takes cost tokens from client and writes the X-RateLimit-* headers
+// to header.
+func charge(ctx context.Context, client ratelimit.Client, cost int, header http.Header) error {
+	decision := rateLimiter().Allow(client, float64(cost))
+	decision.WriteHeaders(header)
+	if decision.Allowed {
+		return nil
+	}
+
+	metrics.RateLimited(client.Tier)
+	utils.Log(ctx).WithFields(logrus.Fields{"client": client.Name, "tier": client.Tier, "cost": cost}).Info("rate limited")
+	return utils.ErrRateLimited(fmt.Sprintf("request costs %d tokens, %d left for tier %s", cost, decision.Remaining, client.Tier))
+}
+
+// setCacheControl lets a response be cached for seconds. A CDN answers a
+// cached response without reaching the API, so cache hits are neither
+// authenticated nor rate limited: responses are private to the caller when
+// the limiter requires a key, and vary on the key headers either way.
+func setCacheControl(w http.ResponseWriter, seconds int) {
+	w.Header().Add("Vary", "Authorization")
+	w.Header().Add("Vary", "X-API-Key")
+	if rateLimiter().RequiresKey() {
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", seconds))
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d, s-maxage=%d", seconds, seconds))
+}
+
+// queryCost weighs a query by the work it fans out into, 1 unless the route
+// sets Cost.
+func queryCost(query string, values url.Values) int {
+	route, ok := queryRoutes[query]
+	if !ok || route.Cost == nil {
+		return 1
+	}
+	return max(1, route.Cost(values))
+}
+
+// poolsCost charges one token per pool read.
+func poolsCost(values url.Values) int {
+	pools := max(len(values["pools.address"]), len(values["pools.pid"]))
+	if pools == 0 {
+		return allPoolsCost
+	}
+	return pools
+}
+
+// tokensCost charges one token per token read.
+func tokensCost(values url.Values) int {
+	return len(values["tokens"])
+}
+
+func fixedCost(cost int) func(url.Values) int {
+	return func(url.Values) int {
+		return cost
+	}
+}
@@ -1,39 +1,131 @@
 package infoHandler
 
-import "github.com/sirupsen/logrus"
-
-const (
-	ColorReset  = "\033[0m"
-	ColorBlue   = "\033[34m"
-	ColorGreen  = "\033[32m"
-	ColorCyan   = "\033[36m"
-	ColorYellow = "\033[33m"
-)
+import (
+	"context"
+	"strings"
 
-func LogGenesisParams(params *GetGenesisBalancesParams) {
-	logrus.Infof("%sChain ID:%s        %s", ColorCyan, ColorReset, params.ChainId)
-	logrus.Infof("%sGenesis Address:%s %s", ColorCyan, ColorReset, params.GenesisAddress)
-	logrus.Infof("%sUser Address:%s    %s", ColorCyan, ColorReset, params.UserAddress)
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
 
-	logrus.Infof("%sPools:%s", ColorYellow, ColorReset)
+func LogGenesisParams(ctx context.Context, params *GetGenesisBalancesParams) {
+	pools := make([]string, len(params.Pools))
 	for i, pool := range params.Pools {
-		logrus.Infof("  %s[%d]%s Address: %s%s%s  PID: %s%s%s",
-			ColorBlue, i, ColorReset,
-			ColorGreen, pool.Address, ColorReset,
-			ColorGreen, pool.PoolId, ColorReset,
-		)
+		pools[i] = pool.PoolId.String() + ":" + pool.Address.Hex()
 	}
+
+	utils.Log(ctx).WithFields(logrus.Fields{
+		"chain_id": params.ChainId,
+		"genesis":  params.GenesisAddress.Hex(),
+		"user":     params.UserAddress.Hex(),
+		"pools":    strings.Join(pools, ","),
+	}).Debug("genesis balances params")
 }
 
-func LogGenesisPairParams(params *GetGenesisPairParams) {
-	logrus.Infof("%sChain ID:%s %s", ColorCyan, ColorReset, params.ChainId)
-	logrus.Infof("%sGenesis Address:%s %s", ColorCyan, ColorReset, params.GenesisAddress)
-	logrus.Infof("%sPair Address:%s %s", ColorCyan, ColorReset, params.PairAddress)
-	logrus.Infof("%sBase Address:%s %s", ColorCyan, ColorReset, params.BaseAddress)
-	logrus.Infof("%sQuote Address:%s %s", ColorCyan, ColorReset, params.QuoteAddress)
+func LogGenesisPairParams(ctx context.Context, params *GetGenesisPairParams) {
+	fields := logrus.Fields{
+		"chain_id": params.ChainId,
+		"genesis":  params.GenesisAddress.Hex(),
+		"pair":     params.PairAddress.Hex(),
+		"base":     params.BaseAddress.Hex(),
+		"quote":    params.QuoteAddress.Hex(),
+	}
 
 	// Only log user address if it's provided (since it's optional)
-	if params.UserAddress != "" {
-		logrus.Infof("%sUser Address:%s %s", ColorCyan, ColorReset, params.UserAddress)
+	if params.UserAddress != (common.Address{}) {
+		fields["user"] = params.UserAddress.Hex()
+	}
+
+	utils.Log(ctx).WithFields(fields).Debug("genesis pair params")
+}
+
+func LogPairTwapParams(ctx context.Context, params *GetPairTwapParams) {
+	utils.Log(ctx).WithFields(logrus.Fields{
+		"chain_id":    params.ChainId,
+		"pair":        params.PairAddress.Hex(),
+		"token_in":    params.TokenIn.Hex(),
+		"amount_in":   params.AmountIn.String(),
+		"granularity": params.Granularity.String(),
+	}).Debug("pair twap params")
+}
+
+func LogTokensParams(ctx context.Context, params *GetTokensParams) {
+	tokens := make([]string, len(params.Tokens))
+	for i, token := range params.Tokens {
+		tokens[i] = token.Hex()
+	}
+	utils.Log(ctx).WithFields(logrus.Fields{
+		"chain_id": params.ChainId,
+		"tokens":   strings.Join(tokens, ","),
+	}).Debug("tokens params")
+}
+
+func LogExportUserHistoryParams(ctx context.Context, params *ExportUserHistoryParams) {
+	utils.Log(ctx).WithFields(logrus.Fields{
+		"chain_id":   params.ChainId,
+		"genesis":    params.GenesisAddress.Hex(),
+		"user":       params.UserAddress.Hex(),
+		"format":     params.Format,
+		"from_block": params.FromBlock.String(),
+		"to_block":   params.ToBlock.String(),
+	}).Debug("export user history params")
+}
+
+func LogOperatorBatchParams(ctx context.Context, params *BuildOperatorBatchParams) {
+	changes := make([]string, len(params.Changes))
+	for i, change := range params.Changes {
+		changes[i] = change.Action + ":" + change.PoolId
+	}
+
+	utils.Log(ctx).WithFields(logrus.Fields{
+		"chain_id": params.ChainId,
+		"genesis":  params.GenesisAddress,
+		"safe":     params.SafeAddress,
+		"changes":  strings.Join(changes, ","),
+	}).Debug("operator batch params")
+}
+
+func LogContractCallParams(ctx context.Context, params *ContractCallParams) {
+	callParams := make([]string, len(params.Params))
+	for i, param := range params.Params {
+		callParams[i] = param.Type + ":" + param.Value
+	}
+
+	utils.Log(ctx).WithFields(logrus.Fields{
+		"chain_id": params.ChainId,
+		"contract": params.ContractAddress,
+		"method":   params.Method,
+		"params":   strings.Join(callParams, ","),
+		"returns":  strings.Join(params.Returns, ","),
+	}).Debug("contract call params")
+}
+
+func LogDecodeParams(ctx context.Context, params *DecodeParams) {
+	topics := make([]string, len(params.Topics))
+	for i, topic := range params.Topics {
+		topics[i] = topic.Hex()
 	}
+
+	utils.Log(ctx).WithFields(logrus.Fields{
+		"chain_id": params.ChainId,
+		"abi":      params.Abi,
+		"calldata": params.CallData,
+		"topics":   strings.Join(topics, ","),
+		"data":     params.Data,
+		"tx":       params.TxHash.Hex(),
+	}).Debug("decode params")
+}
+
+func LogInspectParams(ctx context.Context, params *InspectParams) {
+	slots := make([]string, len(params.Slots))
+	for i, slot := range params.Slots {
+		slots[i] = slot.String()
+	}
+
+	utils.Log(ctx).WithFields(logrus.Fields{
+		"chain_id": params.ChainId,
+		"address":  params.Address,
+		"slots":    strings.Join(slots, ","),
+	}).Debug("inspect params")
 }
@@ -1,8 +1,13 @@
 package infoHandler
 
 import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 type GetGenesisPairResponse struct {
@@ -17,21 +22,97 @@ type GetGenesisPairResponse struct {
 	UserReward       string `json:"user-reward"`
 	UserBaseBalance  string `json:"user-base-balance"`
 	UserQuoteBalance string `json:"user-quote-balance"`
+
+	TokenMetadata *TokenMetadata `json:"token-metadata,omitempty"`
+	BaseMetadata  *TokenMetadata `json:"base-metadata,omitempty"`
+	QuoteMetadata *TokenMetadata `json:"quote-metadata,omitempty"`
 }
 
 type GetGenesisBalanceResponse struct {
-	Token          string `json:"token"`
-	PoolId         string `json:"pool-id"`
-	GenesisBalance string `json:"genesis-balance"`
-	UserBalance    string `json:"user-balance"`
-	UserStake      string `json:"user-stake"`
-	UserReward     string `json:"user-reward"`
+	Token          string         `json:"token"`
+	PoolId         string         `json:"pool-id"`
+	GenesisBalance string         `json:"genesis-balance"`
+	UserBalance    string         `json:"user-balance"`
+	UserStake      string         `json:"user-stake"`
+	UserReward     string         `json:"user-reward"`
+	TokenMetadata  *TokenMetadata `json:"token-metadata,omitempty"`
 }
 
 type GetGenesisBalancesResponse struct {
 	Pools []GetGenesisBalanceResponse `json:"pools"`
 }
 
+type GetPairTwapResponse struct {
+	Pair          string `json:"pair"`
+	TokenIn       string `json:"token-in"`
+	TokenOut      string `json:"token-out"`
+	AmountIn      string `json:"amount-in"`
+	Granularity   string `json:"granularity"`
+	TwapAmountOut string `json:"twap-amount-out"`
+	SpotAmountOut string `json:"spot-amount-out,omitempty"`
+
+	TokenInMetadata  *TokenMetadata `json:"token-in-metadata,omitempty"`
+	TokenOutMetadata *TokenMetadata `json:"token-out-metadata,omitempty"`
+}
+
+// TokenMetadata is what a token reports about itself, with the local
+// overrides applied. Fields a token does not implement are empty, decimals
+// null.
+type TokenMetadata struct {
+	Address     string `json:"address"`
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Decimals    *uint8 `json:"decimals"`
+	TotalSupply string `json:"total-supply,omitempty"`
+	DisplayName string `json:"display-name,omitempty"`
+	Logo        string `json:"logo,omitempty"`
+}
+
+// TokenOverride is an entry of TOKEN_OVERRIDES_FILE. Name and symbol replace
+// what the token returns when set.
+type TokenOverride struct {
+	Name        string `json:"name,omitempty"`
+	Symbol      string `json:"symbol,omitempty"`
+	DisplayName string `json:"display-name,omitempty"`
+	Logo        string `json:"logo,omitempty"`
+}
+
+type cachedToken struct {
+	ChainId string `json:"chain-id"`
+	TokenMetadata
+}
+
+type GetTokensResponse struct {
+	Tokens []TokenMetadata `json:"tokens"`
+}
+
+// UserHistoryRow is one genesis event of a user. Amounts are in token units
+// next to the same amount scaled by the token decimals. FeeEstimate is the
+// deposit fee at the pool's current depFee, which may have changed since.
+type UserHistoryRow struct {
+	Timestamp          string `json:"timestamp"`
+	Block              uint64 `json:"block"`
+	TxHash             string `json:"tx-hash"`
+	LogIndex           uint   `json:"log-index"`
+	Event              string `json:"event"`
+	PoolId             string `json:"pool-id"`
+	Token              string `json:"token"`
+	TokenSymbol        string `json:"token-symbol"`
+	Amount             string `json:"amount"`
+	AmountDecimal      string `json:"amount-decimal"`
+	FeeEstimate        string `json:"fee-estimate"`
+	FeeEstimateDecimal string `json:"fee-estimate-decimal"`
+}
+
+// ExportUserHistoryResponse is written as CSV or NDJSON rows, see Encode.
+type ExportUserHistoryResponse struct {
+	Format    string           `json:"format"`
+	User      string           `json:"user"`
+	FromBlock uint64           `json:"from-block"`
+	ToBlock   uint64           `json:"to-block"`
+	Rows      []UserHistoryRow `json:"rows"`
+}
+
 type Call struct {
 	Target   common.Address
 	CallData []byte
@@ -48,3 +129,279 @@ type MulticallResult struct {
 	Success    bool
 	ReturnData []byte
 }
+
+type PoolInfo struct {
+	Token               common.Address
+	DepFee              *big.Int
+	AllocPoint          *big.Int
+	LastRewardTime      *big.Int
+	AccValhallaPerShare *big.Int
+	IsStarted           bool
+	GaugeInfo           struct {
+		IsGauge      bool
+		Gauge        common.Address
+		RewardTokens []common.Address
+	}
+	PoolValhallaPerSec *big.Int
+}
+
+type SafeContractInput struct {
+	InternalType string `json:"internalType,omitempty"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+}
+
+type SafeContractMethod struct {
+	Inputs  []SafeContractInput `json:"inputs"`
+	Name    string              `json:"name"`
+	Payable bool                `json:"payable"`
+}
+
+type SafeTransaction struct {
+	To                   string             `json:"to"`
+	Value                string             `json:"value"`
+	Data                 *string            `json:"data"`
+	ContractMethod       SafeContractMethod `json:"contractMethod"`
+	ContractInputsValues map[string]string  `json:"contractInputsValues"`
+}
+
+type SafeBatchMeta struct {
+	Name                    string `json:"name"`
+	Description             string `json:"description"`
+	TxBuilderVersion        string `json:"txBuilderVersion"`
+	CreatedFromSafeAddress  string `json:"createdFromSafeAddress"`
+	CreatedFromOwnerAddress string `json:"createdFromOwnerAddress"`
+}
+
+type SafeBatch struct {
+	Version      string            `json:"version"`
+	ChainId      string            `json:"chainId"`
+	CreatedAt    int64             `json:"createdAt"`
+	Meta         SafeBatchMeta     `json:"meta"`
+	Transactions []SafeTransaction `json:"transactions"`
+}
+
+type OperatorDiffEntry struct {
+	Action string `json:"action"`
+	PoolId string `json:"pool-id,omitempty"`
+	Field  string `json:"field"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+type BuildOperatorBatchResponse struct {
+	Batch    SafeBatch           `json:"batch"`
+	Diff     []OperatorDiffEntry `json:"diff"`
+	Summary  []string            `json:"summary"`
+	Skipped  []string            `json:"skipped"`
+	Warnings []string            `json:"warnings"`
+}
+
+type ContractCallOutput struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type ContractCallResponse struct {
+	Contract   string               `json:"contract"`
+	Signature  string               `json:"signature"`
+	Selector   string               `json:"selector"`
+	CallData   string               `json:"call-data"`
+	ReturnData string               `json:"return-data"`
+	Outputs    []ContractCallOutput `json:"outputs,omitempty"`
+}
+
+type DecodedArgument struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Indexed bool        `json:"indexed,omitempty"`
+	Value   interface{} `json:"value"`
+}
+
+type DecodedCall struct {
+	Contract  string            `json:"contract,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Signature string            `json:"signature,omitempty"`
+	Selector  string            `json:"selector"`
+	Arguments []DecodedArgument `json:"arguments,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+type DecodedLog struct {
+	Address   string            `json:"address,omitempty"`
+	LogIndex  uint              `json:"log-index"`
+	Contract  string            `json:"contract,omitempty"`
+	Event     string            `json:"event,omitempty"`
+	Signature string            `json:"signature,omitempty"`
+	Topic     string            `json:"topic"`
+	Arguments []DecodedArgument `json:"arguments,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+type DecodedTransaction struct {
+	Hash        string `json:"hash"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"`
+	BlockNumber string `json:"block-number"`
+	Status      uint64 `json:"status"`
+	GasUsed     uint64 `json:"gas-used"`
+}
+
+type DecodeResponse struct {
+	Transaction *DecodedTransaction `json:"transaction,omitempty"`
+	Call        *DecodedCall        `json:"call,omitempty"`
+	Logs        []DecodedLog        `json:"logs,omitempty"`
+}
+
+type InspectSlot struct {
+	Slot  string `json:"slot"`
+	Value string `json:"value"`
+}
+
+type InspectProxy struct {
+	Standard              string `json:"standard"`
+	Implementation        string `json:"implementation,omitempty"`
+	ImplementationHasCode bool   `json:"implementation-has-code"`
+	Admin                 string `json:"admin,omitempty"`
+	Beacon                string `json:"beacon,omitempty"`
+}
+
+type InspectResponse struct {
+	Address         string        `json:"address"`
+	Kind            string        `json:"kind"`
+	CodeSize        int           `json:"code-size"`
+	CodeHash        string        `json:"code-hash"`
+	CodeHashMatches *bool         `json:"code-hash-matches,omitempty"`
+	Proxy           *InspectProxy `json:"proxy,omitempty"`
+	Slots           []InspectSlot `json:"slots,omitempty"`
+}
+
+// MulticallPlan describes the calls a query needs and how to turn their
+// results into a response, so several queries can share one multicall.
+type MulticallPlan struct {
+	ChainId string
+	Calls   []Calls
+	Decode  func(results []MulticallResult) (interface{}, error)
+}
+
+type BatchRequestItem struct {
+	Id     json.RawMessage        `json:"id"`
+	Query  string                 `json:"query"`
+	Params map[string]interface{} `json:"params"`
+}
+
+type BatchResponseItem struct {
+	Id     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result,omitempty"`
+	Error  interface{}     `json:"error,omitempty"`
+}
+
+type LivenessResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+// ReadinessResponse is ready when every chain has at least one healthy RPC.
+type ReadinessResponse struct {
+	Status    string           `json:"status"`
+	CheckedAt string           `json:"checked-at"`
+	Chains    []ChainReadiness `json:"chains"`
+}
+
+type ChainReadiness struct {
+	ChainId   string         `json:"chain-id"`
+	Name      string         `json:"name"`
+	Healthy   int            `json:"healthy"`
+	BestBlock uint64         `json:"best-block"`
+	Multicall string         `json:"multicall"`
+	Providers []RpcReadiness `json:"providers"`
+}
+
+type RpcReadiness struct {
+	Provider      string `json:"provider"`
+	Healthy       bool   `json:"healthy"`
+	Reachable     bool   `json:"reachable"`
+	ChainId       string `json:"chain-id,omitempty"`
+	ChainIdOk     bool   `json:"chain-id-ok"`
+	LatestBlock   uint64 `json:"latest-block,omitempty"`
+	Lag           uint64 `json:"lag"`
+	MulticallCode bool   `json:"multicall-code"`
+	LatencyMs     int64  `json:"latency-ms"`
+	Error         string `json:"error,omitempty"`
+}
+
+// StreamEvent is the data of an event of /api/stream. Block is 0 for the
+// snapshot sent on connect.
+type StreamEvent struct {
+	Block  uint64       `json:"block,omitempty"`
+	Query  string       `json:"query"`
+	Result interface{}  `json:"result,omitempty"`
+	Error  *utils.Error `json:"error,omitempty"`
+}
+
+// GraphQLRequest is the JSON body of POST /graphql, or the query string of GET
+// with variables as JSON.
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQLResponse follows the GraphQL spec: data is absent when the request
+// could not be executed, and errors carry the path of the failed field with
+// the API error type as extensions.code.
+type GraphQLResponse struct {
+	Data   interface{}   `json:"data,omitempty"`
+	Errors gqlerror.List `json:"errors,omitempty"`
+}
+
+// AlertCondition is one rule of an alert subscription, see alertConditionTypes
+// for the fields each type uses. Amounts are in wei, durations like "1h".
+type AlertCondition struct {
+	Type        string  `json:"type"`
+	ChainId     string  `json:"chain-id"`
+	Genesis     string  `json:"genesis"`
+	PoolId      string  `json:"pool-id,omitempty"`
+	User        string  `json:"user,omitempty"`
+	Threshold   string  `json:"threshold,omitempty"`
+	DropPercent float64 `json:"drop-percent,omitempty"`
+	Window      string  `json:"window,omitempty"`
+	Before      string  `json:"before,omitempty"`
+	Token       string  `json:"token,omitempty"`
+}
+
+// AlertSubscription is a webhook and the conditions it is notified of. The
+// secret is only returned when the subscription is created.
+type AlertSubscription struct {
+	Id          string           `json:"id"`
+	Owner       string           `json:"owner"`
+	Url         string           `json:"url"`
+	Secret      string           `json:"secret,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Conditions  []AlertCondition `json:"conditions"`
+	CreatedAt   string           `json:"created-at"`
+}
+
+type CreateAlertRequest struct {
+	Url         string           `json:"url"`
+	Secret      string           `json:"secret,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Conditions  []AlertCondition `json:"conditions"`
+}
+
+// AlertEvent is the body of a webhook delivery. Condition is the index of the
+// condition that fired in the subscription's conditions.
+type AlertEvent struct {
+	Event        string            `json:"event"`
+	Subscription string            `json:"subscription"`
+	Condition    int               `json:"condition"`
+	Type         string            `json:"type,omitempty"`
+	ChainId      string            `json:"chain-id,omitempty"`
+	Genesis      string            `json:"genesis,omitempty"`
+	PoolId       string            `json:"pool-id,omitempty"`
+	User         string            `json:"user,omitempty"`
+	Message      string            `json:"message"`
+	Values       map[string]string `json:"values,omitempty"`
+	TriggeredAt  string            `json:"triggered-at"`
+}
@@ -0,0 +1,546 @@
+package infoHandler
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/FudgyDRS/valhalla-api/pkg/webhook"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// defaultSwaggerUI serves the docs page assets unless SWAGGER_UI_URL points
+// at a self-hosted copy of swagger-ui-dist. The assets are not embedded, so by
+// default every visitor of /api/docs loads the script and stylesheet from
+// unpkg, a third-party CDN; set SWAGGER_UI_URL where that is not acceptable.
+const defaultSwaggerUI = "https://unpkg.com/swagger-ui-dist@5"
+
+//go:embed docs.html
+var docsHTML string
+
+var docsPage = template.Must(template.New("docs").Parse(docsHTML))
+
+var (
+	openAPIOnce sync.Once
+	openAPISpec []byte
+)
+
+var (
+	addressType = reflect.TypeOf(common.Address{})
+	hashType    = reflect.TypeOf(common.Hash{})
+	bigIntType  = reflect.TypeOf(&big.Int{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+)
+
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	openAPIOnce.Do(func() {
+		spec, err := json.MarshalIndent(BuildOpenAPISpec(), "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		openAPISpec = spec
+	})
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// DocsHandler serves a Swagger UI page for /api/openapi.json. Only the page
+// is served from here, its assets come from SWAGGER_UI_URL or unpkg.
+func DocsHandler(w http.ResponseWriter, r *http.Request) {
+	assets := strings.TrimSuffix(os.Getenv("SWAGGER_UI_URL"), "/")
+	if assets == "" {
+		assets = defaultSwaggerUI
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := docsPage.Execute(w, struct{ Assets string }{assets}); err != nil {
+		utils.Log(r.Context()).WithError(err).Error("failed to render docs page")
+	}
+}
+
+// BuildOpenAPISpec describes every registered query from the `query`,
+// `optional` and `json` tags of its param and response structs. Each query is
+// documented under its /api/v1 path; /api/info only lists the query names, as
+// a query string is not part of an OpenAPI path.
+func BuildOpenAPISpec() map[string]interface{} {
+	gen := &schemaGenerator{components: make(map[string]interface{})}
+	errorResponse := map[string]interface{}{
+		"description": "Error, see ?query=errors for every type",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": gen.schema(reflect.TypeOf(utils.Error{}))},
+		},
+	}
+
+	paths := make(map[string]interface{})
+
+	names := make([]string, 0, len(queryRoutes))
+	for name := range queryRoutes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, restRoute := range restRoutes {
+		method, path := restRoute.restPath()
+		route := queryRoutes[restRoute.Query]
+
+		// Path wildcards replace their query parameter, resolved ones become optional
+		var parameters []interface{}
+		fromPath := make(map[string]bool)
+		for _, segment := range strings.Split(path, "/") {
+			if strings.HasPrefix(segment, "{") {
+				pathName := strings.Trim(segment, "{}")
+				fromPath[restRoute.PathParams[pathName]] = true
+				parameters = append(parameters, map[string]interface{}{
+					"name":     pathName,
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string"},
+				})
+			}
+		}
+		for _, parameter := range queryParameters(gen, route.Params) {
+			parameter := parameter.(map[string]interface{})
+			name := parameter["name"].(string)
+			if fromPath[name] {
+				continue
+			}
+			for _, resolved := range restRoute.Resolved {
+				if resolved == name {
+					parameter["required"] = false
+				}
+			}
+			parameters = append(parameters, parameter)
+		}
+
+		operationId := strings.NewReplacer(restPrefix, "v1-", "/", "-", "{", "", "}", "").Replace(path)
+		operation := gen.operation(operationId, "v1", route, parameters, errorResponse)
+
+		if existing, ok := paths[path].(map[string]interface{}); ok {
+			existing[method] = operation
+			continue
+		}
+		paths[path] = map[string]interface{}{method: operation}
+	}
+
+	paths["/api/info"] = map[string]interface{}{
+		"get": map[string]interface{}{
+			"operationId": "info",
+			"summary":     "Run a query by name",
+			"description": "Takes the parameters of the query's /api/v1 operation in the query string, path parameters included.",
+			"tags":        []string{"info"},
+			"parameters": []interface{}{map[string]interface{}{
+				"name":     "query",
+				"in":       "query",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string", "enum": names},
+			}},
+			"responses": map[string]interface{}{
+				"200": gen.jsonResponse("Result of the query, see its /api/v1 operation", nil),
+				"400": errorResponse,
+				"401": errorResponse,
+				"404": errorResponse,
+				"429": errorResponse,
+				"500": errorResponse,
+				"502": errorResponse,
+				"504": errorResponse,
+			},
+		},
+		"post": map[string]interface{}{
+			"operationId": "batch",
+			"summary":     "Run several queries in one call, merging multicalls per chain",
+			"tags":        []string{"info"},
+			"requestBody": map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": gen.schema(reflect.TypeOf([]BatchRequestItem{}))},
+				},
+			},
+			"responses": map[string]interface{}{
+				"200": gen.jsonResponse("Per-item results or errors", []BatchResponseItem{}),
+				"400": errorResponse,
+				"401": errorResponse,
+				"429": errorResponse,
+			},
+		},
+	}
+
+	paths[streamPath] = map[string]interface{}{
+		"get": map[string]interface{}{
+			"operationId": "stream",
+			"summary":     "Server-Sent Events with the result of get-genesis-balances or get-genesis-pair, pushed on every block that changes it",
+			"description": "Takes the same parameters as the streamed query. The current result is sent on connect, then an `update` event with the block as id whenever it changes, or an `error` event when a block could not be read.",
+			"tags":        []string{"info"},
+			"parameters": []interface{}{map[string]interface{}{
+				"name":     "query",
+				"in":       "query",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string", "enum": []string{"get-genesis-balances", "get-genesis-pair"}},
+			}},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Event stream, the data of each event is a StreamEvent",
+					"content": map[string]interface{}{
+						"text/event-stream": map[string]interface{}{"schema": gen.schema(reflect.TypeOf(StreamEvent{}))},
+					},
+				},
+				"400": errorResponse,
+				"401": errorResponse,
+				"429": errorResponse,
+				"502": errorResponse,
+			},
+		},
+	}
+
+	graphqlResponses := map[string]interface{}{
+		"200": gen.jsonResponse("Data, with errors for the fields that failed", GraphQLResponse{}),
+		"400": gen.jsonResponse("Query that does not parse or validate", GraphQLResponse{}),
+		"401": errorResponse,
+		"429": errorResponse,
+	}
+	paths[graphqlPath] = map[string]interface{}{
+		"get": map[string]interface{}{
+			"operationId": "graphql-get",
+			"summary":     "GraphQL query over genesis pools, pairs, tokens and user positions",
+			"description": "The schema is served at " + graphqlSchemaPath + ". Only the contract calls the selected fields need are made, batched into one multicall per chain for every round of dependent reads.",
+			"tags":        []string{"graphql"},
+			"parameters": []interface{}{
+				map[string]interface{}{"name": "query", "in": "query", "required": true, "schema": map[string]interface{}{"type": "string"}},
+				map[string]interface{}{"name": "variables", "in": "query", "description": "JSON object", "schema": map[string]interface{}{"type": "string"}},
+				map[string]interface{}{"name": "operationName", "in": "query", "schema": map[string]interface{}{"type": "string"}},
+			},
+			"responses": graphqlResponses,
+		},
+		"post": map[string]interface{}{
+			"operationId": "graphql",
+			"summary":     "GraphQL query over genesis pools, pairs, tokens and user positions",
+			"tags":        []string{"graphql"},
+			"requestBody": map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": gen.schema(reflect.TypeOf(GraphQLRequest{}))},
+				},
+			},
+			"responses": graphqlResponses,
+		},
+	}
+	paths[graphqlSchemaPath] = map[string]interface{}{
+		"get": map[string]interface{}{
+			"operationId": "graphql-schema",
+			"summary":     "GraphQL schema in SDL",
+			"tags":        []string{"graphql"},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "Schema",
+					"content":     map[string]interface{}{"text/plain": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}},
+				},
+			},
+		},
+	}
+
+	// Alert subscriptions need an API key and are only served by the
+	// standalone server
+	alertSecurity := []interface{}{
+		map[string]interface{}{"apiKey": []string{}},
+		map[string]interface{}{"bearer": []string{}},
+	}
+	alertId := []interface{}{map[string]interface{}{
+		"name": "id", "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+	}}
+	alertOperation := func(operationId string, summary string, parameters []interface{}, status string, response interface{}) map[string]interface{} {
+		responses := map[string]interface{}{
+			"400": errorResponse,
+			"401": errorResponse,
+			"404": errorResponse,
+			"429": errorResponse,
+		}
+		if response != nil {
+			responses[status] = gen.jsonResponse("Success", response)
+		} else {
+			responses[status] = map[string]interface{}{"description": "Success"}
+		}
+		return map[string]interface{}{
+			"operationId": operationId,
+			"summary":     summary,
+			"tags":        []string{"alerts"},
+			"parameters":  parameters,
+			"security":    alertSecurity,
+			"responses":   responses,
+		}
+	}
+	createAlertOperation := alertOperation("create-alert", "Register a webhook notified when any of its conditions fires. The secret signing deliveries is only returned here", nil, "201", AlertSubscription{})
+	createAlertOperation["description"] = "Deliveries are POSTed as JSON AlertEvent bodies with the headers X-Valhalla-Event, X-Valhalla-Delivery, X-Valhalla-Timestamp and X-Valhalla-Signature, `sha256=` followed by the hex HMAC-SHA256 of `<timestamp>.<body>` keyed by the secret. Failed deliveries are retried with exponential backoff. Condition types: `pending-above` (pool-id, user, threshold in wei), `stake-drop` (pool-id, drop-percent, window), `pool-changed` (pool-id, allocPoint or depFee) and `end-approaching` (before)."
+	createAlertOperation["requestBody"] = map[string]interface{}{
+		"required": true,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": gen.schema(reflect.TypeOf(CreateAlertRequest{}))},
+		},
+	}
+	paths["/api/v1/alerts"] = map[string]interface{}{
+		"post": createAlertOperation,
+		"get":  alertOperation("list-alerts", "Alert subscriptions of the API key", nil, "200", []AlertSubscription{}),
+	}
+	paths["/api/v1/alerts/{id}"] = map[string]interface{}{
+		"get":    alertOperation("get-alert", "One alert subscription", alertId, "200", AlertSubscription{}),
+		"delete": alertOperation("delete-alert", "Delete an alert subscription and its delivery log", alertId, "204", nil),
+	}
+	paths["/api/v1/alerts/{id}/deliveries"] = map[string]interface{}{
+		"get": alertOperation("list-alert-deliveries", "Recent deliveries of a subscription with their attempts and outcome, newest first", alertId, "200", []webhook.Delivery{}),
+	}
+	paths["/api/v1/alerts/{id}/test"] = map[string]interface{}{
+		"post": alertOperation("test-alert", "Send a ping delivery to check the endpoint and its signature verification", alertId, "200", webhook.Delivery{}),
+	}
+
+	paths["/healthz"] = map[string]interface{}{
+		"get": map[string]interface{}{
+			"operationId": "healthz",
+			"summary":     "Liveness probe, touches no RPC",
+			"tags":        []string{"probes"},
+			"responses":   map[string]interface{}{"200": gen.jsonResponse("Alive", LivenessResponse{})},
+		},
+	}
+	paths["/readyz"] = map[string]interface{}{
+		"get": map[string]interface{}{
+			"operationId": "readyz",
+			"summary":     "Readiness probe with the reachability, block lag, chain id and multicall code of every RPC",
+			"tags":        []string{"probes"},
+			"responses": map[string]interface{}{
+				"200": gen.jsonResponse("Every chain has a healthy RPC", ReadinessResponse{}),
+				"503": gen.jsonResponse("A chain has no healthy RPC", ReadinessResponse{}),
+			},
+		},
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Valhalla API",
+			"version": strings.TrimPrefix(Version, "Valhalla API "),
+		},
+		"servers": []interface{}{map[string]interface{}{"url": "/"}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": gen.components,
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+		// Keys are optional unless the deployment requires them, anonymous
+		// requests get the lowest rate limit tier
+		"security": []interface{}{
+			map[string]interface{}{},
+			map[string]interface{}{"apiKey": []string{}},
+			map[string]interface{}{"bearer": []string{}},
+		},
+	}
+}
+
+func (gen *schemaGenerator) operation(operationId string, tag string, route QueryRoute, parameters []interface{}, errorResponse interface{}) map[string]interface{} {
+	operation := map[string]interface{}{
+		"operationId": operationId,
+		"summary":     route.Summary,
+		"tags":        []string{tag},
+		"parameters":  append([]interface{}{}, parameters...),
+		"responses": map[string]interface{}{
+			"200": gen.jsonResponse("Success", route.Response),
+			"400": errorResponse,
+			"401": errorResponse,
+			"404": errorResponse,
+			"429": errorResponse,
+			"500": errorResponse,
+			"502": errorResponse,
+			"504": errorResponse,
+		},
+	}
+
+	if _, ok := route.Response.(ExportUserHistoryResponse); ok {
+		operation["responses"].(map[string]interface{})["200"] = map[string]interface{}{
+			"description": "Rows as CSV with a header line, or as NDJSON with format=ndjson",
+			"content": map[string]interface{}{
+				"text/csv":             map[string]interface{}{"schema": map[string]interface{}{"type": "string"}},
+				"application/x-ndjson": map[string]interface{}{"schema": gen.schema(reflect.TypeOf(UserHistoryRow{}))},
+			},
+		}
+	}
+
+	if route.Body != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": gen.schema(reflect.TypeOf(route.Body))},
+			},
+		}
+	}
+
+	return operation
+}
+
+// queryParameters mirrors how the parsers read the query string: scalar
+// fields are plain params, slices repeat the param and slices of structs are
+// flattened to "field.subfield", e.g. pools.address.
+func queryParameters(gen *schemaGenerator, params interface{}) []interface{} {
+	if params == nil {
+		return nil
+	}
+
+	var parameters []interface{}
+	typ := reflect.TypeOf(params)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		queryTag := field.Tag.Get("query")
+		if queryTag == "" {
+			continue
+		}
+		required := field.Tag.Get("optional") != "true"
+
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct && !isScalarType(field.Type.Elem()) {
+			elem := field.Type.Elem()
+			for j := 0; j < elem.NumField(); j++ {
+				subTag := elem.Field(j).Tag.Get("query")
+				if subTag == "" {
+					continue
+				}
+				parameters = append(parameters, map[string]interface{}{
+					"name":     queryTag + "." + subTag,
+					"in":       "query",
+					"required": required,
+					"explode":  true,
+					"schema":   map[string]interface{}{"type": "array", "items": gen.schema(elem.Field(j).Type)},
+				})
+			}
+			continue
+		}
+
+		parameter := map[string]interface{}{
+			"name":     queryTag,
+			"in":       "query",
+			"required": required,
+			"schema":   gen.schema(field.Type),
+		}
+		if field.Type.Kind() == reflect.Slice && field.Type != rawJSONType {
+			parameter["explode"] = true
+		}
+		parameters = append(parameters, parameter)
+	}
+
+	return parameters
+}
+
+type schemaGenerator struct {
+	components map[string]interface{}
+}
+
+func (gen *schemaGenerator) jsonResponse(description string, response interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+	if response != nil {
+		schema = gen.schema(reflect.TypeOf(response))
+	}
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+func isScalarType(t reflect.Type) bool {
+	return t == addressType || t == hashType || t == bigIntType
+}
+
+func (gen *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case addressType:
+		return map[string]interface{}{"type": "string", "pattern": "^0x[0-9a-fA-F]{40}$"}
+	case hashType:
+		return map[string]interface{}{"type": "string", "pattern": "^0x[0-9a-fA-F]{64}$"}
+	case bigIntType:
+		return map[string]interface{}{"type": "string", "pattern": "^[0-9]+$"}
+	case rawJSONType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return gen.schema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": gen.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": gen.schema(t.Elem())}
+	case reflect.Struct:
+		return gen.structSchema(t)
+	}
+
+	// interface{} and anything else
+	return map[string]interface{}{}
+}
+
+func (gen *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	name := t.Name()
+	if name != "" {
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + name}
+		if _, exists := gen.components[name]; exists {
+			return ref
+		}
+		// Reserve the name first so recursive types terminate
+		gen.components[name] = map[string]interface{}{}
+		gen.components[name] = gen.objectSchema(t)
+		return ref
+	}
+	return gen.objectSchema(t)
+}
+
+func (gen *schemaGenerator) objectSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitempty := field.Name, false
+		if tag := field.Tag.Get("json"); tag != "" {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			for _, option := range parts[1:] {
+				omitempty = omitempty || option == "omitempty"
+			}
+		}
+
+		properties[name] = gen.schema(field.Type)
+		if !omitempty && field.Tag.Get("optional") != "true" {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,60 @@
+package infoHandler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIPathsAreTemplates(t *testing.T) {
+	spec := BuildOpenAPISpec()
+	paths := spec["paths"].(map[string]interface{})
+
+	documented := make(map[string]bool)
+	operationIds := make(map[string]string)
+	for path, item := range paths {
+		if strings.ContainsAny(path, "?&=#") {
+			t.Errorf("path %q is not a path template", path)
+		}
+		for method, operation := range item.(map[string]interface{}) {
+			id := operation.(map[string]interface{})["operationId"].(string)
+			if other, ok := operationIds[id]; ok {
+				t.Errorf("operationId %q used by %s and %s %s", id, other, method, path)
+			}
+			operationIds[id] = method + " " + path
+		}
+	}
+
+	for _, route := range restRoutes {
+		_, path := route.restPath()
+		if _, ok := paths[path]; ok {
+			documented[route.Query] = true
+		}
+	}
+	for name := range queryRoutes {
+		if !documented[name] {
+			t.Errorf("query %s has no /api/v1 operation", name)
+		}
+	}
+}
+
+func TestDocsPageAssets(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want string
+	}{
+		{"default", "", defaultSwaggerUI + "/swagger-ui-bundle.js"},
+		{"self hosted", "/static/swagger/", `src="/static/swagger/swagger-ui-bundle.js"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("SWAGGER_UI_URL", test.env)
+			w := httptest.NewRecorder()
+			DocsHandler(w, httptest.NewRequest("GET", "/api/docs", nil))
+			if body := w.Body.String(); !strings.Contains(body, test.want) {
+				t.Errorf("docs page does not contain %q:\n%s", test.want, body)
+			}
+		})
+	}
+}
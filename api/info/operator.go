@@ -0,0 +1,447 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const safeTxBuilderVersion string = "1.16.5"
+
+//http://localhost:8080/api/info?query=build-operator-batch&chain-id=146&genesis=0x23Ee13d49e78811d063722D9228547a7dF73E42E
+// body: {"safe":"0x...","changes":[{"action":"set","pid":"3","allocPoint":"200"}]}
+
+func BuildOperatorBatch(r *http.Request) (BuildOperatorBatchResponse, error) {
+	params, err := parseOperatorBatchParams(r)
+	if err != nil {
+		return BuildOperatorBatchResponse{}, err
+	}
+
+	LogOperatorBatchParams(r.Context(), params)
+
+	client, err := GetClientForChain(r.Context(), params.ChainId)
+	if err != nil {
+		return BuildOperatorBatchResponse{}, err
+	}
+	multicallAddress, err := getMulticallAddress(params.ChainId)
+	if err != nil {
+		return BuildOperatorBatchResponse{}, err
+	}
+
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+	state, err := fetchGenesisOperatorState(r.Context(), client, multicallAddress, parsedGenesisABI, common.HexToAddress(params.GenesisAddress))
+	if err != nil {
+		return BuildOperatorBatchResponse{}, fmt.Errorf("failed to read genesis state: %w", err)
+	}
+
+	response, err := diffOperatorChanges(params, state, parsedGenesisABI)
+	if err != nil {
+		return BuildOperatorBatchResponse{}, utils.ErrMalformedRequest(err.Error())
+	}
+	utils.Log(r.Context()).WithField("summary", response.Summary).Debug("generated operator batch")
+
+	return response, nil
+}
+
+type genesisOperatorState struct {
+	Operator common.Address
+	DevFund  common.Address
+	Pools    []PoolInfo
+}
+
+func fetchGenesisOperatorState(ctx context.Context, client *ethclient.Client, multicallAddress common.Address, parsedGenesisABI abi.ABI, genesisAddress common.Address) (*genesisOperatorState, error) {
+	lengthData, err := ViewFunction(ctx, client, genesisAddress, parsedGenesisABI, "poolLength")
+	if err != nil {
+		return nil, fmt.Errorf("poolLength call failed: %w", err)
+	}
+	lengthOut, err := parsedGenesisABI.Unpack("poolLength", lengthData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack poolLength: %v", err)
+	}
+	poolLength := lengthOut[0].(*big.Int).Int64()
+
+	calls := []Calls{
+		{contractAddress: genesisAddress, abi: parsedGenesisABI, method: "operator", params: nil},
+		{contractAddress: genesisAddress, abi: parsedGenesisABI, method: "devFund", params: nil},
+	}
+	for pid := int64(0); pid < poolLength; pid++ {
+		calls = append(calls, Calls{
+			contractAddress: genesisAddress,
+			abi:             parsedGenesisABI,
+			method:          "poolInfo",
+			params:          big.NewInt(pid),
+		})
+	}
+
+	results, err := MulticallView(ctx, client, multicallAddress, calls)
+	if err != nil {
+		return nil, fmt.Errorf("multicall view failed: %w", err)
+	}
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("expected %d multicall results, got %d", len(calls), len(results))
+	}
+
+	state := &genesisOperatorState{}
+	operator, err := parsedGenesisABI.Unpack("operator", results[0].ReturnData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack operator: %v", err)
+	}
+	state.Operator = operator[0].(common.Address)
+
+	devFund, err := parsedGenesisABI.Unpack("devFund", results[1].ReturnData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack devFund: %v", err)
+	}
+	state.DevFund = devFund[0].(common.Address)
+
+	for i, result := range results[2:] {
+		var info PoolInfo
+		if err := parsedGenesisABI.UnpackIntoInterface(&info, "poolInfo", result.ReturnData); err != nil {
+			return nil, fmt.Errorf("failed to unpack poolInfo for pid %d: %v", i, err)
+		}
+		state.Pools = append(state.Pools, info)
+	}
+
+	return state, nil
+}
+
+func diffOperatorChanges(params *BuildOperatorBatchParams, state *genesisOperatorState, parsedGenesisABI abi.ABI) (BuildOperatorBatchResponse, error) {
+	genesisAddress := common.HexToAddress(params.GenesisAddress)
+	response := BuildOperatorBatchResponse{
+		Diff:     []OperatorDiffEntry{},
+		Summary:  []string{},
+		Skipped:  []string{},
+		Warnings: []string{},
+	}
+
+	if params.SafeAddress != "" && common.HexToAddress(params.SafeAddress) != state.Operator {
+		response.Warnings = append(response.Warnings, fmt.Sprintf("safe %s is not the genesis operator (%s), the batch will revert", params.SafeAddress, state.Operator.Hex()))
+	}
+
+	addDiff := func(action, pid, field, from, to string) {
+		response.Diff = append(response.Diff, OperatorDiffEntry{Action: action, PoolId: pid, Field: field, From: from, To: to})
+		if pid != "" {
+			response.Summary = append(response.Summary, fmt.Sprintf("%s: pid %s %s %s -> %s", action, pid, field, from, to))
+		} else {
+			response.Summary = append(response.Summary, fmt.Sprintf("%s: %s %s -> %s", action, field, from, to))
+		}
+	}
+
+	var transactions []SafeTransaction
+	addTransaction := func(method string, values ...string) error {
+		tx, err := newSafeTransaction(genesisAddress, parsedGenesisABI.Methods[method], values...)
+		if err != nil {
+			return err
+		}
+		transactions = append(transactions, tx)
+		return nil
+	}
+	var bulkPids, bulkAllocPoints, bulkDepFees []string
+	var addAllocPoints, addDepFees, addTokens []string
+	var addWithUpdate bool
+	var addLastRewardTime string
+	pendingAdds := 0
+	seenPools := make(map[common.Address]struct{})
+	for _, pool := range state.Pools {
+		seenPools[pool.Token] = struct{}{}
+	}
+
+	for i, change := range params.Changes {
+		switch change.Action {
+		case "set":
+			pid, pool, err := lookupPool(state, change.PoolId)
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+			allocPoint, err := parseOptionalUint(change.AllocPoint, pool.AllocPoint, "allocPoint")
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+			depFee, err := parseOptionalUint(change.DepFee, pool.DepFee, "depFee")
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+
+			if allocPoint.Cmp(pool.AllocPoint) == 0 && depFee.Cmp(pool.DepFee) == 0 {
+				response.Skipped = append(response.Skipped, fmt.Sprintf("set: pid %s already matches on-chain state", pid))
+				continue
+			}
+			if allocPoint.Cmp(pool.AllocPoint) != 0 {
+				addDiff("set", pid, "allocPoint", pool.AllocPoint.String(), allocPoint.String())
+			}
+			if depFee.Cmp(pool.DepFee) != 0 {
+				addDiff("set", pid, "depFee", pool.DepFee.String(), depFee.String())
+			}
+
+			if params.Bulk {
+				bulkPids = append(bulkPids, pid)
+				bulkAllocPoints = append(bulkAllocPoints, allocPoint.String())
+				bulkDepFees = append(bulkDepFees, depFee.String())
+				continue
+			}
+			if err := addTransaction("set", pid, allocPoint.String(), depFee.String()); err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+
+		case "add":
+			if change.Token == "" {
+				return response, fmt.Errorf("change %d: add requires a token", i)
+			}
+			token := common.HexToAddress(change.Token)
+			if _, exists := seenPools[token]; exists {
+				return response, fmt.Errorf("change %d: token %s already has a pool", i, token.Hex())
+			}
+			seenPools[token] = struct{}{}
+
+			allocPoint, err := parseOptionalUint(change.AllocPoint, nil, "allocPoint")
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+			depFee, err := parseOptionalUint(change.DepFee, common.Big0, "depFee")
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+			lastRewardTime, err := parseOptionalUint(change.LastRewardTime, common.Big0, "lastRewardTime")
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+
+			pid := fmt.Sprint(len(state.Pools) + pendingAdds)
+			pendingAdds++
+			addDiff("add", pid, "token", "none", token.Hex())
+			addDiff("add", pid, "allocPoint", "none", allocPoint.String())
+			addDiff("add", pid, "depFee", "none", depFee.String())
+
+			if params.Bulk {
+				if len(addTokens) > 0 && (addWithUpdate != change.WithUpdate || addLastRewardTime != lastRewardTime.String()) {
+					return response, fmt.Errorf("change %d: bulk adds must share withUpdate and lastRewardTime", i)
+				}
+				addTokens = append(addTokens, token.Hex())
+				addAllocPoints = append(addAllocPoints, allocPoint.String())
+				addDepFees = append(addDepFees, depFee.String())
+				addWithUpdate = change.WithUpdate
+				addLastRewardTime = lastRewardTime.String()
+				continue
+			}
+			if err := addTransaction("add", allocPoint.String(), depFee.String(), token.Hex(), fmt.Sprint(change.WithUpdate), lastRewardTime.String()); err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+
+		case "setGaugeRewardTokens":
+			pid, pool, err := lookupPool(state, change.PoolId)
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+			rewardTokens := make([]common.Address, len(change.RewardTokens))
+			for j, token := range change.RewardTokens {
+				rewardTokens[j] = common.HexToAddress(token)
+			}
+
+			from, to := formatAddressList(pool.GaugeInfo.RewardTokens), formatAddressList(rewardTokens)
+			if from == to {
+				response.Skipped = append(response.Skipped, fmt.Sprintf("setGaugeRewardTokens: pid %s already matches on-chain state", pid))
+				continue
+			}
+			addDiff("setGaugeRewardTokens", pid, "rewardTokens", from, to)
+			if err := addTransaction("setGaugeRewardTokens", pid, to); err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+
+		case "enableGauge":
+			pid, pool, err := lookupPool(state, change.PoolId)
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+			if pool.GaugeInfo.IsGauge {
+				response.Skipped = append(response.Skipped, fmt.Sprintf("enableGauge: pid %s gauge already enabled", pid))
+				continue
+			}
+			addDiff("enableGauge", pid, "isGauge", "false", "true")
+			if err := addTransaction("enableGauge", pid); err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+
+		case "setDevFund", "setOperator":
+			if change.Address == "" {
+				return response, fmt.Errorf("change %d: %s requires an address", i, change.Action)
+			}
+			current, field := state.DevFund, "devFund"
+			if change.Action == "setOperator" {
+				current, field = state.Operator, "operator"
+			}
+			target := common.HexToAddress(change.Address)
+			if target == current {
+				response.Skipped = append(response.Skipped, fmt.Sprintf("%s: %s already set to %s", change.Action, field, target.Hex()))
+				continue
+			}
+			if target == (common.Address{}) {
+				return response, fmt.Errorf("change %d: %s cannot be the zero address", i, field)
+			}
+			addDiff(change.Action, "", field, current.Hex(), target.Hex())
+			if err := addTransaction(change.Action, target.Hex()); err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+
+		case "governanceRecoverUnsupported":
+			if change.Token == "" {
+				return response, fmt.Errorf("change %d: governanceRecoverUnsupported requires a token", i)
+			}
+			amount, err := parseOptionalUint(change.Amount, nil, "amount")
+			if err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+			token := common.HexToAddress(change.Token)
+			if _, isPool := seenPools[token]; isPool {
+				response.Warnings = append(response.Warnings, fmt.Sprintf("token %s is a pool token, the contract will reject the recovery", token.Hex()))
+			}
+			addDiff("governanceRecoverUnsupported", "", "recover "+token.Hex(), "0", amount.String())
+			if err := addTransaction("governanceRecoverUnsupported", token.Hex(), amount.String()); err != nil {
+				return response, fmt.Errorf("change %d: %v", i, err)
+			}
+
+		default:
+			return response, fmt.Errorf("change %d: unsupported action %q", i, change.Action)
+		}
+	}
+
+	if len(addTokens) > 0 {
+		if err := addTransaction("addBulk", formatList(addAllocPoints), formatList(addDepFees), formatList(addTokens), fmt.Sprint(addWithUpdate), addLastRewardTime); err != nil {
+			return response, err
+		}
+	}
+	if len(bulkPids) > 0 {
+		if err := addTransaction("bulkSet", formatList(bulkPids), formatList(bulkAllocPoints), formatList(bulkDepFees)); err != nil {
+			return response, err
+		}
+	}
+
+	if transactions == nil {
+		transactions = []SafeTransaction{}
+	}
+	response.Batch = SafeBatch{
+		Version:   "1.0",
+		ChainId:   params.ChainId,
+		CreatedAt: time.Now().UnixMilli(),
+		Meta: SafeBatchMeta{
+			Name:                   "Genesis operator batch",
+			Description:            strings.Join(response.Summary, "\n"),
+			TxBuilderVersion:       safeTxBuilderVersion,
+			CreatedFromSafeAddress: params.SafeAddress,
+		},
+		Transactions: transactions,
+	}
+
+	return response, nil
+}
+
+func lookupPool(state *genesisOperatorState, poolId string) (string, PoolInfo, error) {
+	pid, ok := new(big.Int).SetString(poolId, 10)
+	if !ok || pid.Sign() < 0 {
+		return "", PoolInfo{}, fmt.Errorf("invalid pid: %q", poolId)
+	}
+	if !pid.IsInt64() || pid.Int64() >= int64(len(state.Pools)) {
+		return "", PoolInfo{}, fmt.Errorf("pid %s does not exist (pool length %d)", pid, len(state.Pools))
+	}
+	return pid.String(), state.Pools[pid.Int64()], nil
+}
+
+func parseOptionalUint(value string, fallback *big.Int, field string) (*big.Int, error) {
+	if value == "" {
+		if fallback == nil {
+			return nil, fmt.Errorf("%s is required", field)
+		}
+		return fallback, nil
+	}
+	parsed, ok := new(big.Int).SetString(value, 10)
+	if !ok || parsed.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s: %s", field, value)
+	}
+	return parsed, nil
+}
+
+// Safe Transaction Builder encodes array inputs as "[a,b,c]" without quotes.
+func formatList(values []string) string {
+	return "[" + strings.Join(values, ",") + "]"
+}
+
+func formatAddressList(addresses []common.Address) string {
+	values := make([]string, len(addresses))
+	for i, address := range addresses {
+		values[i] = address.Hex()
+	}
+	return formatList(values)
+}
+
+// newSafeTransaction describes a call of method with one value per input.
+// Safe Transaction Builder sends an input without a value as empty, so every
+// input needs one.
+func newSafeTransaction(to common.Address, method abi.Method, values ...string) (SafeTransaction, error) {
+	if len(values) != len(method.Inputs) {
+		return SafeTransaction{}, fmt.Errorf("%s takes %d values, got %d", method.RawName, len(method.Inputs), len(values))
+	}
+
+	internalTypes := genesisInternalTypes(method)
+	tx := SafeTransaction{
+		To:    to.Hex(),
+		Value: "0",
+		ContractMethod: SafeContractMethod{
+			Name:    method.RawName,
+			Payable: method.Payable,
+			Inputs:  make([]SafeContractInput, len(method.Inputs)),
+		},
+		ContractInputsValues: make(map[string]string, len(method.Inputs)),
+	}
+
+	for i, input := range method.Inputs {
+		tx.ContractMethod.Inputs[i] = SafeContractInput{
+			Name: input.Name,
+			Type: input.Type.String(),
+		}
+		if internalTypes != nil {
+			tx.ContractMethod.Inputs[i].InternalType = internalTypes[i]
+		}
+		tx.ContractInputsValues[input.Name] = values[i]
+	}
+
+	return tx, nil
+}
+
+// genesisInternalTypes reads the internalType of the inputs of method from
+// the bundled genesis ABI, as abi.Method drops them. It is nil when the
+// bundled ABI has no method of that name and inputs.
+func genesisInternalTypes(method abi.Method) []string {
+	var entries []struct {
+		Type   string `json:"type"`
+		Name   string `json:"name"`
+		Inputs []struct {
+			Name         string `json:"name"`
+			InternalType string `json:"internalType"`
+		} `json:"inputs"`
+	}
+	if err := json.Unmarshal([]byte(contractAbiGenesis), &entries); err != nil {
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Type != "function" || entry.Name != method.RawName || len(entry.Inputs) != len(method.Inputs) {
+			continue
+		}
+		internalTypes := make([]string, len(entry.Inputs))
+		for i, input := range entry.Inputs {
+			if input.Name != method.Inputs[i].Name {
+				return nil
+			}
+			internalTypes[i] = input.InternalType
+		}
+		return internalTypes
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package infoHandler
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSafeTransactionNeedsEveryInput(t *testing.T) {
+	set := Abis.MustContract(AbiGenesis).Methods["set"]
+	if _, err := newSafeTransaction(common.HexToAddress(testGenesis), set, "1", "10"); err == nil || !strings.Contains(err.Error(), "set takes 3 values, got 2") {
+		t.Errorf("2 values: error = %v, want the count", err)
+	}
+	if _, err := newSafeTransaction(common.HexToAddress(testGenesis), set, "1", "10", "100", "0"); err == nil {
+		t.Error("4 values: want an error")
+	}
+}
+
+// internalType comes from the ABI, which names contracts and structs where
+// the type alone is only an address or a tuple.
+func TestSafeTransactionInternalType(t *testing.T) {
+	genesis := Abis.MustContract(AbiGenesis)
+	tx, err := newSafeTransaction(common.HexToAddress(testGenesis), genesis.Methods["addBulk"], "[1]", "[100]", "["+fakeToken(0).Hex()+"]", "false", "0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"uint256[]", "uint256[]", "contract IERC20[]", "bool", "uint256"}
+	for i, input := range tx.ContractMethod.Inputs {
+		if input.InternalType != want[i] {
+			t.Errorf("input %s internalType = %q, want %q", input.Name, input.InternalType, want[i])
+		}
+	}
+	if tx.ContractInputsValues["_tokens"] != "["+fakeToken(0).Hex()+"]" {
+		t.Errorf("values = %v", tx.ContractInputsValues)
+	}
+}
+
+func TestOperatorBatchAddsPool(t *testing.T) {
+	chain := newFakeChain(t)
+	chain.call = genesisCall(common.HexToAddress(testGenesis), 2)
+
+	items := decodeBatch(t, `[{"query": "build-operator-batch", "params": {
+		"chain-id": "146",
+		"genesis": "`+testGenesis+`",
+		"changes": [{"action": "add", "token": "`+fakeToken(7).Hex()+`", "allocPoint": "5", "depFee": "200"}]
+	}}]`)
+	responses := ExecuteBatch(httptest.NewRequest("POST", "/api/info", nil), items)
+	if responses[0].Error != nil {
+		t.Fatalf("batch item failed: %+v", responses[0].Error)
+	}
+
+	transactions := responses[0].Result.(BuildOperatorBatchResponse).Batch.Transactions
+	if len(transactions) != 1 || transactions[0].ContractMethod.Name != "add" {
+		t.Fatalf("transactions = %+v, want one add", transactions)
+	}
+	if values := transactions[0].ContractInputsValues; values["_allocPoint"] != "5" || values["_depFee"] != "200" || values["_token"] != fakeToken(7).Hex() || len(values) != 5 {
+		t.Errorf("values = %v", values)
+	}
+	if token := transactions[0].ContractMethod.Inputs[2]; token.InternalType != "contract IERC20" || token.Type != "address" {
+		t.Errorf("_token = %+v", token)
+	}
+}
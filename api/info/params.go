@@ -1,23 +1,105 @@
 package infoHandler
 
+import (
+	"math/big"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
 type PoolParams struct {
-	Address string `query:"address"`
-	PoolId  string `query:"pid"`
+	Address common.Address `query:"address" validate:"checksum"`
+	PoolId  *big.Int       `query:"pid" validate:"uint256"`
 }
 
+// GetGenesisBalancesParams reads every pool of the genesis when Pools is
+// omitted. The limit only bounds the pools a caller lists.
 type GetGenesisBalancesParams struct {
-	ChainId        string       `query:"chain-id"`
-	Pools          []PoolParams `query:"pools"`
-	GenesisAddress string       `query:"genesis"`
-	UserAddress    string       `query:"user" optional:"true"`
+	ChainId        string         `query:"chain-id" validate:"chain"`
+	Pools          []PoolParams   `query:"pools" optional:"true" validate:"maxitems=50"`
+	GenesisAddress common.Address `query:"genesis" validate:"checksum"`
+	UserAddress    common.Address `query:"user" optional:"true" validate:"checksum"`
 }
 
 type GetGenesisPairParams struct {
-	ChainId        string `query:"chain-id"`
-	GenesisAddress string `query:"genesis"`
-	PairAddress    string `query:"pair"`
-	BaseAddress    string `query:"base"`
-	QuoteAddress   string `query:"quote"`
-	UserAddress    string `query:"user" optional:"true"`
-	PoolId         string `query:"pid"`
+	ChainId        string         `query:"chain-id" validate:"chain"`
+	GenesisAddress common.Address `query:"genesis" validate:"checksum"`
+	PairAddress    common.Address `query:"pair" validate:"checksum"`
+	BaseAddress    common.Address `query:"base" validate:"checksum"`
+	QuoteAddress   common.Address `query:"quote" validate:"checksum"`
+	UserAddress    common.Address `query:"user" optional:"true" validate:"checksum"`
+	PoolId         *big.Int       `query:"pid" validate:"uint256"`
+}
+
+// GetPairTwapParams defaults to quoting one whole token0.
+type GetPairTwapParams struct {
+	ChainId     string         `query:"chain-id" validate:"chain"`
+	PairAddress common.Address `query:"pair" validate:"checksum"`
+	TokenIn     common.Address `query:"token-in" optional:"true" validate:"checksum"`
+	AmountIn    *big.Int       `query:"amount-in" optional:"true" validate:"uint256"`
+	Granularity *big.Int       `query:"granularity" optional:"true" validate:"uint256"`
+}
+
+type GetTokensParams struct {
+	ChainId string           `query:"chain-id" validate:"chain"`
+	Tokens  []common.Address `query:"tokens" validate:"checksum,maxitems=50"`
+}
+
+// ExportUserHistoryParams scans from the block the genesis was deployed at to
+// the latest one unless from-block or to-block are given. Format is csv or
+// ndjson, csv by default.
+type ExportUserHistoryParams struct {
+	ChainId        string         `query:"chain-id" validate:"chain"`
+	GenesisAddress common.Address `query:"genesis" validate:"checksum"`
+	UserAddress    common.Address `query:"user" validate:"checksum"`
+	Format         string         `query:"format" optional:"true"`
+	FromBlock      *big.Int       `query:"from-block" optional:"true" validate:"uint256"`
+	ToBlock        *big.Int       `query:"to-block" optional:"true" validate:"uint256"`
+}
+
+type OperatorChange struct {
+	Action         string   `json:"action"`
+	PoolId         string   `json:"pid,omitempty"`
+	AllocPoint     string   `json:"allocPoint,omitempty"`
+	DepFee         string   `json:"depFee,omitempty"`
+	Token          string   `json:"token,omitempty"`
+	WithUpdate     bool     `json:"withUpdate,omitempty"`
+	LastRewardTime string   `json:"lastRewardTime,omitempty"`
+	RewardTokens   []string `json:"rewardTokens,omitempty"`
+	Address        string   `json:"address,omitempty"`
+	Amount         string   `json:"amount,omitempty"`
+}
+
+// BuildOperatorBatchParams is sent as a JSON body, chain-id, genesis and safe
+// may also be given in the query string.
+type BuildOperatorBatchParams struct {
+	ChainId        string           `json:"chain-id" query:"chain-id" optional:"true" validate:"chain"`
+	GenesisAddress string           `json:"genesis" query:"genesis" optional:"true" validate:"checksum"`
+	SafeAddress    string           `json:"safe" query:"safe" optional:"true" validate:"checksum"`
+	Bulk           bool             `json:"bulk" optional:"true"`
+	Changes        []OperatorChange `json:"changes"`
+}
+
+type ContractCallParams struct {
+	ChainId         string            `query:"chain-id" validate:"chain"`
+	ContractAddress common.Address    `query:"contract" validate:"checksum"`
+	Method          string            `query:"method" validate:"maxlen=1024"`
+	Params          []utils.Parameter `query:"params" optional:"true" validate:"maxitems=32"`
+	Returns         []string          `query:"returns" optional:"true" validate:"maxitems=32"`
+}
+
+type DecodeParams struct {
+	ChainId  string        `query:"chain-id" optional:"true" validate:"chain"`
+	Abi      string        `query:"abi" optional:"true" validate:"maxlen=64"`
+	CallData string        `query:"calldata" optional:"true" validate:"hex"`
+	Topics   []common.Hash `query:"topics" optional:"true" validate:"maxitems=4"`
+	Data     string        `query:"data" optional:"true" validate:"hex"`
+	TxHash   common.Hash   `query:"tx" optional:"true"`
+}
+
+type InspectParams struct {
+	ChainId          string         `query:"chain-id" validate:"chain"`
+	Address          common.Address `query:"address" validate:"checksum"`
+	Slots            []*big.Int     `query:"slot" optional:"true" validate:"uint256,maxitems=32" base:"0"`
+	ExpectedCodeHash common.Hash    `query:"expect-code-hash" optional:"true"`
 }
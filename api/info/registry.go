@@ -0,0 +1,256 @@
+package infoHandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	AbiMulticall = "multicall"
+	AbiErc20     = "erc20"
+	AbiGenesis   = "genesis"
+	AbiPair      = "pair"
+)
+
+type AbiMethodRef struct {
+	Contract string
+	Method   abi.Method
+}
+
+type AbiEventRef struct {
+	Contract string
+	Event    abi.Event
+}
+
+type AbiErrorRef struct {
+	Contract string
+	Error    abi.Error
+}
+
+// AbiRegistry holds every parsed contract ABI together with lookup tables for
+// method names, 4-byte selectors, custom error selectors and event topics.
+type AbiRegistry struct {
+	mu        sync.RWMutex
+	contracts map[string]abi.ABI
+	methods   map[string][]AbiMethodRef
+	selectors map[[4]byte][]AbiMethodRef
+	errors    map[[4]byte][]AbiErrorRef
+	events    map[common.Hash][]AbiEventRef
+}
+
+var bundledAbis = map[string]string{
+	AbiMulticall: contractAbiMulticall,
+	AbiErc20:     contractAbiErc20,
+	AbiGenesis:   contractAbiGenesis,
+	AbiPair:      contractAbiPair,
+}
+
+// Abis is the registry shared by all handlers. The bundled ABIs are parsed at
+// package initialisation so a malformed literal fails before serving traffic.
+var Abis = mustNewBundledRegistry()
+
+func NewAbiRegistry() *AbiRegistry {
+	return &AbiRegistry{
+		contracts: make(map[string]abi.ABI),
+		methods:   make(map[string][]AbiMethodRef),
+		selectors: make(map[[4]byte][]AbiMethodRef),
+		errors:    make(map[[4]byte][]AbiErrorRef),
+		events:    make(map[common.Hash][]AbiEventRef),
+	}
+}
+
+func mustNewBundledRegistry() *AbiRegistry {
+	registry := NewAbiRegistry()
+
+	names := make([]string, 0, len(bundledAbis))
+	for name := range bundledAbis {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := registry.Register(name, []byte(bundledAbis[name])); err != nil {
+			panic(fmt.Sprintf("bundled abi %s is malformed: %v", name, err))
+		}
+	}
+	return registry
+}
+
+// Register parses an ABI (either a raw JSON array or a build artifact with an
+// "abi" field) and indexes it under the given contract name.
+func (reg *AbiRegistry) Register(name string, data []byte) error {
+	name = strings.ToLower(name)
+
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") {
+		var artifact struct {
+			Abi json.RawMessage `json:"abi"`
+		}
+		if err := json.Unmarshal(data, &artifact); err != nil {
+			return fmt.Errorf("invalid artifact json: %v", err)
+		}
+		if len(artifact.Abi) == 0 {
+			return fmt.Errorf("artifact has no abi field")
+		}
+		trimmed = string(artifact.Abi)
+	}
+
+	parsed, err := abi.JSON(strings.NewReader(trimmed))
+	if err != nil {
+		return err
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	if _, exists := reg.contracts[name]; exists {
+		return fmt.Errorf("abi %s already registered", name)
+	}
+	reg.contracts[name] = parsed
+
+	for _, method := range parsed.Methods {
+		ref := AbiMethodRef{Contract: name, Method: method}
+		reg.methods[method.RawName] = append(reg.methods[method.RawName], ref)
+
+		var selector [4]byte
+		copy(selector[:], method.ID)
+		reg.selectors[selector] = append(reg.selectors[selector], ref)
+	}
+	for _, abiError := range parsed.Errors {
+		var selector [4]byte
+		copy(selector[:], abiError.ID[:4])
+		reg.errors[selector] = append(reg.errors[selector], AbiErrorRef{Contract: name, Error: abiError})
+	}
+	for _, event := range parsed.Events {
+		reg.events[event.ID] = append(reg.events[event.ID], AbiEventRef{Contract: name, Event: event})
+	}
+
+	return nil
+}
+
+// LoadAbis registers the ABI_DIRECTORY, abis by default, into Abis once.
+// main and the CLI call it at startup to fail on a malformed file, handle
+// calls it on first use since Vercel never runs main.
+var LoadAbis = sync.OnceValue(func() error {
+	dir := os.Getenv("ABI_DIRECTORY")
+	if dir == "" {
+		dir = "abis"
+	}
+	return Abis.LoadDirectory(dir)
+})
+
+// LoadDirectory registers every *.json file in dir, named after the file.
+// A missing directory is not an error, a malformed file is. A file named
+// like a bundled ABI is skipped with a warning, as the handlers rely on the
+// methods of the bundled one.
+func (reg *AbiRegistry) LoadDirectory(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read abi directory %s: %v", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read abi %s: %v", path, err)
+		}
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if _, bundled := bundledAbis[strings.ToLower(name)]; bundled {
+			logrus.WithField("file", path).Warnf("abi %s is bundled, skipping the file, rename it to register it", strings.ToLower(name))
+			continue
+		}
+		if err := reg.Register(name, data); err != nil {
+			return fmt.Errorf("failed to load abi %s: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Contract returns the ABI registered under name. Bundled contracts always
+// exist, so callers may ignore the bool for AbiErc20, AbiGenesis, etc.
+func (reg *AbiRegistry) Contract(name string) (abi.ABI, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	parsed, ok := reg.contracts[strings.ToLower(name)]
+	return parsed, ok
+}
+
+func (reg *AbiRegistry) MustContract(name string) abi.ABI {
+	parsed, ok := reg.Contract(name)
+	if !ok {
+		panic(fmt.Sprintf("abi %s not registered", name))
+	}
+	return parsed
+}
+
+func (reg *AbiRegistry) ContractNames() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	names := make([]string, 0, len(reg.contracts))
+	for name := range reg.contracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (reg *AbiRegistry) MethodsByName(name string) []AbiMethodRef {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	return append([]AbiMethodRef(nil), reg.methods[name]...)
+}
+
+func (reg *AbiRegistry) MethodsBySelector(selector []byte) []AbiMethodRef {
+	if len(selector) < 4 {
+		return nil
+	}
+	var key [4]byte
+	copy(key[:], selector[:4])
+
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	return append([]AbiMethodRef(nil), reg.selectors[key]...)
+}
+
+func (reg *AbiRegistry) ErrorsBySelector(selector []byte) []AbiErrorRef {
+	if len(selector) < 4 {
+		return nil
+	}
+	var key [4]byte
+	copy(key[:], selector[:4])
+
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	return append([]AbiErrorRef(nil), reg.errors[key]...)
+}
+
+func (reg *AbiRegistry) EventsByTopic(topic common.Hash) []AbiEventRef {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	return append([]AbiEventRef(nil), reg.events[topic]...)
+}
@@ -0,0 +1,87 @@
+package infoHandler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestRegistryLookups(t *testing.T) {
+	registry := mustNewBundledRegistry()
+
+	// transfer(address,uint256), which the pair has as an ERC20 itself
+	refs := registry.MethodsBySelector(hexutil.MustDecode("0xa9059cbb"))
+	if len(refs) != 2 || refs[0].Contract != AbiErc20 || refs[1].Contract != AbiPair || refs[0].Method.RawName != "transfer" {
+		t.Errorf("transfer selector = %+v", refs)
+	}
+	if refs := registry.MethodsBySelector([]byte{0xa9}); refs != nil {
+		t.Errorf("short selector = %+v, want none", refs)
+	}
+
+	deposit := crypto.Keccak256Hash([]byte("Deposit(address,uint256,uint256)"))
+	events := registry.EventsByTopic(deposit)
+	if len(events) != 1 || events[0].Contract != AbiGenesis || events[0].Event.RawName != "Deposit" {
+		t.Errorf("Deposit topic = %+v", events)
+	}
+
+	found := false
+	for _, ref := range registry.MethodsByName("poolInfo") {
+		found = found || ref.Contract == AbiGenesis
+	}
+	if !found {
+		t.Error("poolInfo is not found by name in the genesis abi")
+	}
+	if _, ok := registry.Contract("ERC20"); !ok {
+		t.Error("contract names are not case insensitive")
+	}
+}
+
+func TestLoadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("vault.json", `{"abi": [{"type": "function", "name": "harvest", "inputs": [], "outputs": [], "stateMutability": "nonpayable"}]}`)
+	write("erc20.json", `[{"type": "function", "name": "mint", "inputs": [], "outputs": [], "stateMutability": "nonpayable"}]`)
+	write("notes.txt", "not an abi")
+
+	registry := mustNewBundledRegistry()
+	if err := registry.LoadDirectory(dir); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, ok := registry.Contract("vault"); !ok {
+		t.Error("vault is not registered")
+	}
+	// The bundled erc20 is kept over the file of the same name
+	if erc20 := registry.MustContract(AbiErc20); erc20.Methods["mint"].RawName != "" || erc20.Methods["transfer"].RawName == "" {
+		t.Error("erc20.json replaced the bundled erc20")
+	}
+
+	write("broken.json", `[{"type": "function"`)
+	if err := mustNewBundledRegistry().LoadDirectory(dir); err == nil {
+		t.Error("a malformed file loaded")
+	}
+	if err := NewAbiRegistry().LoadDirectory(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("missing directory: %v", err)
+	}
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	registry := NewAbiRegistry()
+	data := []byte(`[{"type": "event", "name": "Ping", "inputs": [], "anonymous": false}]`)
+	if err := registry.Register("Ping", data); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Register("ping", data); err == nil {
+		t.Error("a duplicate name registered")
+	}
+	if events := registry.EventsByTopic(common.BytesToHash(crypto.Keccak256([]byte("Ping()")))); len(events) != 1 {
+		t.Errorf("Ping topic = %+v", events)
+	}
+}
@@ -0,0 +1,490 @@
+package infoHandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Resolvers of schema.graphql. Every contract read goes through the executor
+// so that it is batched with the rest of its round.
+
+type gqlQuery struct{}
+
+func (gqlQuery) typeName() string { return "Query" }
+
+func (gqlQuery) resolve(e *gqlExecutor, f gqlField) {
+	switch f.Name {
+	case "__schema", "__type":
+		e.fail(f, utils.ErrMalformedRequest("introspection is not supported, the schema is served at "+graphqlSchemaPath))
+		return
+	}
+
+	chainId, _ := f.args["chainId"].(string)
+	if _, err := GetChainInfo(chainId); err != nil {
+		e.fail(f, err)
+		return
+	}
+
+	switch f.Name {
+	case "pool":
+		genesis, err := gqlAddressArg(f, "genesis")
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		pid, err := gqlPidArg(f)
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		e.object(f, gqlPool{genesis: gqlGenesis{chainId: chainId, address: genesis}, pid: pid})
+		return
+	}
+
+	address, err := gqlAddressArg(f, "address")
+	if err != nil {
+		e.fail(f, err)
+		return
+	}
+	switch f.Name {
+	case "genesis":
+		e.object(f, gqlGenesis{chainId: chainId, address: address})
+	case "pair":
+		e.object(f, gqlPair{chainId: chainId, address: address})
+	case "token":
+		e.object(f, gqlToken{chainId: chainId, address: address})
+	case "user":
+		e.object(f, gqlUser{chainId: chainId, address: address})
+	}
+}
+
+type gqlGenesis struct {
+	chainId string
+	address common.Address
+}
+
+func (gqlGenesis) typeName() string { return "Genesis" }
+
+func (g gqlGenesis) resolve(e *gqlExecutor, f gqlField) {
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+	switch f.Name {
+	case "chainId":
+		f.set(g.chainId)
+	case "address":
+		f.set(g.address.Hex())
+	case "operator", "devFund", "poolStartTime", "poolEndTime", "runningTime", "totalAllocPoint", "valhallaPerSecond":
+		e.value(f, g.chainId, Calls{contractAddress: g.address, abi: parsedGenesisABI, method: f.Name}, 0)
+	case "poolLength":
+		g.poolLength(e, func(length int64, err error) {
+			if err != nil {
+				e.fail(f, err)
+				return
+			}
+			f.set(length)
+		})
+	case "pool":
+		pid, err := gqlPidArg(f)
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		e.object(f, gqlPool{genesis: g, pid: pid})
+	case "pools":
+		g.eachPool(e, f, func(pid int64) gqlResolver {
+			return gqlPool{genesis: g, pid: pid}
+		})
+	}
+}
+
+func (g gqlGenesis) poolLength(e *gqlExecutor, then func(length int64, err error)) {
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+	e.read(g.chainId, Calls{contractAddress: g.address, abi: parsedGenesisABI, method: "poolLength"}, func(data []byte, err error) {
+		if err != nil {
+			then(0, err)
+			return
+		}
+		length, err := unpackUint(parsedGenesisABI.Unpack("poolLength", data))
+		if err != nil {
+			then(0, fmt.Errorf("failed to unpack poolLength: %v", err))
+			return
+		}
+		then(length.Int64(), nil)
+	})
+}
+
+// eachPool resolves f to a list with an object per pid of its pids argument,
+// or per pool of the genesis once poolLength is known.
+func (g gqlGenesis) eachPool(e *gqlExecutor, f gqlField, build func(pid int64) gqlResolver) {
+	if pids, ok := f.args["pids"].([]interface{}); ok {
+		resolvers := make([]gqlResolver, len(pids))
+		for i, value := range pids {
+			pid, err := gqlInt(value)
+			if err != nil || pid < 0 {
+				e.fail(f, utils.ErrMalformedRequest(fmt.Sprintf("invalid pid %v", value)))
+				return
+			}
+			resolvers[i] = build(pid)
+		}
+		e.list(f, resolvers)
+		return
+	}
+
+	g.poolLength(e, func(length int64, err error) {
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		if length > int64(e.maxCalls) {
+			e.overLimit = true
+			return
+		}
+		resolvers := make([]gqlResolver, length)
+		for pid := range resolvers {
+			resolvers[pid] = build(int64(pid))
+		}
+		e.list(f, resolvers)
+	})
+}
+
+type gqlPool struct {
+	genesis gqlGenesis
+	pid     int64
+}
+
+func (gqlPool) typeName() string { return "Pool" }
+
+func (p gqlPool) resolve(e *gqlExecutor, f gqlField) {
+	chainId := p.genesis.chainId
+	switch f.Name {
+	case "pid":
+		f.set(p.pid)
+	case "genesis":
+		e.object(f, p.genesis)
+	case "position":
+		user, err := gqlAddressArg(f, "user")
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		e.object(f, gqlPosition{pool: p, user: user})
+	default:
+		p.info(e, func(info PoolInfo, err error) {
+			if err != nil {
+				e.fail(f, err)
+				return
+			}
+			switch f.Name {
+			case "token":
+				e.object(f, gqlToken{chainId: chainId, address: info.Token})
+			case "pair":
+				// Only pairs answer token0, anything else resolves to null
+				e.read(chainId, Calls{contractAddress: info.Token, abi: Abis.MustContract(AbiPair), method: "token0"}, func(data []byte, err error) {
+					if err == nil && len(data) == 32 {
+						e.object(f, gqlPair{chainId: chainId, address: info.Token})
+					}
+				})
+			case "staked":
+				e.value(f, chainId, Calls{contractAddress: info.Token, abi: Abis.MustContract(AbiErc20), method: "balanceOf", params: p.genesis.address}, 0)
+			default:
+				f.set(poolInfoField(info, f.Name))
+			}
+		})
+	}
+}
+
+func (p gqlPool) info(e *gqlExecutor, then func(info PoolInfo, err error)) {
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+	e.read(p.genesis.chainId, Calls{contractAddress: p.genesis.address, abi: parsedGenesisABI, method: "poolInfo", params: big.NewInt(p.pid)}, func(data []byte, err error) {
+		if err != nil {
+			then(PoolInfo{}, err)
+			return
+		}
+		var info PoolInfo
+		if err := parsedGenesisABI.UnpackIntoInterface(&info, "poolInfo", data); err != nil {
+			then(PoolInfo{}, fmt.Errorf("failed to unpack poolInfo for pid %d: %v", p.pid, err))
+			return
+		}
+		then(info, nil)
+	})
+}
+
+func poolInfoField(info PoolInfo, name string) interface{} {
+	switch name {
+	case "depFee":
+		return gqlScalar(info.DepFee)
+	case "allocPoint":
+		return gqlScalar(info.AllocPoint)
+	case "lastRewardTime":
+		return gqlScalar(info.LastRewardTime)
+	case "accValhallaPerShare":
+		return gqlScalar(info.AccValhallaPerShare)
+	case "isStarted":
+		return info.IsStarted
+	case "isGauge":
+		return info.GaugeInfo.IsGauge
+	case "gauge":
+		return gqlScalar(info.GaugeInfo.Gauge)
+	case "rewardTokens":
+		return gqlScalar(info.GaugeInfo.RewardTokens)
+	case "poolValhallaPerSec":
+		return gqlScalar(info.PoolValhallaPerSec)
+	}
+	return nil
+}
+
+type gqlPosition struct {
+	pool gqlPool
+	user common.Address
+}
+
+func (gqlPosition) typeName() string { return "Position" }
+
+func (p gqlPosition) resolve(e *gqlExecutor, f gqlField) {
+	chainId := p.pool.genesis.chainId
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+	params := []interface{}{big.NewInt(p.pool.pid), p.user}
+	switch f.Name {
+	case "user":
+		f.set(p.user.Hex())
+	case "pid":
+		f.set(p.pool.pid)
+	case "pool":
+		e.object(f, p.pool)
+	case "amount":
+		e.value(f, chainId, Calls{contractAddress: p.pool.genesis.address, abi: parsedGenesisABI, method: "userInfo", params: params}, 0)
+	case "rewardDebt":
+		e.value(f, chainId, Calls{contractAddress: p.pool.genesis.address, abi: parsedGenesisABI, method: "userInfo", params: params}, 1)
+	case "pendingVAL":
+		e.value(f, chainId, Calls{contractAddress: p.pool.genesis.address, abi: parsedGenesisABI, method: "pendingVAL", params: params}, 0)
+	case "walletBalance":
+		p.pool.info(e, func(info PoolInfo, err error) {
+			if err != nil {
+				e.fail(f, err)
+				return
+			}
+			e.value(f, chainId, Calls{contractAddress: info.Token, abi: Abis.MustContract(AbiErc20), method: "balanceOf", params: p.user}, 0)
+		})
+	}
+}
+
+type gqlPair struct {
+	chainId string
+	address common.Address
+}
+
+func (gqlPair) typeName() string { return "Pair" }
+
+func (p gqlPair) resolve(e *gqlExecutor, f gqlField) {
+	parsedPairABI := Abis.MustContract(AbiPair)
+	switch f.Name {
+	case "chainId":
+		f.set(p.chainId)
+	case "address":
+		f.set(p.address.Hex())
+	case "token0", "token1":
+		e.read(p.chainId, Calls{contractAddress: p.address, abi: parsedPairABI, method: f.Name}, func(data []byte, err error) {
+			if err == nil {
+				var out []interface{}
+				if out, err = parsedPairABI.Unpack(f.Name, data); err == nil {
+					e.object(f, gqlToken{chainId: p.chainId, address: out[0].(common.Address)})
+					return
+				}
+			}
+			e.fail(f, err)
+		})
+	case "reserve0", "reserve1", "blockTimestampLast":
+		output := map[string]int{"reserve0": 0, "reserve1": 1, "blockTimestampLast": 2}[f.Name]
+		e.value(f, p.chainId, Calls{contractAddress: p.address, abi: parsedPairABI, method: "getReserves"}, output)
+	case "totalSupply", "stable":
+		e.value(f, p.chainId, Calls{contractAddress: p.address, abi: parsedPairABI, method: f.Name}, 0)
+	case "balanceOf":
+		owner, err := gqlAddressArg(f, "owner")
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		e.value(f, p.chainId, Calls{contractAddress: p.address, abi: parsedPairABI, method: "balanceOf", params: owner}, 0)
+	}
+}
+
+type gqlToken struct {
+	chainId string
+	address common.Address
+}
+
+func (gqlToken) typeName() string { return "Token" }
+
+func (t gqlToken) resolve(e *gqlExecutor, f gqlField) {
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	switch f.Name {
+	case "chainId":
+		f.set(t.chainId)
+	case "address":
+		f.set(t.address.Hex())
+	case "name", "symbol", "decimals", "displayName", "logo":
+		t.metadata(e, f, func(metadata TokenMetadata) {
+			f.set(tokenMetadataField(metadata, f.Name))
+		})
+	case "totalSupply":
+		e.value(f, t.chainId, Calls{contractAddress: t.address, abi: parsedErc20ABI, method: f.Name}, 0)
+	case "balanceOf":
+		owner, err := gqlAddressArg(f, "owner")
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		e.value(f, t.chainId, Calls{contractAddress: t.address, abi: parsedErc20ABI, method: "balanceOf", params: owner}, 0)
+	}
+}
+
+// metadata hands the token metadata to then, from the registry or from name,
+// symbol and decimals reads that may revert, like they do on older tokens.
+func (t gqlToken) metadata(e *gqlExecutor, f gqlField, then func(metadata TokenMetadata)) {
+	if metadata, ok := Tokens.cachedMetadata(t.chainId, t.address); ok {
+		then(metadata)
+		return
+	}
+
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	methods := []string{"name", "symbol", "decimals"}
+	results := make([]MulticallResult, len(methods))
+	remaining, failed := len(methods), false
+	for i, method := range methods {
+		e.read(t.chainId, Calls{contractAddress: t.address, abi: parsedErc20ABI, method: method}, func(data []byte, err error) {
+			if failed {
+				return
+			}
+			if err != nil && utils.AsError(err).Type != utils.CodeCallReverted {
+				failed = true
+				e.fail(f, err)
+				return
+			}
+			results[i] = MulticallResult{Success: err == nil, ReturnData: data}
+			if remaining--; remaining > 0 {
+				return
+			}
+			lookup := &tokenLookup{chainId: t.chainId, tokens: []common.Address{t.address}, cached: make(map[common.Address]TokenMetadata), misses: []common.Address{t.address}}
+			then(lookup.resolve(results)[t.address])
+		})
+	}
+}
+
+func tokenMetadataField(metadata TokenMetadata, name string) interface{} {
+	var value string
+	switch name {
+	case "decimals":
+		if metadata.Decimals == nil {
+			return nil
+		}
+		return int(*metadata.Decimals)
+	case "name":
+		value = metadata.Name
+	case "symbol":
+		value = metadata.Symbol
+	case "displayName":
+		value = metadata.DisplayName
+	case "logo":
+		value = metadata.Logo
+	}
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+type gqlUser struct {
+	chainId string
+	address common.Address
+}
+
+func (gqlUser) typeName() string { return "User" }
+
+func (u gqlUser) resolve(e *gqlExecutor, f gqlField) {
+	switch f.Name {
+	case "chainId":
+		f.set(u.chainId)
+	case "address":
+		f.set(u.address.Hex())
+	case "positions":
+		genesisAddress, err := gqlAddressArg(f, "genesis")
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		genesis := gqlGenesis{chainId: u.chainId, address: genesisAddress}
+		genesis.eachPool(e, f, func(pid int64) gqlResolver {
+			return gqlPosition{pool: gqlPool{genesis: genesis, pid: pid}, user: u.address}
+		})
+	case "balanceOf":
+		token, err := gqlAddressArg(f, "token")
+		if err != nil {
+			e.fail(f, err)
+			return
+		}
+		e.value(f, u.chainId, Calls{contractAddress: token, abi: Abis.MustContract(AbiErc20), method: "balanceOf", params: u.address}, 0)
+	}
+}
+
+func gqlAddressArg(f gqlField, name string) (common.Address, error) {
+	value, _ := f.args[name].(string)
+	if !common.IsHexAddress(value) {
+		return common.Address{}, utils.ErrInvalidAddress(fmt.Sprintf("%s %q is not an address", name, value))
+	}
+	return common.HexToAddress(value), nil
+}
+
+func gqlPidArg(f gqlField) (int64, error) {
+	pid, err := gqlInt(f.args["pid"])
+	if err != nil || pid < 0 {
+		return 0, utils.ErrMalformedRequest(fmt.Sprintf("invalid pid %v", f.args["pid"]))
+	}
+	return pid, nil
+}
+
+// gqlInt reads an Int argument, which is an int64 when written in the query
+// and a json.Number when it comes from the variables.
+func gqlInt(value interface{}) (int64, error) {
+	switch value := value.(type) {
+	case int64:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case json.Number:
+		return value.Int64()
+	case string:
+		return strconv.ParseInt(value, 10, 64)
+	}
+	return 0, fmt.Errorf("expected an integer, got %T", value)
+}
+
+// gqlScalar converts an ABI value to its schema type: uint256 and other wide
+// integers to decimal strings, addresses to checksummed hex.
+func gqlScalar(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *big.Int:
+		if value == nil {
+			return nil
+		}
+		return value.String()
+	case common.Address:
+		return value.Hex()
+	case []common.Address:
+		addresses := make([]string, len(value))
+		for i, address := range value {
+			addresses[i] = address.Hex()
+		}
+		return addresses
+	case uint8:
+		return int(value)
+	case uint32:
+		return strconv.FormatUint(uint64(value), 10)
+	case uint64:
+		return strconv.FormatUint(value, 10)
+	}
+	return value
+}
@@ -0,0 +1,353 @@
+package infoHandler
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const restPrefix = "/api/v1/"
+
+// RestRoute exposes a ?query= entry point as a resource URL. PathParams maps
+// path wildcards to the query parameters the service binds, Resolve fills
+// parameters that can be read from chain (e.g. every pool of a genesis) and
+// Resolved names them so the docs mark them optional. CacheSeconds lets
+// browsers and CDNs keep a response, see setCacheControl for what a CDN hit
+// skips.
+type RestRoute struct {
+	Pattern      string
+	Query        string
+	PathParams   map[string]string
+	Resolve      func(ctx context.Context, values url.Values) error
+	Resolved     []string
+	CacheSeconds int
+}
+
+var genesisPath = map[string]string{"chainId": "chain-id", "address": "genesis"}
+
+func withPath(base map[string]string, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+//http://localhost:8080/api/v1/chains/146/genesis/0x23Ee13d49e78811d063722D9228547a7dF73E42E/pools
+//http://localhost:8080/api/v1/chains/146/genesis/0x23Ee13d49e78811d063722D9228547a7dF73E42E/pools/18/users/0x...
+
+// restRoutes are served under /api/v1/ next to the legacy ?query= dispatcher.
+var restRoutes = []RestRoute{
+	{
+		Pattern:      "GET /api/v1/version",
+		Query:        "version",
+		CacheSeconds: 3600,
+	},
+	{
+		Pattern:      "GET /api/v1/errors",
+		Query:        "errors",
+		CacheSeconds: 3600,
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/genesis/{address}/pools",
+		Query:        "get-genesis-balances",
+		PathParams:   genesisPath,
+		Resolve:      resolveGenesisPools,
+		Resolved:     []string{"pools.address", "pools.pid"},
+		CacheSeconds: 5,
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/genesis/{address}/pools/{pid}",
+		Query:        "get-genesis-balances",
+		PathParams:   withPath(genesisPath, map[string]string{"pid": "pools.pid"}),
+		Resolve:      resolveGenesisPools,
+		Resolved:     []string{"pools.address"},
+		CacheSeconds: 5,
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/genesis/{address}/users/{user}",
+		Query:        "get-genesis-balances",
+		PathParams:   withPath(genesisPath, map[string]string{"user": "user"}),
+		Resolve:      resolveGenesisPools,
+		Resolved:     []string{"pools.address", "pools.pid"},
+		CacheSeconds: 5,
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/tokens/{address}",
+		Query:        "get-tokens",
+		PathParams:   map[string]string{"chainId": "chain-id", "address": "tokens"},
+		CacheSeconds: 60,
+	},
+	{
+		Pattern:    "GET /api/v1/chains/{chainId}/genesis/{address}/users/{user}/history",
+		Query:      "export-user-history",
+		PathParams: withPath(genesisPath, map[string]string{"user": "user"}),
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/genesis/{address}/pools/{pid}/users/{user}",
+		Query:        "get-genesis-balances",
+		PathParams:   withPath(genesisPath, map[string]string{"pid": "pools.pid", "user": "user"}),
+		Resolve:      resolveGenesisPools,
+		Resolved:     []string{"pools.address"},
+		CacheSeconds: 5,
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/genesis/{address}/pairs/{pair}",
+		Query:        "get-genesis-pair",
+		PathParams:   withPath(genesisPath, map[string]string{"pair": "pair"}),
+		Resolve:      resolveGenesisPair,
+		Resolved:     []string{"base", "quote", "pid"},
+		CacheSeconds: 5,
+	},
+	{
+		Pattern:    "POST /api/v1/chains/{chainId}/genesis/{address}/operator-batch",
+		Query:      "build-operator-batch",
+		PathParams: genesisPath,
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/addresses/{address}",
+		Query:        "inspect",
+		PathParams:   map[string]string{"chainId": "chain-id", "address": "address"},
+		CacheSeconds: 5,
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/contracts/{address}/call",
+		Query:        "call",
+		PathParams:   map[string]string{"chainId": "chain-id", "address": "contract"},
+		CacheSeconds: 5,
+	},
+	{
+		Pattern:      "GET /api/v1/chains/{chainId}/transactions/{hash}",
+		Query:        "decode",
+		PathParams:   map[string]string{"chainId": "chain-id", "hash": "tx"},
+		CacheSeconds: 3600,
+	},
+	{
+		Pattern:      "GET /api/v1/decode",
+		Query:        "decode",
+		CacheSeconds: 3600,
+	},
+}
+
+var restMux = newRestMux()
+
+func newRestMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, route := range restRoutes {
+		if _, ok := queryRoutes[route.Query]; !ok {
+			panic(fmt.Sprintf("rest route %s targets unknown query %s", route.Pattern, route.Query))
+		}
+		mux.HandleFunc(route.Pattern, route.serve)
+	}
+	for pattern, handler := range alertRoutes {
+		mux.HandleFunc(pattern, serveAlerts(handler))
+	}
+	return mux
+}
+
+func HandleRest(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := restMux.Handler(r); pattern == "" {
+		HandleResponse(w, r, nil, utils.ErrNotFound(fmt.Sprintf("no route for %s %s", r.Method, r.URL.Path)))
+		return
+	}
+	restMux.ServeHTTP(w, r)
+}
+
+// serve rewrites the resource URL into the query string the service expects,
+// path values taking precedence, and runs the same handler as ?query=.
+func (route RestRoute) serve(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	for pathName, queryName := range route.PathParams {
+		values.Set(queryName, r.PathValue(pathName))
+	}
+
+	if err := limitRequest(w, r, queryCost(route.Query, values)); err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+
+	response, err := runQuery(r, route.Query, func(r *http.Request) (interface{}, error) {
+		if route.Resolve != nil {
+			if err := route.Resolve(r.Context(), values); err != nil {
+				return nil, err
+			}
+		}
+
+		routeURL := *r.URL
+		routeURL.RawQuery = values.Encode()
+		routeRequest := r.Clone(r.Context())
+		routeRequest.URL = &routeURL
+
+		return queryRoutes[route.Query].Handler(routeRequest)
+	})
+	if err == nil && route.CacheSeconds > 0 {
+		setCacheControl(w, route.CacheSeconds)
+	}
+	HandleResponse(w, r, response, err)
+}
+
+// restPath splits the pattern into its lower cased method and its path.
+func (route RestRoute) restPath() (string, string) {
+	method, path, _ := strings.Cut(route.Pattern, " ")
+	return strings.ToLower(method), path
+}
+
+func resolveChainAndGenesis(values url.Values) (string, common.Address, error) {
+	chainId := values.Get("chain-id")
+	if _, err := GetChainInfo(chainId); err != nil {
+		return "", common.Address{}, err
+	}
+	genesis := values.Get("genesis")
+	if !common.IsHexAddress(genesis) {
+		return "", common.Address{}, utils.ErrInvalidAddress(fmt.Sprintf("invalid genesis address %s", genesis))
+	}
+	return chainId, common.HexToAddress(genesis), nil
+}
+
+// resolveGenesisPools looks up the staked token when only pids were given.
+// Without pids every pool is read by get-genesis-balances itself, as the
+// pool count of a genesis is not bounded like a caller's list.
+func resolveGenesisPools(ctx context.Context, values url.Values) error {
+	pids := values["pools.pid"]
+	if values.Has("pools.address") || len(pids) == 0 {
+		return nil
+	}
+	chainId, genesis, err := resolveChainAndGenesis(values)
+	if err != nil {
+		return err
+	}
+
+	pools, err := fetchGenesisPools(ctx, chainId, genesis)
+	if err != nil {
+		return err
+	}
+
+	for _, pid := range pids {
+		n, ok := new(big.Int).SetString(pid, 10)
+		if !ok || n.Sign() < 0 {
+			return utils.ErrMalformedRequest(fmt.Sprintf("invalid pid %s", pid))
+		}
+		if !n.IsInt64() || n.Int64() >= int64(len(pools)) {
+			return utils.ErrNotFound(fmt.Sprintf("pid %s does not exist (pool length %d)", pid, len(pools)))
+		}
+		values.Add("pools.address", pools[n.Int64()].Token.Hex())
+	}
+	return nil
+}
+
+// resolveGenesisPair reads base and quote from the pair's token0 and token1,
+// and finds the pid staking the pair, unless they were given.
+func resolveGenesisPair(ctx context.Context, values url.Values) error {
+	if values.Has("base") && values.Has("quote") && values.Has("pid") {
+		return nil
+	}
+	chainId, genesis, err := resolveChainAndGenesis(values)
+	if err != nil {
+		return err
+	}
+	pairValue := values.Get("pair")
+	if !common.IsHexAddress(pairValue) {
+		return utils.ErrInvalidAddress(fmt.Sprintf("invalid pair address %s", pairValue))
+	}
+	pair := common.HexToAddress(pairValue)
+
+	if !values.Has("base") || !values.Has("quote") {
+		token0, token1, err := fetchPairTokens(ctx, chainId, pair)
+		if err != nil {
+			return err
+		}
+		if !values.Has("base") {
+			values.Set("base", token0.Hex())
+		}
+		if !values.Has("quote") {
+			values.Set("quote", token1.Hex())
+		}
+	}
+
+	if !values.Has("pid") {
+		pools, err := fetchGenesisPools(ctx, chainId, genesis)
+		if err != nil {
+			return err
+		}
+		for pid, pool := range pools {
+			if pool.Token == pair {
+				values.Set("pid", fmt.Sprint(pid))
+				return nil
+			}
+		}
+		return utils.ErrNotFound(fmt.Sprintf("no pool stakes pair %s", pair.Hex()))
+	}
+	return nil
+}
+
+// ListGenesisPools reads the configuration of every pool of genesis, under
+// the timeout of QUERY_TIMEOUT_LIST_GENESIS_POOLS or QUERY_TIMEOUT.
+func ListGenesisPools(ctx context.Context, chainId string, genesis string) ([]PoolInfo, error) {
+	chainId, genesisAddress, err := resolveChainAndGenesis(url.Values{"chain-id": {chainId}, "genesis": {genesis}})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout("list-genesis-pools"))
+	defer cancel()
+	return fetchGenesisPools(ctx, chainId, genesisAddress)
+}
+
+func fetchGenesisPools(ctx context.Context, chainId string, genesis common.Address) ([]PoolInfo, error) {
+	client, err := GetClientForChain(ctx, chainId)
+	if err != nil {
+		return nil, err
+	}
+	multicallAddress, err := getMulticallAddress(chainId)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := fetchGenesisOperatorState(ctx, client, multicallAddress, Abis.MustContract(AbiGenesis), genesis)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list genesis pools: %w", err)
+	}
+	return state.Pools, nil
+}
+
+func fetchPairTokens(ctx context.Context, chainId string, pair common.Address) (common.Address, common.Address, error) {
+	client, err := GetClientForChain(ctx, chainId)
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+	multicallAddress, err := getMulticallAddress(chainId)
+	if err != nil {
+		return common.Address{}, common.Address{}, err
+	}
+
+	parsedPairABI := Abis.MustContract(AbiPair)
+	results, err := MulticallView(ctx, client, multicallAddress, []Calls{
+		{contractAddress: pair, abi: parsedPairABI, method: "token0", params: nil},
+		{contractAddress: pair, abi: parsedPairABI, method: "token1", params: nil},
+	})
+	if err != nil {
+		return common.Address{}, common.Address{}, fmt.Errorf("failed to read pair tokens: %w", err)
+	}
+	if len(results) != 2 {
+		return common.Address{}, common.Address{}, fmt.Errorf("expected 2 multicall results, got %d", len(results))
+	}
+
+	tokens := make([]common.Address, 2)
+	for i, result := range results {
+		out, err := parsedPairABI.Unpack([]string{"token0", "token1"}[i], result.ReturnData)
+		if err != nil {
+			return common.Address{}, common.Address{}, utils.ErrNotFound(fmt.Sprintf("%s is not a pair: %v", pair.Hex(), err))
+		}
+		tokens[i] = out[0].(common.Address)
+	}
+	return tokens[0], tokens[1], nil
+}
@@ -0,0 +1,115 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/ratelimit"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func getJSON(t *testing.T, path string, response interface{}) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	Handler(w, httptest.NewRequest("GET", path, nil))
+	if w.Code == http.StatusOK && response != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), response); err != nil {
+			t.Fatalf("invalid response %s: %v", w.Body.String(), err)
+		}
+	}
+	if w.Code != http.StatusOK {
+		t.Logf("%s: %d %s", path, w.Code, w.Body.String())
+	}
+	return w.Code
+}
+
+// A genesis may have more pools than a caller may list, every one of them is
+// still read.
+func TestEveryPoolOfALargeGenesis(t *testing.T) {
+	const pools = 130
+	chain := newFakeChain(t)
+	chain.call = genesisCall(common.HexToAddress(testGenesis), pools)
+
+	var response GetGenesisBalancesResponse
+	if code := getJSON(t, "/api/v1/chains/146/genesis/"+testGenesis+"/pools", &response); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(response.Pools) != pools {
+		t.Fatalf("got %d pools, want %d", len(response.Pools), pools)
+	}
+	for pid, pool := range response.Pools {
+		if pool.Token != fakeToken(pid).Hex() || pool.GenesisBalance != fmt.Sprint(pid+1) {
+			t.Fatalf("pool %d = %+v", pid, pool)
+		}
+	}
+}
+
+func TestCallerPoolListIsLimited(t *testing.T) {
+	newFakeChain(t).call = genesisCall(common.HexToAddress(testGenesis), 60)
+
+	values := url.Values{"chain-id": {"146"}, "genesis": {testGenesis}}
+	for i := 0; i < 51; i++ {
+		values.Add("pools.address", fakeToken(0).Hex())
+		values.Add("pools.pid", "0")
+	}
+	_, err := Query(context.Background(), "get-genesis-balances", values)
+	var apiErr utils.Error
+	if !errors.As(err, &apiErr) || !strings.Contains(apiErr.Details, "at most 50 values allowed, got 51") {
+		t.Errorf("51 pools: error = %v, want the limit", err)
+	}
+}
+
+// useLimiter serves requests with a limiter of config until the test ends.
+func useLimiter(t *testing.T, config ratelimit.Config) {
+	t.Helper()
+	limiter, err := ratelimit.New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := rateLimiter
+	rateLimiter = func() *ratelimit.Limiter { return limiter }
+	t.Cleanup(func() { rateLimiter = saved })
+}
+
+// Cached responses vary on the key, and stay out of shared caches when a key
+// is required as a CDN would serve them to callers without one.
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name       string
+		requireKey bool
+		want       string
+	}{
+		{"anonymous allowed", false, "public, max-age=3600, s-maxage=3600"},
+		{"key required", true, "private, max-age=3600"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := ratelimit.DefaultConfig()
+			config.RequireKey = test.requireKey
+			config.Keys = []ratelimit.Key{{Key: "secret", Name: "test", Tier: ratelimit.AnonymousTier}}
+			useLimiter(t, config)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/api/v1/version", nil)
+			r.Header.Set("X-API-Key", "secret")
+			Handler(w, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Cache-Control"); got != test.want {
+				t.Errorf("Cache-Control = %q, want %q", got, test.want)
+			}
+			if vary := strings.Join(w.Header().Values("Vary"), ", "); !strings.Contains(vary, "Authorization, X-API-Key") {
+				t.Errorf("Vary = %q, want the key headers", vary)
+			}
+		})
+	}
+}
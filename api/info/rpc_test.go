@@ -0,0 +1,240 @@
+package infoHandler
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// fakeChain is a JSON-RPC server standing in for every RPC of chain 146. Calls
+// to multicallView are split into their subcalls, so tests only answer plain
+// contract calls.
+type fakeChain struct {
+	mu sync.Mutex
+	// call answers a contract call, ok false reverts it
+	call func(to common.Address, data []byte) (result []byte, ok bool)
+	// methods answers any other JSON-RPC method
+	methods map[string]func(params []json.RawMessage) (interface{}, error)
+	// sent is the calldata of every eth_call, subcalls included
+	sent [][]byte
+	// blocks is the block of every eth_call sent to the multicall
+	blocks []string
+}
+
+// newFakeChain points chain 146 at a fake RPC until the test ends.
+func newFakeChain(t *testing.T) *fakeChain {
+	t.Helper()
+	chain := &fakeChain{methods: make(map[string]func([]json.RawMessage) (interface{}, error))}
+	server := httptest.NewServer(chain)
+
+	saved := make(map[string]ChainInfo)
+	for _, id := range []string{"146", "0x92"} {
+		saved[id] = SupportedChains[id]
+		info := SupportedChains[id]
+		info.RPC = []string{server.URL}
+		SupportedChains[id] = info
+	}
+	rpcClients.close()
+
+	t.Cleanup(func() {
+		rpcClients.close()
+		for id, info := range saved {
+			SupportedChains[id] = info
+		}
+		server.Close()
+	})
+	return chain
+}
+
+type fakeRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type fakeError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type fakeResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *fakeError      `json:"error,omitempty"`
+}
+
+func (c *fakeChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		var requests []fakeRequest
+		json.Unmarshal(body, &requests)
+		responses := make([]fakeResponse, len(requests))
+		for i, request := range requests {
+			responses[i] = c.answer(request)
+		}
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+	var request fakeRequest
+	json.Unmarshal(body, &request)
+	json.NewEncoder(w).Encode(c.answer(request))
+}
+
+func (c *fakeChain) answer(request fakeRequest) fakeResponse {
+	response := fakeResponse{Version: "2.0", ID: request.ID}
+	if handler, ok := c.methods[request.Method]; ok {
+		result, err := handler(request.Params)
+		if err != nil {
+			response.Error = &fakeError{Code: -32000, Message: err.Error()}
+		} else {
+			response.Result = result
+		}
+		return response
+	}
+
+	switch request.Method {
+	case "eth_chainId":
+		response.Result = "0x92"
+	case "eth_blockNumber":
+		response.Result = "0x10"
+	case "eth_call":
+		var msg struct {
+			To    common.Address `json:"to"`
+			Input hexutil.Bytes  `json:"input"`
+			Data  hexutil.Bytes  `json:"data"`
+		}
+		json.Unmarshal(request.Params[0], &msg)
+		if len(msg.Input) == 0 {
+			msg.Input = msg.Data
+		}
+		if multicall, _ := getMulticallAddress("146"); msg.To == multicall && len(request.Params) > 1 {
+			var block string
+			json.Unmarshal(request.Params[1], &block)
+			c.mu.Lock()
+			c.blocks = append(c.blocks, block)
+			c.mu.Unlock()
+		}
+		result, ok := c.contractCall(msg.To, msg.Input)
+		if !ok {
+			response.Error = &fakeError{Code: 3, Message: "execution reverted"}
+			return response
+		}
+		response.Result = hexutil.Encode(result)
+	default:
+		response.Error = &fakeError{Code: -32601, Message: "method not found: " + request.Method}
+	}
+	return response
+}
+
+func (c *fakeChain) contractCall(to common.Address, data []byte) ([]byte, bool) {
+	multicall, _ := getMulticallAddress("146")
+	if to != multicall || methodName(AbiMulticall, data) != "multicallView" {
+		c.mu.Lock()
+		c.sent = append(c.sent, data)
+		c.mu.Unlock()
+		if c.call == nil || len(data) < 4 {
+			return nil, false
+		}
+		return c.call(to, data)
+	}
+
+	method := Abis.MustContract(AbiMulticall).Methods["multicallView"]
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, false
+	}
+	calls := args[0].([]struct {
+		Target   common.Address `json:"target"`
+		CallData []byte         `json:"callData"`
+	})
+	results := make([]struct {
+		Success    bool   `json:"success"`
+		ReturnData []byte `json:"returnData"`
+	}, len(calls))
+	for i, call := range calls {
+		results[i].ReturnData, results[i].Success = c.contractCall(call.Target, call.CallData)
+	}
+	packed, err := method.Outputs.Pack(results)
+	if err != nil {
+		return nil, false
+	}
+	return packed, true
+}
+
+// calls counts the calls sent with the selector of method.
+func (c *fakeChain) calls(contract string, method string) int {
+	id := Abis.MustContract(contract).Methods[method].ID
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := 0
+	for _, data := range c.sent {
+		if len(data) >= 4 && string(data[:4]) == string(id) {
+			count++
+		}
+	}
+	return count
+}
+
+// methodName is the name of the method of contract data calls, if any.
+func methodName(contract string, data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	contractABI := Abis.MustContract(contract)
+	parsed, err := contractABI.MethodById(data[:4])
+	if err != nil {
+		return ""
+	}
+	return parsed.Name
+}
+
+// fakeToken is the staked token of pid in genesisCall.
+func fakeToken(pid int) common.Address {
+	return common.BigToAddress(big.NewInt(int64(0x1000 + pid)))
+}
+
+// genesisCall answers the calls of a genesis with pools pools staking
+// fakeToken(pid), each holding pid + 1 tokens. Every other call reverts.
+func genesisCall(genesis common.Address, pools int) func(common.Address, []byte) ([]byte, bool) {
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	return func(to common.Address, data []byte) ([]byte, bool) {
+		if to != genesis {
+			pid := int(new(big.Int).SetBytes(to.Bytes()).Int64()) - 0x1000
+			if pid < 0 || pid >= pools || methodName(AbiErc20, data) != "balanceOf" {
+				return nil, false
+			}
+			out, _ := parsedErc20ABI.Methods["balanceOf"].Outputs.Pack(big.NewInt(int64(pid + 1)))
+			return out, true
+		}
+
+		var out []byte
+		var err error
+		switch name := methodName(AbiGenesis, data); name {
+		case "poolLength":
+			out, err = parsedGenesisABI.Methods[name].Outputs.Pack(big.NewInt(int64(pools)))
+		case "operator", "devFund":
+			out, err = parsedGenesisABI.Methods[name].Outputs.Pack(common.Address{})
+		case "poolInfo":
+			pid := new(big.Int).SetBytes(data[4:36])
+			gauge := struct {
+				IsGauge      bool
+				Gauge        common.Address
+				RewardTokens []common.Address
+			}{RewardTokens: []common.Address{}}
+			out, err = parsedGenesisABI.Methods[name].Outputs.Pack(fakeToken(int(pid.Int64())), big.NewInt(100), big.NewInt(1), big.NewInt(0), big.NewInt(0), true, gauge, big.NewInt(0))
+		default:
+			return nil, false
+		}
+		return out, err == nil
+	}
+}
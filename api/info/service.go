@@ -1,16 +1,16 @@
 package infoHandler
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"net/http"
-	"strings"
+	"net/url"
 
 	"github.com/FudgyDRS/valhalla-api/pkg/utils"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/sirupsen/logrus"
 	// "github.com/sirupsen/logrus"
 )
 
@@ -21,39 +21,68 @@ func VersionRequest(r *http.Request, parameters ...interface{}) (interface{}, er
 }
 
 func GetGenesisBalances(r *http.Request) (GetGenesisBalancesResponse, error) {
-	// Parse the request parameters into the params struct
-	params, err := parseGenesisParams(r)
+	plan, err := planGenesisBalances(r)
 	if err != nil {
-		return GetGenesisBalancesResponse{}, utils.ErrInternal(err.Error())
+		return GetGenesisBalancesResponse{}, err
 	}
 
-	// Log the parsed params (optional, for debugging purposes)
-	LogGenesisParams(params)
-
-	client, err := GetClientForChain(params.ChainId)
+	response, err := plan.Execute(r.Context())
 	if err != nil {
 		return GetGenesisBalancesResponse{}, err
 	}
-	multicallAddress, err := getMulticallAddress(params.ChainId)
+
+	return response.(GetGenesisBalancesResponse), nil
+}
+
+func planGenesisBalances(r *http.Request) (*MulticallPlan, error) {
+	// Parse the request parameters into the params struct
+	params, err := parseGenesisParams(r)
 	if err != nil {
-		return GetGenesisBalancesResponse{}, err
+		return nil, err
 	}
-	calls := createMulticallParams(params)
-	// logrus.Info("Generated multicall parameters:", calls)
 
-	results, err := MulticallView(client, multicallAddress, calls)
-	if err != nil {
-		return GetGenesisBalancesResponse{}, utils.ErrInternal(fmt.Errorf("multicall view failed: %v", err).Error())
+	// Log the parsed params (optional, for debugging purposes)
+	LogGenesisParams(r.Context(), params)
+
+	if len(params.Pools) == 0 {
+		pools, err := fetchGenesisPools(r.Context(), params.ChainId, params.GenesisAddress)
+		if err != nil {
+			return nil, err
+		}
+		for pid, pool := range pools {
+			params.Pools = append(params.Pools, PoolParams{Address: pool.Token, PoolId: big.NewInt(int64(pid))})
+		}
 	}
 
-	responseData, err := handleMulticallResponse(results, params)
-	if err != nil {
-		return GetGenesisBalancesResponse{}, utils.ErrInternal(fmt.Errorf("failed to parse multicall response: %v", err).Error())
+	calls := createMulticallParams(params)
+
+	// Tokens missing from the cache are read in the same multicall
+	poolTokens := make([]common.Address, len(params.Pools))
+	for i, pool := range params.Pools {
+		poolTokens[i] = pool.Address
 	}
-	logrus.Info("Generated multicall responseData:", responseData)
+	tokens := Tokens.lookup(params.ChainId, poolTokens, false)
+	balanceCalls := len(calls)
+	calls = append(calls, tokens.calls()...)
 
-	return GetGenesisBalancesResponse{
-		Pools: responseData,
+	return &MulticallPlan{
+		ChainId: params.ChainId,
+		Calls:   calls,
+		Decode: func(results []MulticallResult) (interface{}, error) {
+			responseData, err := handleMulticallResponse(results[:balanceCalls], params)
+			if err != nil {
+				return nil, utils.ErrInternal(fmt.Errorf("failed to parse multicall response: %v", err).Error())
+			}
+			metadata := tokens.resolve(results[balanceCalls:])
+			for i, pool := range params.Pools {
+				responseData[i].TokenMetadata = tokenRef(metadata, pool.Address)
+			}
+			utils.Log(r.Context()).WithField("pools", len(responseData)).Debug("decoded genesis balances")
+
+			return GetGenesisBalancesResponse{
+				Pools: responseData,
+			}, nil
+		},
 	}, nil
 }
 
@@ -69,91 +98,68 @@ func GetGenesisBalances(r *http.Request) (GetGenesisBalancesResponse, error) {
 //http://localhost:8080/api/info?query=get-genesis-pair&chain-id=146&genesis=0x23Ee13d49e78811d063722D9228547a7dF73E42E&user=0x04301b0c3bC192C28DD3CAF345C4aE6E979EC040&pid=18&pair=0xAC60849b0456baD97E75E8f84C245Bd9C2Fc9766&quote=0xb1e25689D55734FD3ffFc939c4C3Eb52DFf8A794
 
 func GetGenesisPairBalance(r *http.Request) (GetGenesisPairResponse, error) {
-	params, err := parseGenesisPairParams(r)
-	if err != nil {
-		return GetGenesisPairResponse{}, utils.ErrInternal(err.Error())
-	}
-
-	LogGenesisPairParams(params)
-
-	client, err := GetClientForChain(params.ChainId)
+	plan, err := planGenesisPair(r)
 	if err != nil {
 		return GetGenesisPairResponse{}, err
 	}
-	multicallAddress, err := getMulticallAddress(params.ChainId)
+
+	response, err := plan.Execute(r.Context())
 	if err != nil {
 		return GetGenesisPairResponse{}, err
 	}
-	calls := createMulticallPairParams(params)
 
-	results, err := MulticallView(client, multicallAddress, calls)
-	if err != nil {
-		return GetGenesisPairResponse{}, utils.ErrInternal(fmt.Errorf("multicall view failed: %v", err).Error())
-	}
+	return response.(GetGenesisPairResponse), nil
+}
 
-	responseData, err := handleMulticallPairResponse(results, params)
+func planGenesisPair(r *http.Request) (*MulticallPlan, error) {
+	params, err := parseGenesisPairParams(r)
 	if err != nil {
-		return GetGenesisPairResponse{}, utils.ErrInternal(fmt.Errorf("failed to parse multicall response: %v", err).Error())
+		return nil, err
 	}
-	logrus.Info("Generated multicall responseData:", responseData)
 
-	return responseData, nil
+	LogGenesisPairParams(r.Context(), params)
+
+	calls := createMulticallPairParams(params)
+
+	tokens := Tokens.lookup(params.ChainId, []common.Address{params.PairAddress, params.BaseAddress, params.QuoteAddress}, false)
+	pairCalls := len(calls)
+	calls = append(calls, tokens.calls()...)
+
+	return &MulticallPlan{
+		ChainId: params.ChainId,
+		Calls:   calls,
+		Decode: func(results []MulticallResult) (interface{}, error) {
+			responseData, err := handleMulticallPairResponse(results[:pairCalls], params)
+			if err != nil {
+				return nil, utils.ErrInternal(fmt.Errorf("failed to parse multicall response: %v", err).Error())
+			}
+			metadata := tokens.resolve(results[pairCalls:])
+			responseData.TokenMetadata = tokenRef(metadata, params.PairAddress)
+			responseData.BaseMetadata = tokenRef(metadata, params.BaseAddress)
+			responseData.QuoteMetadata = tokenRef(metadata, params.QuoteAddress)
+			utils.Log(r.Context()).WithField("pair", params.PairAddress.Hex()).Debug("decoded genesis pair")
+
+			return responseData, nil
+		},
+	}, nil
 }
 
 func handleMulticallResponse(results []MulticallResult, params *GetGenesisBalancesParams) ([]GetGenesisBalanceResponse, error) {
 	var responses []GetGenesisBalanceResponse
 
-	logrus.Info("results: ", results)
-
-	parsedErc20ABI, _ := abi.JSON(strings.NewReader(`
-	[{
-		"type": "function",
-		"name": "name",
-		"inputs": [],
-		"outputs": [{"name":"","type":"string","internalType":"string"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "symbol",
-		"inputs": [],
-		"outputs": [{"name":"","type":"string","internalType":"string"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "decimals",
-		"inputs": [],
-		"outputs": [{"name":"","type": "uint8","internalType":"uint8"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "totalSupply",
-		"inputs": [],
-		"outputs": [{"name":"","type": "uint256","internalType":"uint256"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "balanceOf",
-		"inputs": [{"name":"account","type": "address","internalType":"address"}],
-		"outputs": [{"name":"","type": "uint256","internalType":"uint256"}],
-		"stateMutability":"view"
-	}]`,
-	))
-	parsedGenesisABI, _ := abi.JSON(strings.NewReader(contractAbiGenesis))
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
 
 	var resultIndex = 0
 	for _, pool := range params.Pools {
 		// Create a new response for each pool
 		response := GetGenesisBalanceResponse{
-			Token:          pool.Address, // Will fill it from the ABI unpack
-			PoolId:         pool.PoolId,  // Will fill it from the ABI unpack
-			GenesisBalance: "null",       // Default value, will change if valid
-			UserBalance:    "null",       // Default value, will change if valid
-			UserStake:      "null",       // Default value, will change if valid
-			UserReward:     "null",       // Default value, will change if valid
+			Token:          pool.Address.Hex(),
+			PoolId:         pool.PoolId.String(),
+			GenesisBalance: "null", // Default value, will change if valid
+			UserBalance:    "null", // Default value, will change if valid
+			UserStake:      "null", // Default value, will change if valid
+			UserReward:     "null", // Default value, will change if valid
 		}
 
 		genesisBalance, err := parsedErc20ABI.Unpack("balanceOf", results[resultIndex].ReturnData)
@@ -163,7 +169,7 @@ func handleMulticallResponse(results []MulticallResult, params *GetGenesisBalanc
 		response.GenesisBalance = genesisBalance[0].(*big.Int).String()
 		resultIndex += 1
 
-		if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+		if params.UserAddress != (common.Address{}) {
 			userBalance, err := parsedErc20ABI.Unpack("balanceOf", results[resultIndex].ReturnData)
 			if err != nil {
 				return nil, fmt.Errorf("failed to unpack balanceOf for user: %v", err)
@@ -173,7 +179,7 @@ func handleMulticallResponse(results []MulticallResult, params *GetGenesisBalanc
 		}
 
 		// Parse userInfo result (skip if user address is address(0))
-		if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+		if params.UserAddress != (common.Address{}) {
 			userInfoData, err := parsedGenesisABI.Unpack("userInfo", results[resultIndex].ReturnData)
 			if err != nil {
 				return nil, fmt.Errorf("failed to unpack userInfo: %v", err)
@@ -184,7 +190,7 @@ func handleMulticallResponse(results []MulticallResult, params *GetGenesisBalanc
 			resultIndex += 1
 		}
 
-		if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+		if params.UserAddress != (common.Address{}) {
 			userInfoData, err := parsedGenesisABI.Unpack("pendingVAL", results[resultIndex].ReturnData)
 			if err != nil {
 				return nil, fmt.Errorf("failed to unpack userInfo: %v", err)
@@ -193,8 +199,6 @@ func handleMulticallResponse(results []MulticallResult, params *GetGenesisBalanc
 			response.UserReward = userInfoData[0].(*big.Int).String()
 			resultIndex += 1
 		}
-		logrus.Info(response)
-
 		responses = append(responses, response)
 	}
 
@@ -203,44 +207,8 @@ func handleMulticallResponse(results []MulticallResult, params *GetGenesisBalanc
 
 func createMulticallParams(params *GetGenesisBalancesParams) []Calls {
 	var calls []Calls
-	parsedErc20ABI, _ := abi.JSON(strings.NewReader(`
-	[{
-		"type": "function",
-		"name": "name",
-		"inputs": [],
-		"outputs": [{"name":"","type":"string","internalType":"string"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "symbol",
-		"inputs": [],
-		"outputs": [{"name":"","type":"string","internalType":"string"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "decimals",
-		"inputs": [],
-		"outputs": [{"name":"","type": "uint8","internalType":"uint8"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "totalSupply",
-		"inputs": [],
-		"outputs": [{"name":"","type": "uint256","internalType":"uint256"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "balanceOf",
-		"inputs": [{"name":"account","type": "address","internalType":"address"}],
-		"outputs": [{"name":"","type": "uint256","internalType":"uint256"}],
-		"stateMutability":"view"
-	}]`,
-	))
-	parsedGenesisABI, _ := abi.JSON(strings.NewReader(contractAbiGenesis))
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
 
 	// Helper function to append calls, skipping user-related ones when user address is address(0)
 	addCall := func(contractAddress common.Address, abi abi.ABI, method string, params interface{}) {
@@ -255,26 +223,22 @@ func createMulticallParams(params *GetGenesisBalancesParams) []Calls {
 	// Create calls for each pool dynamically
 	for _, pool := range params.Pools {
 		// Pool-related calls (balanceOf)
-		poolAddress := common.HexToAddress(pool.Address)
-		genesisAddress := common.HexToAddress(params.GenesisAddress)
-		userAddress := common.HexToAddress(params.UserAddress)
-		poolId := new(big.Int)
-		_, success := poolId.SetString(pool.PoolId, 10) // Base 10 for decimal numbers
-		if !success {
-			return nil
-		}
+		poolAddress := pool.Address
+		genesisAddress := params.GenesisAddress
+		userAddress := params.UserAddress
+		poolId := pool.PoolId
 
 		addCall(poolAddress, parsedErc20ABI, "balanceOf", genesisAddress)
 
-		if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+		if params.UserAddress != (common.Address{}) {
 			addCall(poolAddress, parsedErc20ABI, "balanceOf", userAddress)
 		}
 
-		if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+		if params.UserAddress != (common.Address{}) {
 			addCall(genesisAddress, parsedGenesisABI, "userInfo", []interface{}{poolId, userAddress})
 		}
 
-		if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+		if params.UserAddress != (common.Address{}) {
 			addCall(genesisAddress, parsedGenesisABI, "pendingVAL", []interface{}{poolId, userAddress})
 		}
 	}
@@ -282,17 +246,16 @@ func createMulticallParams(params *GetGenesisBalancesParams) []Calls {
 	return calls
 }
 
-func processMulticallAndParse(client *ethclient.Client, multicallAddress common.Address, params *GetGenesisBalancesParams) (*GetGenesisBalancesResponse, error) {
+func processMulticallAndParse(ctx context.Context, client *ethclient.Client, multicallAddress common.Address, params *GetGenesisBalancesParams) (*GetGenesisBalancesResponse, error) {
 	// Generate the multicall parameters based on the input
 	calls := createMulticallParams(params)
 
 	// Execute the multicall
-	results, err := MulticallView(client, multicallAddress, calls)
+	results, err := MulticallView(ctx, client, multicallAddress, calls)
 	if err != nil {
-		return nil, fmt.Errorf("multicall view failed: %v", err)
+		return nil, fmt.Errorf("multicall view failed: %w", err)
 	}
 
-	logrus.Info("just before handleMulticallResponse")
 	// Parse the results
 	responses, err := handleMulticallResponse(results, params)
 	if err != nil {
@@ -308,52 +271,14 @@ func processMulticallAndParse(client *ethclient.Client, multicallAddress common.
 func handleMulticallPairResponse(results []MulticallResult, params *GetGenesisPairParams) (GetGenesisPairResponse, error) {
 	// var response GetGenesisBalanceResponse
 
-	logrus.Info("results: ", results)
-
-	parsedErc20ABI, _ := abi.JSON(strings.NewReader(`
-	[{
-		"type": "function",
-		"name": "name",
-		"inputs": [],
-		"outputs": [{"name":"","type":"string","internalType":"string"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "symbol",
-		"inputs": [],
-		"outputs": [{"name":"","type":"string","internalType":"string"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "decimals",
-		"inputs": [],
-		"outputs": [{"name":"","type": "uint8","internalType":"uint8"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "totalSupply",
-		"inputs": [],
-		"outputs": [{"name":"","type": "uint256","internalType":"uint256"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "balanceOf",
-		"inputs": [{"name":"account","type": "address","internalType":"address"}],
-		"outputs": [{"name":"","type": "uint256","internalType":"uint256"}],
-		"stateMutability":"view"
-	}]`,
-	))
-	parsedGenesisABI, _ := abi.JSON(strings.NewReader(contractAbiGenesis))
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
 
 	var resultIndex = 0
 	response := GetGenesisPairResponse{
-		PairAddress:      params.PairAddress,
+		PairAddress:      params.PairAddress.Hex(),
 		PairTotalSupply:  "null",
-		PoolId:           params.PoolId,
+		PoolId:           params.PoolId.String(),
 		BaseBalance:      "null",
 		QuoteBalance:     "null",
 		GenesisBalance:   "null",
@@ -392,7 +317,7 @@ func handleMulticallPairResponse(results []MulticallResult, params *GetGenesisPa
 	response.GenesisBalance = genesisBalance[0].(*big.Int).String()
 	resultIndex += 1
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		userBalance, err := parsedErc20ABI.Unpack("balanceOf", results[resultIndex].ReturnData)
 		if err != nil {
 			return GetGenesisPairResponse{}, fmt.Errorf("failed to unpack balanceOf for userBalance: %v", err)
@@ -402,7 +327,7 @@ func handleMulticallPairResponse(results []MulticallResult, params *GetGenesisPa
 	}
 
 	// Parse userInfo result (skip if user address is address(0))
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		userInfoData, err := parsedGenesisABI.Unpack("userInfo", results[resultIndex].ReturnData)
 		if err != nil {
 			return GetGenesisPairResponse{}, fmt.Errorf("failed to unpack userInfo: %v", err)
@@ -413,7 +338,7 @@ func handleMulticallPairResponse(results []MulticallResult, params *GetGenesisPa
 		resultIndex += 1
 	}
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		userInfoData, err := parsedGenesisABI.Unpack("pendingVAL", results[resultIndex].ReturnData)
 		if err != nil {
 			return GetGenesisPairResponse{}, fmt.Errorf("failed to unpack userInfo: %v", err)
@@ -422,7 +347,7 @@ func handleMulticallPairResponse(results []MulticallResult, params *GetGenesisPa
 		resultIndex += 1
 	}
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		userBaseBalance, err := parsedErc20ABI.Unpack("balanceOf", results[resultIndex].ReturnData)
 		if err != nil {
 			return GetGenesisPairResponse{}, fmt.Errorf("failed to unpack balanceOf for userBalance: %v", err)
@@ -431,7 +356,7 @@ func handleMulticallPairResponse(results []MulticallResult, params *GetGenesisPa
 		resultIndex += 1
 	}
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		userQuoteBalance, err := parsedErc20ABI.Unpack("balanceOf", results[resultIndex].ReturnData)
 		if err != nil {
 			return GetGenesisPairResponse{}, fmt.Errorf("failed to unpack balanceOf for userBalance: %v", err)
@@ -440,51 +365,13 @@ func handleMulticallPairResponse(results []MulticallResult, params *GetGenesisPa
 		resultIndex += 1
 	}
 
-	logrus.Info(response)
-
 	return response, nil
 }
 
 func createMulticallPairParams(params *GetGenesisPairParams) []Calls {
 	var calls []Calls
-	parsedErc20ABI, _ := abi.JSON(strings.NewReader(`
-	[{
-		"type": "function",
-		"name": "name",
-		"inputs": [],
-		"outputs": [{"name":"","type":"string","internalType":"string"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "symbol",
-		"inputs": [],
-		"outputs": [{"name":"","type":"string","internalType":"string"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "decimals",
-		"inputs": [],
-		"outputs": [{"name":"","type": "uint8","internalType":"uint8"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "totalSupply",
-		"inputs": [],
-		"outputs": [{"name":"","type": "uint256","internalType":"uint256"}],
-		"stateMutability":"view"
-	},
-	{
-		"type": "function",
-		"name": "balanceOf",
-		"inputs": [{"name":"account","type": "address","internalType":"address"}],
-		"outputs": [{"name":"","type": "uint256","internalType":"uint256"}],
-		"stateMutability":"view"
-	}]`,
-	))
-	parsedGenesisABI, _ := abi.JSON(strings.NewReader(contractAbiGenesis))
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	parsedGenesisABI := Abis.MustContract(AbiGenesis)
 
 	addCall := func(contractAddress common.Address, abi abi.ABI, method string, params interface{}) {
 		calls = append(calls, Calls{
@@ -495,56 +382,51 @@ func createMulticallPairParams(params *GetGenesisPairParams) []Calls {
 		})
 	}
 
-	genesisAddress := common.HexToAddress(params.GenesisAddress)
-	pairAddress := common.HexToAddress(params.PairAddress)
-	baseAddress := common.HexToAddress(params.BaseAddress)
-	quoteAddress := common.HexToAddress(params.QuoteAddress)
-	userAddress := common.HexToAddress(params.UserAddress)
-	poolId := new(big.Int)
-	_, success := poolId.SetString(params.PoolId, 10) // Base 10 for decimal numbers
-	if !success {
-		return nil
-	}
+	genesisAddress := params.GenesisAddress
+	pairAddress := params.PairAddress
+	baseAddress := params.BaseAddress
+	quoteAddress := params.QuoteAddress
+	userAddress := params.UserAddress
+	poolId := params.PoolId
 
 	addCall(pairAddress, parsedErc20ABI, "totalSupply", nil)
 	addCall(baseAddress, parsedErc20ABI, "balanceOf", pairAddress)
 	addCall(quoteAddress, parsedErc20ABI, "balanceOf", pairAddress)
 	addCall(pairAddress, parsedErc20ABI, "balanceOf", genesisAddress)
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		addCall(pairAddress, parsedErc20ABI, "balanceOf", userAddress)
 	}
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		addCall(genesisAddress, parsedGenesisABI, "userInfo", []interface{}{poolId, userAddress})
 	}
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		addCall(genesisAddress, parsedGenesisABI, "pendingVAL", []interface{}{poolId, userAddress})
 	}
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		addCall(baseAddress, parsedErc20ABI, "balanceOf", userAddress)
 	}
 
-	if params.UserAddress != "0x0000000000000000000000000000000000000000" {
+	if params.UserAddress != (common.Address{}) {
 		addCall(quoteAddress, parsedErc20ABI, "balanceOf", userAddress)
 	}
 
 	return calls
 }
 
-func processMulticallPairAndParse(client *ethclient.Client, multicallAddress common.Address, params *GetGenesisPairParams) (*GetGenesisPairResponse, error) {
+func processMulticallPairAndParse(ctx context.Context, client *ethclient.Client, multicallAddress common.Address, params *GetGenesisPairParams) (*GetGenesisPairResponse, error) {
 	// Generate the multicall parameters based on the input
 	calls := createMulticallPairParams(params)
 
 	// Execute the multicall
-	results, err := MulticallView(client, multicallAddress, calls)
+	results, err := MulticallView(ctx, client, multicallAddress, calls)
 	if err != nil {
-		return nil, fmt.Errorf("multicall view failed: %v", err)
+		return nil, fmt.Errorf("multicall view failed: %w", err)
 	}
 
-	logrus.Info("just before handleMulticallResponse")
 	// Parse the results
 	responses, err := handleMulticallPairResponse(results, params)
 	if err != nil {
@@ -554,3 +436,165 @@ func processMulticallPairAndParse(client *ethclient.Client, multicallAddress com
 	// Return the structured response
 	return &responses, nil
 }
+
+const defaultTwapGranularity = 4
+
+// GetPairTwap quotes amount-in of token-in through the pair's average price
+// over its last granularity observations, and through its current reserves.
+// token-in defaults to token0 and amount-in to one whole token. values are
+// the fields of GetPairTwapParams. It is not served over HTTP, the valhalla
+// CLI calls it, under the timeout of QUERY_TIMEOUT_GET_PAIR_TWAP or
+// QUERY_TIMEOUT.
+func GetPairTwap(ctx context.Context, values url.Values) (GetPairTwapResponse, error) {
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, "/?"+values.Encode(), nil)
+	if err != nil {
+		return GetPairTwapResponse{}, utils.ErrInternal(err.Error())
+	}
+	params, err := parsePairTwapParams(r)
+	if err != nil {
+		return GetPairTwapResponse{}, err
+	}
+
+	LogPairTwapParams(ctx, params)
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout("get-pair-twap"))
+	defer cancel()
+
+	token0, token1, err := fetchPairTokens(ctx, params.ChainId, params.PairAddress)
+	if err != nil {
+		return GetPairTwapResponse{}, err
+	}
+	tokenIn, tokenOut := token0, token1
+	switch params.TokenIn {
+	case common.Address{}, token0:
+	case token1:
+		tokenIn, tokenOut = token1, token0
+	default:
+		return GetPairTwapResponse{}, utils.ErrMalformedRequest(fmt.Sprintf("token-in %s is not a token of pair %s", params.TokenIn.Hex(), params.PairAddress.Hex()))
+	}
+
+	client, err := GetClientForChain(ctx, params.ChainId)
+	if err != nil {
+		return GetPairTwapResponse{}, err
+	}
+	multicallAddress, err := getMulticallAddress(params.ChainId)
+	if err != nil {
+		return GetPairTwapResponse{}, err
+	}
+
+	metadata, err := ResolveTokens(ctx, params.ChainId, []common.Address{tokenIn, tokenOut}, false)
+	if err != nil {
+		return GetPairTwapResponse{}, fmt.Errorf("failed to read token metadata: %w", err)
+	}
+
+	amountIn := params.AmountIn
+	if amountIn == nil {
+		decimals := metadata[tokenIn].Decimals
+		if decimals == nil {
+			return GetPairTwapResponse{}, utils.ErrMalformedRequest("token has no decimals, pass amount-in")
+		}
+		amountIn = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(*decimals)), nil)
+	}
+
+	parsedPairABI := Abis.MustContract(AbiPair)
+	results, err := MulticallView(ctx, client, multicallAddress, []Calls{
+		{contractAddress: params.PairAddress, abi: parsedPairABI, method: "quote", params: []interface{}{tokenIn, amountIn, params.Granularity}},
+		{contractAddress: params.PairAddress, abi: parsedPairABI, method: "getAmountOut", params: []interface{}{amountIn, tokenIn}},
+	})
+	if err != nil {
+		return GetPairTwapResponse{}, fmt.Errorf("failed to quote pair: %w", err)
+	}
+	if len(results) != 2 {
+		return GetPairTwapResponse{}, fmt.Errorf("expected 2 multicall results, got %d", len(results))
+	}
+	if !results[0].Success {
+		return GetPairTwapResponse{}, utils.ErrCallReverted(fmt.Errorf("quote reverted, the pair may have fewer than %s observations", params.Granularity))
+	}
+	twap, err := unpackUint(parsedPairABI.Unpack("quote", results[0].ReturnData))
+	if err != nil {
+		return GetPairTwapResponse{}, utils.ErrInternal(fmt.Sprintf("failed to unpack quote: %v", err))
+	}
+
+	response := GetPairTwapResponse{
+		Pair:             params.PairAddress.Hex(),
+		TokenIn:          tokenIn.Hex(),
+		TokenOut:         tokenOut.Hex(),
+		AmountIn:         amountIn.String(),
+		Granularity:      params.Granularity.String(),
+		TwapAmountOut:    twap.String(),
+		TokenInMetadata:  tokenRef(metadata, tokenIn),
+		TokenOutMetadata: tokenRef(metadata, tokenOut),
+	}
+	if results[1].Success {
+		if spot, err := unpackUint(parsedPairABI.Unpack("getAmountOut", results[1].ReturnData)); err == nil {
+			response.SpotAmountOut = spot.String()
+		}
+	}
+	return response, nil
+}
+
+//http://localhost:8080/api/info?query=call&chain-id=146&contract=0xAC60849b0456baD97E75E8f84C245Bd9C2Fc9766&method=balanceOf(address)&params.value=0x04301b0c3bC192C28DD3CAF345C4aE6E979EC040&returns=uint256
+
+func ContractCallRequest(r *http.Request) (ContractCallResponse, error) {
+	params, err := parseContractCallParams(r)
+	if err != nil {
+		return ContractCallResponse{}, err
+	}
+
+	LogContractCallParams(r.Context(), params)
+
+	var outputs abi.Arguments
+	for _, ret := range params.Returns {
+		abiType, err := ParseAbiType(ret)
+		if err != nil {
+			return ContractCallResponse{}, utils.ErrMalformedRequest(fmt.Sprintf("invalid return type %s: %v", ret, err))
+		}
+		outputs = append(outputs, abi.Argument{Type: abiType})
+	}
+
+	callData, err := ConstructCallData(params.Method, params.Params)
+	if err != nil {
+		return ContractCallResponse{}, utils.ErrMalformedRequest(err.Error())
+	}
+
+	client, err := GetClientForChain(r.Context(), params.ChainId)
+	if err != nil {
+		return ContractCallResponse{}, err
+	}
+
+	contractAddress := params.ContractAddress
+	returnData, err := CallContract(r.Context(), client, contractAddress, callData)
+	if err != nil {
+		return ContractCallResponse{}, err
+	}
+
+	// Fall back to the registry when no return types were given
+	if len(outputs) == 0 {
+		if refs := Abis.MethodsBySelector(callData); len(refs) > 0 {
+			outputs = refs[0].Method.Outputs
+		}
+	}
+
+	response := ContractCallResponse{
+		Contract:   contractAddress.Hex(),
+		Signature:  createMethodSignature(params.Method, params.Params),
+		Selector:   utils.ToHexBytes(callData[:4]),
+		CallData:   utils.ToHexBytes(callData),
+		ReturnData: utils.ToHexBytes(returnData),
+	}
+
+	if len(outputs) > 0 {
+		decoded, err := outputs.Unpack(returnData)
+		if err != nil {
+			return ContractCallResponse{}, utils.ErrInternal(fmt.Errorf("failed to decode return data: %v", err).Error())
+		}
+		for i, value := range decoded {
+			response.Outputs = append(response.Outputs, ContractCallOutput{
+				Type:  outputs[i].Type.String(),
+				Value: FormatAbiValue(value),
+			})
+		}
+	}
+
+	return response, nil
+}
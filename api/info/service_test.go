@@ -0,0 +1,121 @@
+package infoHandler
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestContractCallSendsReportedCallData(t *testing.T) {
+	chain := newFakeChain(t)
+	chain.call = func(to common.Address, data []byte) ([]byte, bool) {
+		return common.LeftPadBytes([]byte{42}, 32), true
+	}
+
+	values := url.Values{
+		"chain-id":     {"146"},
+		"contract":     {"0xAC60849b0456baD97E75E8f84C245Bd9C2Fc9766"},
+		"method":       {"balanceOf(address)"},
+		"params.value": {"0x04301b0c3bC192C28DD3CAF345C4aE6E979EC040"},
+		"returns":      {"uint256"},
+	}
+	response, err := Query(context.Background(), "call", values)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	call := response.(ContractCallResponse)
+
+	if len(chain.sent) != 1 {
+		t.Fatalf("sent %d calls, want 1", len(chain.sent))
+	}
+	reported, err := hexutil.Decode(call.CallData)
+	if err != nil {
+		t.Fatalf("invalid call-data %q: %v", call.CallData, err)
+	}
+	if !bytes.Equal(chain.sent[0], reported) {
+		t.Errorf("sent %x, response reports %x", chain.sent[0], reported)
+	}
+	if len(call.Outputs) != 1 || call.Outputs[0].Value != "42" {
+		t.Errorf("outputs = %+v, want 42", call.Outputs)
+	}
+}
+
+func TestGetPairTwap(t *testing.T) {
+	useTokenRegistry(t, maxCachedTokens)
+	pair := common.HexToAddress("0xAC60849b0456baD97E75E8f84C245Bd9C2Fc9766")
+	parsedPairABI := Abis.MustContract(AbiPair)
+	var nameOk atomic.Bool
+	nameOk.Store(true)
+	tokens := tokenCall(&nameOk)
+	var noDecimals atomic.Bool
+
+	chain := newFakeChain(t)
+	chain.call = func(to common.Address, data []byte) ([]byte, bool) {
+		if noDecimals.Load() && to == fakeToken(0) && methodName(AbiErc20, data) == "decimals" {
+			return nil, false
+		}
+		if to != pair {
+			return tokens(to, data)
+		}
+		var out []byte
+		var err error
+		switch name := methodName(AbiPair, data); name {
+		case "token0":
+			out, err = parsedPairABI.Methods[name].Outputs.Pack(fakeToken(0))
+		case "token1":
+			out, err = parsedPairABI.Methods[name].Outputs.Pack(fakeToken(1))
+		case "quote", "getAmountOut":
+			args, _ := parsedPairABI.Methods[name].Inputs.Unpack(data[4:])
+			// quote(tokenIn, amountIn, granularity), getAmountOut(amountIn, tokenIn)
+			amountIn, ok := args[0].(*big.Int)
+			if !ok {
+				amountIn = args[1].(*big.Int)
+			}
+			price := map[string]int64{"quote": 5, "getAmountOut": 12}[name]
+			out, err = parsedPairABI.Methods[name].Outputs.Pack(new(big.Int).Mul(amountIn, big.NewInt(price)))
+		default:
+			return nil, false
+		}
+		return out, err == nil
+	}
+
+	// Without decimals the amount of one token is unknown
+	noDecimals.Store(true)
+	_, err := GetPairTwap(context.Background(), url.Values{"chain-id": {"146"}, "pair": {pair.Hex()}, "token-in": {fakeToken(0).Hex()}})
+	if apiErr := utils.AsError(err); apiErr.Type != utils.CodeMalformedRequest || !strings.Contains(apiErr.Details, "pass amount-in") {
+		t.Errorf("token without decimals: %v, want MALFORMED_REQUEST", err)
+	}
+	noDecimals.Store(false)
+
+	twap, err := GetPairTwap(context.Background(), url.Values{"chain-id": {"146"}, "pair": {pair.Hex()}, "token-in": {fakeToken(1).Hex()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if twap.TokenOut != fakeToken(0).Hex() || twap.AmountIn != "1000000000000000000" || twap.TwapAmountOut != "5000000000000000000" || twap.SpotAmountOut != "12000000000000000000" {
+		t.Errorf("twap = %+v", twap)
+	}
+
+	if _, err := GetPairTwap(context.Background(), url.Values{"chain-id": {"146"}, "pair": {pair.Hex()}, "granularity": {"0x4"}}); err == nil {
+		t.Error("hex granularity accepted")
+	}
+}
+
+// The twap quote is for the CLI only.
+func TestPairTwapIsNotServed(t *testing.T) {
+	if _, ok := queryRoutes["get-pair-twap"]; ok {
+		t.Error("get-pair-twap is a query")
+	}
+	for _, route := range restRoutes {
+		if strings.HasSuffix(route.Pattern, "/twap") {
+			t.Errorf("%s is served", route.Pattern)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package infoHandler
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []func(ctx context.Context) error
+	drainHooks    []func()
+)
+
+// OnDrain registers fn to end long-lived requests once the server stopped
+// accepting connections, so draining does not wait for them.
+func OnDrain(fn func()) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	drainHooks = append(drainHooks, fn)
+}
+
+// Drain runs the hooks registered with OnDrain.
+func Drain() {
+	shutdownMu.Lock()
+	hooks := drainHooks
+	drainHooks = nil
+	shutdownMu.Unlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+// OnShutdown registers fn to release a resource (client pools, background
+// workers) once the server stopped serving requests.
+func OnShutdown(fn func(ctx context.Context) error) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, fn)
+}
+
+// Shutdown runs the registered hooks, last registered first, and returns
+// every error they reported. Call it after the HTTP server drained.
+func Shutdown(ctx context.Context) error {
+	shutdownMu.Lock()
+	hooks := shutdownHooks
+	shutdownHooks = nil
+	shutdownMu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
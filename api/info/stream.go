@@ -0,0 +1,397 @@
+package infoHandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/ratelimit"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	streamPath                 = "/api/stream"
+	defaultStreamPollInterval  = time.Second
+	defaultStreamKeepAlive     = 15 * time.Second
+	defaultStreamMaxSubscriber = 1000
+	defaultStreamMaxPerClient  = 10
+)
+
+//http://localhost:8080/api/stream?query=get-genesis-balances&chain-id=146&genesis=0x23Ee13d49e78811d063722D9228547a7dF73E42E&pools.address=0x...&pools.pid=0&user=0x...
+
+// HandleStream serves Server-Sent Events for any query of multicallPlanners,
+// with the same params as the query. The current result is sent on connect,
+// then an "update" event whenever a new block changes it. Subscriptions with
+// the same query and params share a topic, and every topic of a chain is read
+// in one multicall per block.
+func HandleStream(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	planner, ok := multicallPlanners[query]
+	if !ok {
+		HandleResponse(w, r, nil, utils.ErrMalformedRequest(fmt.Sprintf("query %q cannot be streamed, use get-genesis-balances or get-genesis-pair", query)))
+		return
+	}
+	plan, err := planner(r)
+	if err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+	if err := limitRequest(w, r, queryCost(query, r.URL.Query())); err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+
+	// Streams outlive the server's write timeout
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+		HandleResponse(w, r, nil, utils.ErrInternal(err.Error()))
+		return
+	}
+
+	var owner string
+	if client, ok := r.Context().Value(clientKey{}).(ratelimit.Client); ok {
+		owner = client.Bucket()
+	}
+	subscriber, initial, err := streams.subscribe(r.Context(), owner, query, r.URL.Query(), plan)
+	if err != nil {
+		HandleResponse(w, r, nil, err)
+		return
+	}
+	defer streams.unsubscribe(subscriber)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeStreamEvent(w, *initial); err != nil {
+		return
+	}
+	controller.Flush()
+
+	keepAlive := time.NewTicker(envDuration("STREAM_KEEPALIVE", defaultStreamKeepAlive))
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-subscriber.events:
+			if !ok {
+				return
+			}
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, event StreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	name := "update"
+	if event.Error != nil {
+		name = "error"
+	}
+	if event.Block > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", event.Block); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+	return err
+}
+
+type streamSubscriber struct {
+	topic  *streamTopic
+	owner  string
+	events chan StreamEvent
+}
+
+// send keeps only the newest event for a subscriber that is not keeping up,
+// older results are stale anyway.
+func (s *streamSubscriber) send(event StreamEvent) {
+	select {
+	case s.events <- event:
+		return
+	default:
+	}
+	select {
+	case <-s.events:
+	default:
+	}
+	select {
+	case s.events <- event:
+	default:
+	}
+}
+
+type streamTopic struct {
+	key         string
+	query       string
+	plan        *MulticallPlan
+	watcher     *chainWatcher
+	subscribers map[*streamSubscriber]struct{}
+	last        []byte
+}
+
+// chainWatcher polls the latest block of a chain while it has topics.
+type chainWatcher struct {
+	chainId string
+	topics  map[string]*streamTopic
+	cancel  context.CancelFunc
+}
+
+type streamHub struct {
+	mu          sync.Mutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+	draining    bool
+	watchers    map[string]*chainWatcher
+	subscribers int
+	// owners counts the open streams of each rate limit bucket
+	owners map[string]int
+}
+
+var streams = newStreamHub()
+
+func newStreamHub() *streamHub {
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := &streamHub{ctx: ctx, cancel: cancel, watchers: make(map[string]*chainWatcher), owners: make(map[string]int)}
+	OnDrain(hub.close)
+	return hub
+}
+
+// subscribe joins or opens the topic for query and values and returns the
+// latest result to send first, read now if the topic is new. owner is the
+// rate limit bucket of the client, which may hold STREAM_MAX_PER_CLIENT
+// streams so that one client cannot take every STREAM_MAX_SUBSCRIBERS slot.
+func (hub *streamHub) subscribe(ctx context.Context, owner string, query string, values url.Values, plan *MulticallPlan) (*streamSubscriber, *StreamEvent, error) {
+	chainId := canonicalChainId(plan.ChainId)
+	values.Del("query")
+	values.Set("chain-id", chainId)
+	key := query + "?" + values.Encode()
+
+	hub.mu.Lock()
+	if hub.draining {
+		hub.mu.Unlock()
+		return nil, nil, utils.NewError(utils.CodeRpcUnavailable, "server is shutting down", nil)
+	}
+	if hub.subscribers >= streamMaxSubscribers() {
+		hub.mu.Unlock()
+		return nil, nil, utils.ErrRateLimited("too many open streams, retry later")
+	}
+	if hub.owners[owner] >= envInt("STREAM_MAX_PER_CLIENT", defaultStreamMaxPerClient) {
+		hub.mu.Unlock()
+		return nil, nil, utils.ErrRateLimited(fmt.Sprintf("%d streams already open, close one first", hub.owners[owner]))
+	}
+
+	watcher, ok := hub.watchers[chainId]
+	if !ok {
+		watcherCtx, cancel := context.WithCancel(hub.ctx)
+		watcher = &chainWatcher{chainId: chainId, topics: make(map[string]*streamTopic), cancel: cancel}
+		hub.watchers[chainId] = watcher
+		go hub.watch(watcherCtx, watcher)
+	}
+	topic, ok := watcher.topics[key]
+	if !ok {
+		topic = &streamTopic{key: key, query: query, plan: plan, watcher: watcher, subscribers: make(map[*streamSubscriber]struct{})}
+		watcher.topics[key] = topic
+	}
+
+	subscriber := &streamSubscriber{topic: topic, owner: owner, events: make(chan StreamEvent, 1)}
+	topic.subscribers[subscriber] = struct{}{}
+	hub.subscribers++
+	hub.owners[owner]++
+	metrics.SetStreamSubscribers(hub.subscribers)
+	last := topic.last
+	hub.mu.Unlock()
+
+	if last != nil {
+		return subscriber, &StreamEvent{Query: query, Result: json.RawMessage(last)}, nil
+	}
+
+	// A new topic is read once now rather than waiting for the next block
+	queryCtx, cancel := context.WithTimeout(ctx, QueryTimeout(query))
+	defer cancel()
+	result, err := plan.Execute(queryCtx)
+	if err != nil {
+		hub.unsubscribe(subscriber)
+		return nil, nil, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		hub.unsubscribe(subscriber)
+		return nil, nil, utils.ErrInternal(err.Error())
+	}
+
+	// The next block only pushes what changed since this snapshot
+	hub.mu.Lock()
+	if topic.last == nil {
+		topic.last = data
+	}
+	hub.mu.Unlock()
+	return subscriber, &StreamEvent{Query: query, Result: json.RawMessage(data)}, nil
+}
+
+func (hub *streamHub) unsubscribe(subscriber *streamSubscriber) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	topic := subscriber.topic
+	if _, ok := topic.subscribers[subscriber]; !ok {
+		return
+	}
+	delete(topic.subscribers, subscriber)
+	hub.subscribers--
+	hub.release(subscriber.owner)
+	metrics.SetStreamSubscribers(hub.subscribers)
+
+	if len(topic.subscribers) > 0 {
+		return
+	}
+	watcher := topic.watcher
+	delete(watcher.topics, topic.key)
+	if len(watcher.topics) == 0 {
+		watcher.cancel()
+		delete(hub.watchers, watcher.chainId)
+	}
+}
+
+// close ends every stream and stops the watchers, new streams are refused.
+func (hub *streamHub) close() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	hub.draining = true
+	hub.cancel()
+	for _, watcher := range hub.watchers {
+		for _, topic := range watcher.topics {
+			for subscriber := range topic.subscribers {
+				close(subscriber.events)
+				delete(topic.subscribers, subscriber)
+				hub.subscribers--
+				hub.release(subscriber.owner)
+			}
+		}
+	}
+	hub.watchers = make(map[string]*chainWatcher)
+	metrics.SetStreamSubscribers(hub.subscribers)
+}
+
+// release frees a stream of owner, hub.mu is held.
+func (hub *streamHub) release(owner string) {
+	if hub.owners[owner]--; hub.owners[owner] <= 0 {
+		delete(hub.owners, owner)
+	}
+}
+
+func (hub *streamHub) watch(ctx context.Context, watcher *chainWatcher) {
+	ticker := time.NewTicker(envDuration("STREAM_POLL_INTERVAL", defaultStreamPollInterval))
+	defer ticker.Stop()
+
+	var lastBlock uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		block, err := latestBlock(ctx, watcher.chainId)
+		if err != nil {
+			logrus.WithError(err).WithField("chain_id", watcher.chainId).Debug("stream block poll failed")
+			continue
+		}
+		if block <= lastBlock {
+			continue
+		}
+		lastBlock = block
+		hub.update(ctx, watcher, block)
+	}
+}
+
+func latestBlock(ctx context.Context, chainId string) (uint64, error) {
+	client, err := GetClientForChain(ctx, chainId)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := rpcAttemptContext(ctx)
+	defer cancel()
+	return client.BlockNumber(ctx)
+}
+
+// update reads every topic of the chain in one multicall and pushes the
+// results that changed since the previous block.
+func (hub *streamHub) update(ctx context.Context, watcher *chainWatcher, block uint64) {
+	hub.mu.Lock()
+	topics := make([]*streamTopic, 0, len(watcher.topics))
+	plans := make([]*MulticallPlan, 0, len(watcher.topics))
+	for _, topic := range watcher.topics {
+		topics = append(topics, topic)
+		plans = append(plans, topic.plan)
+	}
+	hub.mu.Unlock()
+	if len(plans) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, QueryTimeout("stream"))
+	defer cancel()
+	results, errs := ExecuteMulticallPlans(ctx, plans)
+
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	for i, topic := range topics {
+		event := StreamEvent{Block: block, Query: topic.query}
+		if errs[i] != nil {
+			apiErr := utils.AsError(errs[i])
+			event.Error = &apiErr
+			// Forget the result so the next success is pushed again
+			topic.last = nil
+		} else {
+			data, err := json.Marshal(results[i])
+			if err != nil || bytes.Equal(data, topic.last) {
+				continue
+			}
+			topic.last = data
+			event.Result = json.RawMessage(data)
+			metrics.StreamUpdate(topic.query)
+		}
+		for subscriber := range topic.subscribers {
+			subscriber.send(event)
+		}
+	}
+}
+
+func streamMaxSubscribers() int {
+	value := os.Getenv("STREAM_MAX_SUBSCRIBERS")
+	if value == "" {
+		return defaultStreamMaxSubscriber
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		logrus.WithField("value", value).Warn("ignoring invalid STREAM_MAX_SUBSCRIBERS")
+		return defaultStreamMaxSubscriber
+	}
+	return n
+}
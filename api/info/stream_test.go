@@ -0,0 +1,189 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// testStreamHub is a hub of its own, closed when the test ends.
+func testStreamHub(t *testing.T) *streamHub {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	hub := &streamHub{ctx: ctx, cancel: cancel, watchers: make(map[string]*chainWatcher), owners: make(map[string]int)}
+	t.Cleanup(hub.close)
+	return hub
+}
+
+// subscribeBalances subscribes owner to the balance of pid on chainId.
+func subscribeBalances(t *testing.T, hub *streamHub, owner string, chainId string, pid int) (*streamSubscriber, *StreamEvent, error) {
+	t.Helper()
+	values := url.Values{
+		"query":         {"get-genesis-balances"},
+		"chain-id":      {chainId},
+		"genesis":       {testGenesis},
+		"pools.address": {fakeToken(pid).Hex()},
+		"pools.pid":     {strconv.Itoa(pid)},
+	}
+	r := httptest.NewRequest("GET", streamPath+"?"+values.Encode(), nil)
+	plan, err := planGenesisBalances(r)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	return hub.subscribe(context.Background(), owner, "get-genesis-balances", r.URL.Query(), plan)
+}
+
+func mustSubscribe(t *testing.T, hub *streamHub, owner string, pid int) *streamSubscriber {
+	t.Helper()
+	subscriber, initial, err := subscribeBalances(t, hub, owner, "146", pid)
+	if err != nil {
+		t.Fatalf("subscribe to pid %d: %v", pid, err)
+	}
+	if initial == nil || initial.Result == nil {
+		t.Fatalf("no initial result for pid %d", pid)
+	}
+	return subscriber
+}
+
+// Subscriptions to the same query share a topic, whichever way their chain
+// id is spelled.
+func TestStreamTopicOfAChain(t *testing.T) {
+	newFakeChain(t).call = genesisCall(common.HexToAddress(testGenesis), 3)
+	hub := testStreamHub(t)
+
+	first, _, err := subscribeBalances(t, hub, "ip:192.0.2.1", "146", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, initial, err := subscribeBalances(t, hub, "ip:192.0.2.1", "0x92", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.topic != second.topic {
+		t.Error("146 and 0x92 opened two topics")
+	}
+	if initial.Result == nil {
+		t.Error("no initial result for the shared topic")
+	}
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if len(hub.watchers) != 1 || hub.watchers["146"] == nil {
+		t.Errorf("watchers = %v, want one for 146", hub.watchers)
+	}
+}
+
+func TestStreamUnsubscribe(t *testing.T) {
+	newFakeChain(t).call = genesisCall(common.HexToAddress(testGenesis), 3)
+	hub := testStreamHub(t)
+
+	first := mustSubscribe(t, hub, "ip:192.0.2.1", 1)
+	second := mustSubscribe(t, hub, "ip:192.0.2.2", 1)
+	other := mustSubscribe(t, hub, "ip:192.0.2.1", 2)
+
+	hub.unsubscribe(first)
+	hub.unsubscribe(first)
+	hub.mu.Lock()
+	if len(second.topic.subscribers) != 1 || len(hub.watchers["146"].topics) != 2 || hub.subscribers != 2 {
+		t.Errorf("after one unsubscribe: %d subscribers of the topic, %d topics, %d subscribers", len(second.topic.subscribers), len(hub.watchers["146"].topics), hub.subscribers)
+	}
+	hub.mu.Unlock()
+
+	hub.unsubscribe(second)
+	hub.unsubscribe(other)
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if len(hub.watchers) != 0 || hub.subscribers != 0 || len(hub.owners) != 0 {
+		t.Errorf("after every unsubscribe: watchers %v, %d subscribers, owners %v", hub.watchers, hub.subscribers, hub.owners)
+	}
+}
+
+func TestStreamClose(t *testing.T) {
+	newFakeChain(t).call = genesisCall(common.HexToAddress(testGenesis), 3)
+	hub := testStreamHub(t)
+	subscriber := mustSubscribe(t, hub, "ip:192.0.2.1", 1)
+
+	hub.close()
+	if _, ok := <-subscriber.events; ok {
+		t.Error("events are still open after close")
+	}
+	// A stream ending after close is already released
+	hub.unsubscribe(subscriber)
+	if hub.subscribers != 0 || len(hub.owners) != 0 {
+		t.Errorf("%d subscribers, owners %v after close", hub.subscribers, hub.owners)
+	}
+
+	_, _, err := subscribeBalances(t, hub, "ip:192.0.2.1", "146", 1)
+	if apiErr := utils.AsError(err); apiErr.Type != utils.CodeRpcUnavailable {
+		t.Errorf("subscribe after close: %v, want RPC_UNAVAILABLE", err)
+	}
+}
+
+// Every topic of a chain is read in one multicall per block, and only the
+// topics whose result changed are pushed.
+func TestStreamUpdateSharesOneMulticall(t *testing.T) {
+	// Blocks are pushed by hand rather than by the watcher
+	t.Setenv("STREAM_POLL_INTERVAL", "1h")
+	genesis := common.HexToAddress(testGenesis)
+	base := genesisCall(genesis, 3)
+	var deposited atomic.Bool
+	chain := newFakeChain(t)
+	chain.call = func(to common.Address, data []byte) ([]byte, bool) {
+		if deposited.Load() && to == fakeToken(1) && methodName(AbiErc20, data) == "balanceOf" {
+			out, _ := Abis.MustContract(AbiErc20).Methods["balanceOf"].Outputs.Pack(big.NewInt(1000))
+			return out, true
+		}
+		return base(to, data)
+	}
+	hub := testStreamHub(t)
+	changed := mustSubscribe(t, hub, "ip:192.0.2.1", 1)
+	unchanged := mustSubscribe(t, hub, "ip:192.0.2.1", 2)
+
+	deposited.Store(true)
+	multicalls := len(chain.blocks)
+	hub.update(context.Background(), hub.watchers["146"], 17)
+
+	if sent := len(chain.blocks) - multicalls; sent != 1 {
+		t.Errorf("sent %d multicalls for 2 topics, want 1", sent)
+	}
+	select {
+	case event := <-changed.events:
+		result, _ := json.Marshal(event.Result)
+		if event.Block != 17 || event.Error != nil || !strings.Contains(string(result), `"1000"`) {
+			t.Errorf("event = block %d %s %v", event.Block, result, event.Error)
+		}
+	default:
+		t.Error("the changed topic was not pushed")
+	}
+	select {
+	case event := <-unchanged.events:
+		t.Errorf("the unchanged topic was pushed: %s", event.Result)
+	default:
+	}
+}
+
+// One client may not hold every stream slot.
+func TestStreamLimitPerClient(t *testing.T) {
+	t.Setenv("STREAM_MAX_PER_CLIENT", "2")
+	newFakeChain(t).call = genesisCall(common.HexToAddress(testGenesis), 3)
+	hub := testStreamHub(t)
+
+	first := mustSubscribe(t, hub, "ip:192.0.2.1", 0)
+	mustSubscribe(t, hub, "ip:192.0.2.1", 1)
+	_, _, err := subscribeBalances(t, hub, "ip:192.0.2.1", "146", 2)
+	if apiErr := utils.AsError(err); apiErr.Type != utils.CodeRateLimited {
+		t.Fatalf("third stream: %v, want RATE_LIMITED", err)
+	}
+
+	mustSubscribe(t, hub, "key:other", 2)
+	hub.unsubscribe(first)
+	mustSubscribe(t, hub, "ip:192.0.2.1", 2)
+}
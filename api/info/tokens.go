@@ -0,0 +1,482 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+var tokenStringType, _ = abi.NewType("string", "", nil)
+
+const (
+	// maxCachedTokens bounds the token cache, the least recently used tokens
+	// are dropped past it
+	maxCachedTokens = 10000
+	// tokenRetryInterval is how long a token that failed to return a name,
+	// symbol or decimals is served without it before it is read again
+	tokenRetryInterval = 10 * time.Minute
+)
+
+type tokenKey struct {
+	chainId string
+	address common.Address
+}
+
+// tokenRegistry caches the name, symbol and decimals of tokens, which never
+// change once deployed, and applies the local overrides. totalSupply is not
+// cached.
+type tokenRegistry struct {
+	mu        sync.Mutex
+	path      string
+	tokens    map[tokenKey]*cacheEntry
+	overrides map[tokenKey]TokenOverride
+	limit     int
+	clock     uint64
+	now       func() time.Time
+
+	// dirty is set when tokens changed since the file was last written,
+	// saving while a write is in flight
+	dirty  bool
+	saving bool
+	writes sync.WaitGroup
+}
+
+type cacheEntry struct {
+	metadata TokenMetadata
+	// used orders entries for eviction
+	used uint64
+	// retry is when the fields a token did not return are read again
+	retry time.Time
+}
+
+// Tokens is the token metadata of every query, in memory until Load is
+// called.
+var Tokens = &tokenRegistry{
+	tokens:    make(map[tokenKey]*cacheEntry),
+	overrides: make(map[tokenKey]TokenOverride),
+	limit:     maxCachedTokens,
+	now:       time.Now,
+}
+
+// Load reads the cache at cachePath, which is rewritten in the background
+// whenever tokens are resolved, and the overrides at overridesPath, chain id
+// to address to TokenOverride. Either may be empty, and the cache may not
+// exist yet.
+func (registry *tokenRegistry) Load(cachePath string, overridesPath string) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if cachePath != "" {
+		data, err := os.ReadFile(cachePath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read token cache: %v", err)
+		}
+		if err == nil {
+			var cached []cachedToken
+			if err := json.Unmarshal(data, &cached); err != nil {
+				return fmt.Errorf("failed to parse token cache %s: %v", cachePath, err)
+			}
+			for _, token := range cached {
+				registry.add(tokenKey{tokenChain(token.ChainId), common.HexToAddress(token.Address)}, token.TokenMetadata)
+			}
+		}
+		registry.path = cachePath
+		OnShutdown(registry.wait)
+	}
+
+	if overridesPath != "" {
+		data, err := os.ReadFile(overridesPath)
+		if err != nil {
+			return fmt.Errorf("failed to read token overrides: %v", err)
+		}
+		var overrides map[string]map[string]TokenOverride
+		if err := json.Unmarshal(data, &overrides); err != nil {
+			return fmt.Errorf("failed to parse token overrides %s: %v", overridesPath, err)
+		}
+		for chainId, tokens := range overrides {
+			for address, override := range tokens {
+				if !common.IsHexAddress(address) {
+					return fmt.Errorf("invalid token address %s in %s", address, overridesPath)
+				}
+				registry.overrides[tokenKey{tokenChain(chainId), common.HexToAddress(address)}] = override
+			}
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{"cached": len(registry.tokens), "overrides": len(registry.overrides), "file": registry.path}).Info("token metadata loaded")
+	return nil
+}
+
+// tokenChain keys tokens by chain so that every id of a chain shares them.
+func tokenChain(chainId string) string {
+	if chain, err := GetChainInfo(chainId); err == nil {
+		return chain.ID
+	}
+	return chainId
+}
+
+// tokenLookup resolves a set of tokens from the cache, or from calls that
+// ride along the multicall of the query that needs them.
+type tokenLookup struct {
+	chainId string
+	tokens  []common.Address
+	cached  map[common.Address]TokenMetadata
+	misses  []common.Address
+	supply  bool
+}
+
+// lookup starts resolving tokens, with their totalSupply when supply is set.
+func (registry *tokenRegistry) lookup(chainId string, tokens []common.Address, supply bool) *tokenLookup {
+	lookup := &tokenLookup{chainId: chainId, cached: make(map[common.Address]TokenMetadata), supply: supply}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, token := range tokens {
+		if token == (common.Address{}) || slices.Contains(lookup.tokens, token) {
+			continue
+		}
+		lookup.tokens = append(lookup.tokens, token)
+		if entry, ok := registry.tokens[tokenKey{tokenChain(chainId), token}]; ok {
+			registry.clock++
+			entry.used = registry.clock
+			lookup.cached[token] = entry.metadata
+			if tokenComplete(entry.metadata) || registry.now().Before(entry.retry) {
+				metrics.CacheHit("token_metadata")
+				continue
+			}
+		}
+		metrics.CacheMiss("token_metadata")
+		lookup.misses = append(lookup.misses, token)
+	}
+	return lookup
+}
+
+// calls reads name, symbol and decimals of every token missing from the
+// cache, then totalSupply of every token when asked for.
+func (lookup *tokenLookup) calls() []Calls {
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	var calls []Calls
+	for _, token := range lookup.misses {
+		for _, method := range []string{"name", "symbol", "decimals"} {
+			calls = append(calls, Calls{contractAddress: token, abi: parsedErc20ABI, method: method, params: nil})
+		}
+	}
+	if lookup.supply {
+		for _, token := range lookup.tokens {
+			calls = append(calls, Calls{contractAddress: token, abi: parsedErc20ABI, method: "totalSupply", params: nil})
+		}
+	}
+	return calls
+}
+
+// resolve decodes the results of calls, caches the new tokens and returns
+// the metadata of every token with its overrides applied. A call that
+// reverted leaves its field empty rather than failing the query, and keeps
+// what an earlier lookup of the token decoded.
+func (lookup *tokenLookup) resolve(results []MulticallResult) map[common.Address]TokenMetadata {
+	resolved := make([]TokenMetadata, 0, len(lookup.misses))
+	for i, token := range lookup.misses {
+		if len(results) < 3*(i+1) {
+			break
+		}
+		metadata := lookup.cached[token]
+		metadata.Address = token.Hex()
+		if name := decodeTokenString(results[3*i]); name != "" {
+			metadata.Name = name
+		}
+		if symbol := decodeTokenString(results[3*i+1]); symbol != "" {
+			metadata.Symbol = symbol
+		}
+		if decimals := decodeTokenDecimals(results[3*i+2]); decimals != nil {
+			metadata.Decimals = decimals
+		}
+		lookup.cached[token] = metadata
+		resolved = append(resolved, metadata)
+	}
+	Tokens.store(lookup.chainId, resolved)
+
+	supplies := results[min(3*len(lookup.misses), len(results)):]
+	metadata := make(map[common.Address]TokenMetadata, len(lookup.tokens))
+	for i, token := range lookup.tokens {
+		token := lookup.cached[token]
+		if lookup.supply && i < len(supplies) && supplies[i].Success && len(supplies[i].ReturnData) >= 32 {
+			token.TotalSupply = new(big.Int).SetBytes(supplies[i].ReturnData[:32]).String()
+		}
+		metadata[common.HexToAddress(token.Address)] = Tokens.override(lookup.chainId, token)
+	}
+	return metadata
+}
+
+// store caches tokens that answered a symbol or decimals, an address that
+// answers neither may not be a token yet. Tokens missing a field are read
+// again after tokenRetryInterval. The file is written in the background.
+func (registry *tokenRegistry) store(chainId string, tokens []TokenMetadata) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	added := 0
+	for _, token := range tokens {
+		if token.Symbol == "" && token.Decimals == nil {
+			continue
+		}
+		entry := registry.add(tokenKey{tokenChain(chainId), common.HexToAddress(token.Address)}, token)
+		if !tokenComplete(token) {
+			entry.retry = registry.now().Add(tokenRetryInterval)
+		}
+		added++
+	}
+	if added == 0 || registry.path == "" {
+		return
+	}
+	registry.dirty = true
+	if !registry.saving {
+		registry.saving = true
+		registry.writes.Add(1)
+		go registry.saveLoop()
+	}
+}
+
+// add caches token under key, dropping the least recently used token when
+// the cache is full. registry.mu must be held.
+func (registry *tokenRegistry) add(key tokenKey, token TokenMetadata) *cacheEntry {
+	entry, ok := registry.tokens[key]
+	if !ok {
+		if len(registry.tokens) >= registry.limit {
+			registry.evict()
+		}
+		entry = &cacheEntry{}
+		registry.tokens[key] = entry
+	}
+	registry.clock++
+	entry.metadata = token
+	entry.used = registry.clock
+	entry.retry = time.Time{}
+	return entry
+}
+
+func (registry *tokenRegistry) evict() {
+	var oldest tokenKey
+	var used uint64
+	first := true
+	for key, entry := range registry.tokens {
+		if first || entry.used < used {
+			oldest, used, first = key, entry.used, false
+		}
+	}
+	delete(registry.tokens, oldest)
+}
+
+// tokenComplete reports whether a token returned every cached field.
+func tokenComplete(token TokenMetadata) bool {
+	return token.Name != "" && token.Symbol != "" && token.Decimals != nil
+}
+
+// saveLoop writes the cache until no token changed during the last write, so
+// tokens stored while a write is in flight are saved together.
+func (registry *tokenRegistry) saveLoop() {
+	defer registry.writes.Done()
+	for {
+		registry.mu.Lock()
+		if !registry.dirty {
+			registry.saving = false
+			registry.mu.Unlock()
+			return
+		}
+		registry.dirty = false
+		path, cached := registry.path, registry.snapshot()
+		registry.mu.Unlock()
+
+		if err := saveTokenCache(path, cached); err != nil {
+			logrus.WithError(err).Warn("failed to save token cache")
+		}
+	}
+}
+
+// wait blocks until pending writes of the cache are done.
+func (registry *tokenRegistry) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		registry.writes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("token cache not saved: %v", ctx.Err())
+	}
+}
+
+// snapshot lists the cache sorted by chain and address. registry.mu must be
+// held.
+func (registry *tokenRegistry) snapshot() []cachedToken {
+	cached := make([]cachedToken, 0, len(registry.tokens))
+	for key, entry := range registry.tokens {
+		cached = append(cached, cachedToken{ChainId: key.chainId, TokenMetadata: entry.metadata})
+	}
+	sort.Slice(cached, func(i, j int) bool {
+		if cached[i].ChainId != cached[j].ChainId {
+			return cached[i].ChainId < cached[j].ChainId
+		}
+		return cached[i].Address < cached[j].Address
+	})
+	return cached
+}
+
+// saveTokenCache writes a temporary file then renames it, so a crash never
+// leaves a truncated cache.
+func saveTokenCache(path string, cached []cachedToken) error {
+	data, err := json.MarshalIndent(cached, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create token cache directory: %v", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write token cache: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace token cache: %v", err)
+	}
+	return nil
+}
+
+func (registry *tokenRegistry) override(chainId string, token TokenMetadata) TokenMetadata {
+	registry.mu.Lock()
+	override, ok := registry.overrides[tokenKey{tokenChain(chainId), common.HexToAddress(token.Address)}]
+	registry.mu.Unlock()
+	if !ok {
+		return token
+	}
+
+	if override.Name != "" {
+		token.Name = override.Name
+	}
+	if override.Symbol != "" {
+		token.Symbol = override.Symbol
+	}
+	token.DisplayName = override.DisplayName
+	token.Logo = override.Logo
+	return token
+}
+
+// cachedMetadata returns the metadata of a cached token with its overrides.
+func (registry *tokenRegistry) cachedMetadata(chainId string, token common.Address) (TokenMetadata, bool) {
+	registry.mu.Lock()
+	entry, ok := registry.tokens[tokenKey{tokenChain(chainId), token}]
+	var metadata TokenMetadata
+	if ok {
+		metadata = entry.metadata
+	}
+	registry.mu.Unlock()
+	if !ok {
+		return TokenMetadata{}, false
+	}
+	return registry.override(chainId, metadata), true
+}
+
+// decodeTokenString reads a string, or the bytes32 that older tokens such as
+// MKR return from name and symbol.
+func decodeTokenString(result MulticallResult) string {
+	if !result.Success {
+		return ""
+	}
+	if out, err := (abi.Arguments{{Type: tokenStringType}}).Unpack(result.ReturnData); err == nil {
+		return out[0].(string)
+	}
+	if len(result.ReturnData) == 32 {
+		if value := strings.TrimRight(string(result.ReturnData), "\x00"); utf8.ValidString(value) {
+			return value
+		}
+	}
+	return ""
+}
+
+// decodeTokenDecimals reads decimals as a full word, as some tokens return
+// uint256 rather than uint8.
+func decodeTokenDecimals(result MulticallResult) *uint8 {
+	if !result.Success || len(result.ReturnData) < 32 {
+		return nil
+	}
+	value := new(big.Int).SetBytes(result.ReturnData[:32])
+	if !value.IsUint64() || value.Uint64() > 255 {
+		return nil
+	}
+	decimals := uint8(value.Uint64())
+	return &decimals
+}
+
+// ResolveTokens returns the metadata of tokens, reading the ones that are not
+// cached in a single multicall.
+func ResolveTokens(ctx context.Context, chainId string, tokens []common.Address, supply bool) (map[common.Address]TokenMetadata, error) {
+	lookup := Tokens.lookup(chainId, tokens, supply)
+	calls := lookup.calls()
+	if len(calls) == 0 {
+		return lookup.resolve(nil), nil
+	}
+
+	plan := &MulticallPlan{
+		ChainId: chainId,
+		Calls:   calls,
+		Decode: func(results []MulticallResult) (interface{}, error) {
+			return lookup.resolve(results), nil
+		},
+	}
+	response, err := plan.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return response.(map[common.Address]TokenMetadata), nil
+}
+
+// tokenRef is the metadata of token for a response field, nil when the
+// token is unset.
+func tokenRef(metadata map[common.Address]TokenMetadata, token common.Address) *TokenMetadata {
+	if value, ok := metadata[token]; ok {
+		return &value
+	}
+	return nil
+}
+
+//http://localhost:8080/api/info?query=get-tokens&chain-id=146&tokens=0x039e2fB66102314Ce7b64Ce5Ce3E5183bc94aD38
+
+func GetTokens(r *http.Request) (GetTokensResponse, error) {
+	params, err := parseTokensParams(r)
+	if err != nil {
+		return GetTokensResponse{}, err
+	}
+
+	LogTokensParams(r.Context(), params)
+
+	metadata, err := ResolveTokens(r.Context(), params.ChainId, params.Tokens, true)
+	if err != nil {
+		return GetTokensResponse{}, err
+	}
+
+	// Tokens keep the order they were asked in, duplicates once
+	response := GetTokensResponse{Tokens: []TokenMetadata{}}
+	for _, token := range params.Tokens {
+		if value, ok := metadata[token]; ok {
+			response.Tokens = append(response.Tokens, value)
+			delete(metadata, token)
+		}
+	}
+	return response, nil
+}
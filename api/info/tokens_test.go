@@ -0,0 +1,169 @@
+package infoHandler
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// useTokenRegistry replaces Tokens with an empty registry until the test
+// ends, its clock returned so tests can move it.
+func useTokenRegistry(t *testing.T, limit int) *time.Time {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	saved := Tokens
+	Tokens = &tokenRegistry{
+		tokens:    make(map[tokenKey]*cacheEntry),
+		overrides: make(map[tokenKey]TokenOverride),
+		limit:     limit,
+		now:       func() time.Time { return now },
+	}
+	t.Cleanup(func() {
+		Tokens.writes.Wait()
+		Tokens = saved
+	})
+	return &now
+}
+
+// tokenCall answers name, symbol and decimals of any address, name only once
+// nameOk is set.
+func tokenCall(nameOk *atomic.Bool) func(common.Address, []byte) ([]byte, bool) {
+	parsedErc20ABI := Abis.MustContract(AbiErc20)
+	return func(to common.Address, data []byte) ([]byte, bool) {
+		var out []byte
+		var err error
+		switch name := methodName(AbiErc20, data); name {
+		case "name":
+			if !nameOk.Load() {
+				return nil, false
+			}
+			out, err = parsedErc20ABI.Methods[name].Outputs.Pack("Token " + to.Hex()[40:])
+		case "symbol":
+			out, err = parsedErc20ABI.Methods[name].Outputs.Pack("T" + to.Hex()[40:])
+		case "decimals":
+			out, err = parsedErc20ABI.Methods[name].Outputs.Pack(uint8(18))
+		default:
+			return nil, false
+		}
+		return out, err == nil
+	}
+}
+
+func TestTokenCacheRetriesMissingFields(t *testing.T) {
+	now := useTokenRegistry(t, maxCachedTokens)
+	var nameOk atomic.Bool
+	chain := newFakeChain(t)
+	chain.call = tokenCall(&nameOk)
+	token := fakeToken(1)
+
+	resolve := func() TokenMetadata {
+		t.Helper()
+		metadata, err := ResolveTokens(context.Background(), "146", []common.Address{token}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return metadata[token]
+	}
+
+	if metadata := resolve(); metadata.Name != "" || metadata.Symbol != "T01" || metadata.Decimals == nil {
+		t.Fatalf("first lookup = %+v, want no name", metadata)
+	}
+
+	// The name is served empty from the cache until the retry
+	nameOk.Store(true)
+	if metadata := resolve(); metadata.Name != "" {
+		t.Fatalf("lookup before the retry = %+v", metadata)
+	}
+	if calls := chain.calls(AbiErc20, "name"); calls != 1 {
+		t.Fatalf("name read %d times before the retry, want 1", calls)
+	}
+
+	*now = now.Add(tokenRetryInterval)
+	if metadata := resolve(); metadata.Name != "Token 01" || metadata.Symbol != "T01" || *metadata.Decimals != 18 {
+		t.Fatalf("lookup after the retry = %+v", metadata)
+	}
+	resolve()
+	if calls := chain.calls(AbiErc20, "name"); calls != 2 {
+		t.Errorf("name read %d times, want 2", calls)
+	}
+}
+
+func TestTokenCacheKeepsDecodedFields(t *testing.T) {
+	useTokenRegistry(t, maxCachedTokens)
+	token := fakeToken(1)
+	decimals := uint8(6)
+	Tokens.store("146", []TokenMetadata{{Address: token.Hex(), Name: "Old", Symbol: "OLD", Decimals: &decimals}})
+	Tokens.tokens[tokenKey{"146", token}].metadata.Name = ""
+
+	lookup := Tokens.lookup("146", []common.Address{token}, false)
+	// Every call reverts this time
+	metadata := lookup.resolve(make([]MulticallResult, 3))[token]
+	if metadata.Symbol != "OLD" || metadata.Decimals == nil || *metadata.Decimals != 6 {
+		t.Errorf("metadata = %+v, want the cached fields kept", metadata)
+	}
+}
+
+func TestTokenCacheIsBounded(t *testing.T) {
+	useTokenRegistry(t, 2)
+	decimals := uint8(18)
+	store := func(pid int) {
+		Tokens.store("146", []TokenMetadata{{Address: fakeToken(pid).Hex(), Name: "n", Symbol: "s", Decimals: &decimals}})
+	}
+
+	store(0)
+	store(1)
+	Tokens.lookup("146", []common.Address{fakeToken(0)}, false)
+	store(2)
+
+	if len(Tokens.tokens) != 2 {
+		t.Fatalf("%d tokens cached, want 2", len(Tokens.tokens))
+	}
+	for pid, want := range []bool{true, false, true} {
+		if _, ok := Tokens.cachedMetadata("146", fakeToken(pid)); ok != want {
+			t.Errorf("token %d cached %v, want %v", pid, ok, want)
+		}
+	}
+}
+
+func TestTokenCacheSavedInBackground(t *testing.T) {
+	useTokenRegistry(t, maxCachedTokens)
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := Tokens.Load(path, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	decimals := uint8(18)
+	for pid := 0; pid < 20; pid++ {
+		Tokens.store("146", []TokenMetadata{{Address: fakeToken(pid).Hex(), Name: "n", Symbol: "s", Decimals: &decimals}})
+	}
+	if err := Tokens.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cached []cachedToken
+	if err := json.Unmarshal(data, &cached); err != nil {
+		t.Fatal(err)
+	}
+	if len(cached) != 20 || cached[0].Address != fakeToken(0).Hex() {
+		t.Errorf("saved %d tokens, want 20 in order", len(cached))
+	}
+
+	// A new registry reads them back
+	useTokenRegistry(t, maxCachedTokens)
+	if err := Tokens.Load(path, ""); err != nil {
+		t.Fatal(err)
+	}
+	if metadata, ok := Tokens.cachedMetadata("146", fakeToken(19)); !ok || metadata.Name != "n" || metadata.TotalSupply != "" {
+		t.Errorf("reloaded token 19 = %+v, %v", metadata, ok)
+	}
+}
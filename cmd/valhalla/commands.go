@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	InfoHandler "github.com/FudgyDRS/valhalla-api/api/info"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+)
+
+var balanceColumns = []string{"pid", "token", "symbol", "genesis-balance", "user-balance", "user-stake", "user-reward"}
+
+func runPools(ctx context.Context, opts options, args []string) (result, error) {
+	pools, err := InfoHandler.ListGenesisPools(ctx, opts.chainId, opts.genesis)
+	if err != nil {
+		return result{}, err
+	}
+	balances, err := genesisBalances(ctx, opts, pools, nil, opts.user)
+	if err != nil {
+		return result{}, err
+	}
+
+	type pool struct {
+		Pid            int                        `json:"pid"`
+		Config         InfoHandler.PoolInfo       `json:"config"`
+		TokenMetadata  *InfoHandler.TokenMetadata `json:"token-metadata,omitempty"`
+		GenesisBalance string                     `json:"genesis-balance"`
+	}
+	res := result{
+		columns: []string{"pid", "token", "symbol", "alloc-point", "dep-fee", "valhalla-per-sec", "started", "gauge", "genesis-balance"},
+	}
+	value := make([]pool, len(pools))
+	for pid, info := range pools {
+		// A pool added between the two reads has no balance yet
+		var balance InfoHandler.GetGenesisBalanceResponse
+		if pid < len(balances) {
+			balance = balances[pid]
+		}
+		value[pid] = pool{Pid: pid, Config: info, TokenMetadata: balance.TokenMetadata, GenesisBalance: balance.GenesisBalance}
+		gauge := ""
+		if info.GaugeInfo.IsGauge {
+			gauge = info.GaugeInfo.Gauge.Hex()
+		}
+		res.rows = append(res.rows, []string{
+			strconv.Itoa(pid),
+			info.Token.Hex(),
+			symbol(balance.TokenMetadata),
+			info.AllocPoint.String(),
+			info.DepFee.String(),
+			info.PoolValhallaPerSec.String(),
+			strconv.FormatBool(info.IsStarted),
+			gauge,
+			balance.GenesisBalance,
+		})
+	}
+	res.value = value
+	return res, nil
+}
+
+func runPool(ctx context.Context, opts options, args []string) (result, error) {
+	pid, err := strconv.Atoi(args[0])
+	if err != nil || pid < 0 {
+		return result{}, utils.ErrMalformedRequest(fmt.Sprintf("invalid pid %s", args[0]))
+	}
+	pools, err := InfoHandler.ListGenesisPools(ctx, opts.chainId, opts.genesis)
+	if err != nil {
+		return result{}, err
+	}
+	if pid >= len(pools) {
+		return result{}, utils.ErrNotFound(fmt.Sprintf("pid %d does not exist (pool length %d)", pid, len(pools)))
+	}
+	balances, err := genesisBalances(ctx, opts, pools, []int{pid}, opts.user)
+	if err != nil {
+		return result{}, err
+	}
+
+	info, balance := pools[pid], balances[0]
+	rewardTokens := make([]string, len(info.GaugeInfo.RewardTokens))
+	for i, token := range info.GaugeInfo.RewardTokens {
+		rewardTokens[i] = token.Hex()
+	}
+	return result{
+		value: struct {
+			Pid     int                                   `json:"pid"`
+			Config  InfoHandler.PoolInfo                  `json:"config"`
+			Balance InfoHandler.GetGenesisBalanceResponse `json:"balance"`
+		}{pid, info, balance},
+		columns: []string{"pid", "token", "symbol", "alloc-point", "dep-fee", "last-reward-time", "acc-valhalla-per-share", "valhalla-per-sec", "started", "gauge", "reward-tokens", "genesis-balance", "user-balance", "user-stake", "user-reward"},
+		rows: [][]string{{
+			strconv.Itoa(pid),
+			info.Token.Hex(),
+			symbol(balance.TokenMetadata),
+			info.AllocPoint.String(),
+			info.DepFee.String(),
+			info.LastRewardTime.String(),
+			info.AccValhallaPerShare.String(),
+			info.PoolValhallaPerSec.String(),
+			strconv.FormatBool(info.IsStarted),
+			info.GaugeInfo.Gauge.Hex(),
+			strings.Join(rewardTokens, " "),
+			balance.GenesisBalance,
+			balance.UserBalance,
+			balance.UserStake,
+			balance.UserReward,
+		}},
+		record: true,
+	}, nil
+}
+
+func runUser(ctx context.Context, opts options, args []string) (result, error) {
+	balances, err := genesisBalances(ctx, opts, nil, nil, args[0])
+	if err != nil {
+		return result{}, err
+	}
+
+	res := result{value: []InfoHandler.GetGenesisBalanceResponse{}, columns: balanceColumns}
+	positions := []InfoHandler.GetGenesisBalanceResponse{}
+	for _, balance := range balances {
+		if !opts.all && isZero(balance.UserBalance) && isZero(balance.UserStake) && isZero(balance.UserReward) {
+			continue
+		}
+		positions = append(positions, balance)
+		res.rows = append(res.rows, []string{balance.PoolId, balance.Token, symbol(balance.TokenMetadata), balance.GenesisBalance, balance.UserBalance, balance.UserStake, balance.UserReward})
+	}
+	res.value = positions
+	return res, nil
+}
+
+func runPair(ctx context.Context, opts options, args []string) (result, error) {
+	values := url.Values{"chain-id": {opts.chainId}, "genesis": {opts.genesis}, "pair": {args[0]}}
+	if opts.user != "" {
+		values.Set("user", opts.user)
+	}
+	response, err := InfoHandler.Query(ctx, "get-genesis-pair", values)
+	if err != nil {
+		return result{}, err
+	}
+
+	pair := response.(InfoHandler.GetGenesisPairResponse)
+	return result{
+		value:   pair,
+		columns: []string{"pair", "pid", "total-supply", "base-balance", "quote-balance", "genesis-balance", "user-balance", "user-stake", "user-reward", "user-base-balance", "user-quote-balance"},
+		rows: [][]string{{
+			pair.PairAddress,
+			pair.PoolId,
+			pair.PairTotalSupply,
+			pair.BaseBalance,
+			pair.QuoteBalance,
+			pair.GenesisBalance,
+			pair.UserBalance,
+			pair.UserStake,
+			pair.UserReward,
+			pair.UserBaseBalance,
+			pair.UserQuoteBalance,
+		}},
+		record: true,
+	}, nil
+}
+
+func runTwap(ctx context.Context, opts options, args []string) (result, error) {
+	values := url.Values{"chain-id": {opts.chainId}, "pair": {args[0]}}
+	for key, value := range map[string]string{"token-in": opts.tokenIn, "amount-in": opts.amountIn, "granularity": opts.granularity} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	twap, err := InfoHandler.GetPairTwap(ctx, values)
+	if err != nil {
+		return result{}, err
+	}
+
+	return result{
+		value:   twap,
+		columns: []string{"pair", "token-in", "token-out", "amount-in", "granularity", "twap-amount-out", "spot-amount-out"},
+		rows:    [][]string{{twap.Pair, twap.TokenIn, twap.TokenOut, twap.AmountIn, twap.Granularity, twap.TwapAmountOut, twap.SpotAmountOut}},
+		record:  true,
+	}, nil
+}
+
+// runDecode decodes calldata against the registered ABIs, or fetches a
+// transaction when given a 32 byte hash and decodes its input and logs.
+func runDecode(ctx context.Context, opts options, args []string) (result, error) {
+	values := url.Values{}
+	if opts.abi != "" {
+		values.Set("abi", opts.abi)
+	}
+	if input := strings.TrimPrefix(args[0], "0x"); len(input) == 64 {
+		values.Set("tx", args[0])
+		values.Set("chain-id", opts.chainId)
+	} else {
+		values.Set("calldata", args[0])
+	}
+	response, err := InfoHandler.Query(ctx, "decode", values)
+	if err != nil {
+		return result{}, err
+	}
+
+	decoded := response.(InfoHandler.DecodeResponse)
+	res := result{value: decoded, columns: []string{"source", "name", "argument", "type", "value"}}
+	if call := decoded.Call; call != nil {
+		name := call.Signature
+		if name == "" {
+			name = call.Selector
+		}
+		if call.Error != "" {
+			res.rows = append(res.rows, []string{"call", name, "", "", call.Error})
+		}
+		for _, arg := range call.Arguments {
+			res.rows = append(res.rows, []string{"call", name, arg.Name, arg.Type, cell(arg.Value)})
+		}
+	}
+	for _, log := range decoded.Logs {
+		source, name := fmt.Sprintf("log %d", log.LogIndex), log.Signature
+		if name == "" {
+			name = log.Topic
+		}
+		if log.Error != "" {
+			res.rows = append(res.rows, []string{source, name, "", "", log.Error})
+		}
+		for _, arg := range log.Arguments {
+			res.rows = append(res.rows, []string{source, name, arg.Name, arg.Type, cell(arg.Value)})
+		}
+	}
+	return res, nil
+}
+
+// runCall reads a method given by its signature, "name(types)" with the
+// return types in a second pair of parentheses. Without them the registered
+// ABIs are searched for the selector.
+func runCall(ctx context.Context, opts options, args []string) (result, error) {
+	method, inputs, returns, err := parseSignature(args[1])
+	if err != nil {
+		return result{}, err
+	}
+	if len(inputs) != len(args)-2 {
+		return result{}, utils.ErrMalformedRequest(fmt.Sprintf("%s takes %d arguments, got %d", method, len(inputs), len(args)-2))
+	}
+
+	values := url.Values{"chain-id": {opts.chainId}, "contract": {args[0]}, "method": {method}}
+	for i, input := range inputs {
+		values.Add("params.type", input)
+		values.Add("params.value", args[i+2])
+	}
+	for _, ret := range returns {
+		values.Add("returns", ret)
+	}
+	response, err := InfoHandler.Query(ctx, "call", values)
+	if err != nil {
+		return result{}, err
+	}
+
+	call := response.(InfoHandler.ContractCallResponse)
+	res := result{value: call, columns: []string{"index", "type", "value"}}
+	for i, output := range call.Outputs {
+		res.rows = append(res.rows, []string{strconv.Itoa(i), output.Type, cell(output.Value)})
+	}
+	if len(call.Outputs) == 0 {
+		res.rows = append(res.rows, []string{"", "bytes", call.ReturnData})
+	}
+	return res, nil
+}
+
+// genesisBalances reads the balances of pids, or of every pool when pids is
+// nil.
+func genesisBalances(ctx context.Context, opts options, pools []InfoHandler.PoolInfo, pids []int, user string) ([]InfoHandler.GetGenesisBalanceResponse, error) {
+	values := url.Values{"chain-id": {opts.chainId}, "genesis": {opts.genesis}}
+	if user != "" {
+		values.Set("user", user)
+	}
+	for _, pid := range pids {
+		values.Add("pools.pid", strconv.Itoa(pid))
+		values.Add("pools.address", pools[pid].Token.Hex())
+	}
+
+	response, err := InfoHandler.Query(ctx, "get-genesis-balances", values)
+	if err != nil {
+		return nil, err
+	}
+	return response.(InfoHandler.GetGenesisBalancesResponse).Pools, nil
+}
+
+// parseSignature splits "transfer(address,uint256)(bool)" into the method
+// name, the input types and the return types. Tuple types keep their commas.
+func parseSignature(signature string) (string, []string, []string, error) {
+	open := strings.Index(signature, "(")
+	if open <= 0 {
+		return "", nil, nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid signature %s, expected name(types)", signature))
+	}
+	method := signature[:open]
+
+	groups, rest := [][]string{}, signature[open:]
+	for rest != "" && len(groups) < 2 {
+		types, remainder, err := splitTypes(rest)
+		if err != nil {
+			return "", nil, nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid signature %s: %v", signature, err))
+		}
+		groups, rest = append(groups, types), remainder
+	}
+	if rest != "" {
+		return "", nil, nil, utils.ErrMalformedRequest(fmt.Sprintf("invalid signature %s, unexpected %s", signature, rest))
+	}
+
+	var returns []string
+	if len(groups) == 2 {
+		returns = groups[1]
+	}
+	return method, groups[0], returns, nil
+}
+
+// splitTypes reads a parenthesised list of types from the start of s and
+// returns them with what follows the closing parenthesis.
+func splitTypes(s string) ([]string, string, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, "", fmt.Errorf("expected ( at %s", s)
+	}
+
+	var types []string
+	depth, start := 0, 1
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 1 {
+				types = append(types, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+			continue
+		default:
+			continue
+		}
+		if depth == 0 {
+			if last := strings.TrimSpace(s[start:i]); last != "" || len(types) > 0 {
+				types = append(types, last)
+			}
+			return types, s[i+1:], nil
+		}
+	}
+	return nil, "", fmt.Errorf("unbalanced parentheses")
+}
+
+// symbol is the display name of a token when it has one, else its symbol.
+func symbol(metadata *InfoHandler.TokenMetadata) string {
+	if metadata == nil {
+		return ""
+	}
+	if metadata.DisplayName != "" {
+		return metadata.DisplayName
+	}
+	return metadata.Symbol
+}
+
+func isZero(amount string) bool {
+	n, ok := new(big.Int).SetString(amount, 10)
+	return !ok || n.Sign() == 0
+}
@@ -0,0 +1,274 @@
+// Command valhalla reads genesis pools, user positions and pairs from the
+// terminal. It runs the same service package as the API, in-process, so there
+// is no server to reach.
+//
+//	valhalla [flags] <command> [args]
+//
+// Commands:
+//
+//	pools                               every pool of the genesis with its stake
+//	pool <pid>                          one pool, and the position of --user
+//	user <address>                      positions of a user, --all for empty pools too
+//	pair <address>                      pair reserves, genesis stake and the position of --user
+//	twap <pair>                         time weighted and spot quote, see --token-in, --amount-in, --granularity
+//	decode <calldata|tx-hash>           decode calldata, or the call and logs of a transaction
+//	call <address> <signature> [args]   read any method, e.g. "balanceOf(address)(uint256)"
+//
+// Flags may come before or after the command, and -- ends them:
+//
+//	--chain      chain id, VALHALLA_CHAIN_ID or 146
+//	--genesis    genesis address, VALHALLA_GENESIS
+//	--output -o  table, json or csv
+//	--block      read the state at this block instead of the latest
+//	--timeout    deadline of the command, 30s by default
+//
+// Logs go to stderr at warn level unless LOG_LEVEL is set.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	InfoHandler "github.com/FudgyDRS/valhalla-api/api/info"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+)
+
+type options struct {
+	chainId     string
+	genesis     string
+	output      string
+	block       string
+	timeout     time.Duration
+	user        string
+	all         bool
+	tokenIn     string
+	amountIn    string
+	granularity string
+	abi         string
+}
+
+// result is what a command prints: value as JSON, or columns and rows as a
+// table or CSV. A record is a single row, shown one field per line.
+type result struct {
+	value   interface{}
+	columns []string
+	rows    [][]string
+	record  bool
+}
+
+type command struct {
+	usage string
+	args  int
+	run   func(ctx context.Context, opts options, args []string) (result, error)
+}
+
+var commands = map[string]command{
+	"pools":  {usage: "pools", args: 0, run: runPools},
+	"pool":   {usage: "pool <pid>", args: 1, run: runPool},
+	"user":   {usage: "user <address>", args: 1, run: runUser},
+	"pair":   {usage: "pair <address>", args: 1, run: runPair},
+	"twap":   {usage: "twap <pair>", args: 1, run: runTwap},
+	"decode": {usage: "decode <calldata|tx-hash>", args: 1, run: runDecode},
+	"call":   {usage: "call <address> <signature> [args...]", args: -2, run: runCall},
+}
+
+func main() {
+	godotenv.Load()
+	if err := utils.SetupLogging("text"); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+	logrus.SetOutput(os.Stderr)
+	if os.Getenv("LOG_LEVEL") == "" {
+		logrus.SetLevel(logrus.WarnLevel)
+	}
+
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(argv []string, stdout io.Writer, stderr io.Writer) int {
+	opts, args, err := parseArgs(argv)
+	if errors.Is(err, flag.ErrHelp) || len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 2
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "error: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	args = args[1:]
+	if (cmd.args >= 0 && len(args) != cmd.args) || (cmd.args < 0 && len(args) < -cmd.args) {
+		fmt.Fprintf(stderr, "usage: valhalla %s\n", cmd.usage)
+		return 2
+	}
+	if !slices.Contains([]string{"table", "json", "csv"}, opts.output) {
+		fmt.Fprintf(stderr, "error: invalid output %q, expected table, json or csv\n", opts.output)
+		return 2
+	}
+
+	if err := InfoHandler.LoadAbis(); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	if err := InfoHandler.Tokens.Load(os.Getenv("TOKEN_CACHE_FILE"), os.Getenv("TOKEN_OVERRIDES_FILE")); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	// The token cache is written in the background, wait for it on exit
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := InfoHandler.Shutdown(ctx); err != nil {
+			fmt.Fprintln(stderr, "warning:", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+	if opts.block != "" && opts.block != "latest" {
+		block, ok := new(big.Int).SetString(opts.block, 0)
+		if !ok || block.Sign() < 0 {
+			fmt.Fprintf(stderr, "error: invalid block %q\n", opts.block)
+			return 2
+		}
+		ctx = InfoHandler.WithBlock(ctx, block)
+	}
+
+	res, err := cmd.run(ctx, opts, args)
+	if err != nil {
+		apiErr := utils.AsError(err)
+		message := apiErr.Details
+		if message == "" {
+			message = apiErr.Message
+		}
+		fmt.Fprintf(stderr, "error: %s (%s)\n", message, apiErr.Type)
+		return 1
+	}
+	if err := res.print(stdout, opts.output); err != nil {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
+
+// parseArgs reads the flags wherever they are among the arguments, which the
+// flag package alone stops at the first argument that is not a flag.
+func parseArgs(argv []string) (options, []string, error) {
+	chainId := os.Getenv("VALHALLA_CHAIN_ID")
+	if chainId == "" {
+		chainId = "146"
+	}
+
+	var opts options
+	fs := flag.NewFlagSet("valhalla", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.chainId, "chain", chainId, "chain id")
+	fs.StringVar(&opts.genesis, "genesis", os.Getenv("VALHALLA_GENESIS"), "genesis address")
+	fs.StringVar(&opts.output, "output", "table", "table, json or csv")
+	fs.StringVar(&opts.output, "o", "table", "table, json or csv")
+	fs.StringVar(&opts.block, "block", "", "block number, latest by default")
+	fs.DurationVar(&opts.timeout, "timeout", 30*time.Second, "deadline of the command")
+	fs.StringVar(&opts.user, "user", "", "user address")
+	fs.BoolVar(&opts.all, "all", false, "list pools without a position too")
+	fs.StringVar(&opts.tokenIn, "token-in", "", "token quoted by twap, token0 by default")
+	fs.StringVar(&opts.amountIn, "amount-in", "", "amount quoted by twap in wei, one whole token by default")
+	fs.StringVar(&opts.granularity, "granularity", "", "observations averaged by twap")
+	fs.StringVar(&opts.abi, "abi", "", "registered abi to decode with")
+
+	var rest []string
+	if i := slices.Index(argv, "--"); i >= 0 {
+		argv, rest = argv[:i], argv[i+1:]
+	}
+
+	var args []string
+	for {
+		if err := fs.Parse(argv); err != nil {
+			return options{}, nil, err
+		}
+		argv = fs.Args()
+		if len(argv) == 0 {
+			break
+		}
+		args = append(args, argv[0])
+		argv = argv[1:]
+	}
+	return opts, append(args, rest...), nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: valhalla [flags] <command> [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  valhalla %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "flags: --chain, --genesis, --output/-o table|json|csv, --block, --timeout,")
+	fmt.Fprintln(w, "       --user, --all, --token-in, --amount-in, --granularity, --abi")
+}
+
+func (res result) print(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(res.value)
+
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write(res.columns)
+		writer.WriteAll(res.rows)
+		return writer.Error()
+	}
+
+	writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if res.record && len(res.rows) == 1 {
+		for i, column := range res.columns {
+			fmt.Fprintf(writer, "%s\t%s\n", column, res.rows[0][i])
+		}
+		return writer.Flush()
+	}
+	fmt.Fprintln(writer, strings.ToUpper(strings.Join(res.columns, "\t")))
+	for _, row := range res.rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+// cell formats a decoded value for a table or CSV: strings as they are,
+// anything else as JSON.
+func cell(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
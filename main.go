@@ -1,51 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"fmt"
-	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	InfoHandler "github.com/FudgyDRS/valhalla-api/api/info"
+	"github.com/FudgyDRS/valhalla-api/pkg/metrics"
+	"github.com/FudgyDRS/valhalla-api/pkg/server"
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
+	"github.com/FudgyDRS/valhalla-api/pkg/utils"
 	"github.com/sirupsen/logrus"
 
 	"github.com/joho/godotenv"
 )
 
-type CustomLogFormatter struct{}
-
-func (f *CustomLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
-	level := entry.Level.String()
-
-	// Set log colors, using 256-color ANSI escape code
-	var levelColor string
-	switch entry.Level {
-	case logrus.InfoLevel:
-		levelColor = "\033[38;5;45m" // Green
-	case logrus.DebugLevel:
-		levelColor = "\033[34m" // Blue
-	case logrus.WarnLevel:
-		levelColor = "\033[33m" // Yellow
-	case logrus.ErrorLevel:
-		levelColor = "\033[31m" // Red
-	case logrus.FatalLevel:
-		levelColor = "\033[35m" // Magenta
-	case logrus.PanicLevel:
-		levelColor = "\033[36m" // Cyan
-	default:
-		levelColor = "\033[0m" // Reset color (for unknown levels)
-	}
-
-	logMessage := fmt.Sprintf("\n\033[38;5;180m%s\033[0m [%s%s\033[0m] %s\n",
-		entry.Time.Format("2006-01-02 15:04:05"), // Timestamp in cream color
-		levelColor,                               // Colorized log level
-		level,                                    // Log level name
-		entry.Message)                            // Log message
-
-	return []byte(logMessage), nil
-}
-
 func main() {
 	serverEnv := flag.String("server", "production", "Specify the server environment (local/production)")
 	flag.Parse()
@@ -57,24 +30,68 @@ func main() {
 		envFile = ".env"
 	}
 
-	err := godotenv.Load(envFile)
-	if err != nil {
-		fmt.Println("Error loading .env file")
+	// A missing env file is fine, the environment may be set by the host
+	envErr := godotenv.Load(envFile)
+
+	logFormat := "json"
+	if *serverEnv == "local" {
+		logFormat = "text"
+	}
+	if err := utils.SetupLogging(logFormat); err != nil {
+		logrus.Fatalf("Error setting up logging: %v", err)
+	}
+	if envErr != nil {
+		logrus.WithError(envErr).WithField("file", envFile).Warn("env file not loaded")
 	}
 
-	debugMode := os.Getenv("DEBUG_MODE_ENABLED")
-	if debugMode == "true" || debugMode == "1" {
-		logrus.SetLevel(logrus.DebugLevel)
-	} else {
-		logrus.SetLevel(logrus.FatalLevel)
+	if err := InfoHandler.LoadAbis(); err != nil {
+		logrus.Fatalf("Error loading abi directory: %v", err)
+	}
+	if err := InfoHandler.Tokens.Load(os.Getenv("TOKEN_CACHE_FILE"), os.Getenv("TOKEN_OVERRIDES_FILE")); err != nil {
+		logrus.Fatalf("Error loading token metadata: %v", err)
 	}
 
-	logrus.SetFormatter(&CustomLogFormatter{})
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		logrus.Fatalf("Error setting up tracing: %v", err)
+	}
 
-	logrus.Warning("program starting in debug mode...")
+	if err := InfoHandler.StartAlerts(); err != nil {
+		logrus.Fatalf("Error starting webhook alerts: %v", err)
+	}
 
-	http.HandleFunc("/api/info", InfoHandler.Handler)
+	if err := InfoHandler.StartGRPC(); err != nil {
+		logrus.Fatalf("Error starting gRPC server: %v", err)
+	}
 
-	log.Println("Starting server on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	serverConfig, err := server.LoadConfig()
+	if err != nil {
+		logrus.Fatalf("Error loading server config: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/info", InfoHandler.Handler)
+	mux.HandleFunc("/api/v1/", InfoHandler.Handler)
+	mux.HandleFunc("/api/stream", InfoHandler.Handler)
+	mux.HandleFunc("/graphql", InfoHandler.Handler)
+	mux.HandleFunc("/graphql/schema.graphql", InfoHandler.Handler)
+	mux.HandleFunc("/api/openapi.json", InfoHandler.OpenAPIHandler)
+	mux.HandleFunc("/api/docs", InfoHandler.DocsHandler)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/healthz", InfoHandler.HealthHandler)
+	mux.HandleFunc("/readyz", InfoHandler.ReadyHandler)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err = server.Run(ctx, serverConfig, mux, server.Hooks{
+		OnDrain: InfoHandler.Drain,
+		OnShutdown: func(ctx context.Context) error {
+			return errors.Join(InfoHandler.Shutdown(ctx), shutdownTracing(ctx))
+		},
+	})
+	if err != nil {
+		logrus.Fatalf("Server stopped: %v", err)
+	}
+	logrus.Info("server stopped")
 }
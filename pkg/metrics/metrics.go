@@ -0,0 +1,296 @@
+package metrics
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "valhalla"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "API requests by query and HTTP status.",
+	}, []string{"query", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "API request latency by query and HTTP status.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 45},
+	}, []string{"query", "status"})
+
+	rpcRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_requests_total",
+		Help:      "Upstream JSON-RPC HTTP requests by chain and provider host.",
+	}, []string{"chain", "provider"})
+
+	rpcErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "Upstream JSON-RPC requests that failed in transport or returned a non 2xx status.",
+	}, []string{"chain", "provider", "reason"})
+
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Upstream JSON-RPC latency by chain and provider host.",
+		Buckets:   []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"chain", "provider"})
+
+	multicallBatchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "multicall_batch_size",
+		Help:      "Sub-calls per multicallView request.",
+		Buckets:   []float64{1, 2, 5, 10, 25, 50, 100, 250, 500},
+	})
+
+	multicallFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "multicall_failures_total",
+		Help:      "multicallView requests that failed as a whole.",
+	})
+
+	multicallSubcallFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "multicall_subcall_failures_total",
+		Help:      "Sub-calls that reported success=false inside a multicallView.",
+	})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Cache lookups by cache and result (hit or miss). The hit ratio is hit / (hit + miss).",
+	}, []string{"cache", "result"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests refused with 429 by rate limit tier.",
+	}, []string{"tier"})
+
+	streamSubscribers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_subscribers",
+		Help:      "Open event stream connections.",
+	})
+
+	streamUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "stream_updates_total",
+		Help:      "Changed results pushed to event stream topics, once per topic and block.",
+	}, []string{"query"})
+
+	alertsTriggered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "alerts_triggered_total",
+		Help:      "Webhook alert conditions that fired, by condition type.",
+	}, []string{"type"})
+
+	webhookAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_attempts_total",
+		Help:      "Webhook delivery attempts by outcome: delivered, retry or failed.",
+	}, []string{"outcome"})
+
+	providerHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rpc_provider_healthy",
+		Help:      "1 while the pooled client for a provider is considered healthy, 0 otherwise.",
+	}, []string{"chain", "provider"})
+
+	poolHealthyProviders = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rpc_pool_healthy_providers",
+		Help:      "Healthy providers in the client pool of a chain.",
+	}, []string{"chain"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		rpcRequestsTotal,
+		rpcErrorsTotal,
+		rpcDuration,
+		multicallBatchSize,
+		multicallFailures,
+		multicallSubcallFailures,
+		cacheLookups,
+		rateLimited,
+		streamSubscribers,
+		streamUpdates,
+		alertsTriggered,
+		webhookAttempts,
+		providerHealthy,
+		poolHealthyProviders,
+	)
+}
+
+// Handler serves every metric in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Instrument counts and times every request to next. label names the query
+// and must return a bounded set of values.
+func Instrument(label func(r *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			status := recorder.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			query, code := label(r), strconv.Itoa(status)
+			requestsTotal.WithLabelValues(query, code).Inc()
+			requestDuration.WithLabelValues(query, code).Observe(time.Since(start).Seconds())
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// ProviderLabel reduces an RPC URL to its host so keys in paths or query
+// strings never end up in a label.
+func ProviderLabel(rpcURL string) string {
+	parsed, err := url.Parse(rpcURL)
+	if err != nil || parsed.Host == "" {
+		return "invalid"
+	}
+	return parsed.Host
+}
+
+// RoundTripper records every upstream RPC request made through next. onResult
+// is told whether each request succeeded, for pool health tracking.
+func RoundTripper(chain string, rpcURL string, next http.RoundTripper, onResult func(ok bool)) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &rpcTransport{chain: chain, provider: ProviderLabel(rpcURL), next: next, onResult: onResult}
+}
+
+type rpcTransport struct {
+	chain    string
+	provider string
+	next     http.RoundTripper
+	onResult func(ok bool)
+}
+
+func (t *rpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	rpcRequestsTotal.WithLabelValues(t.chain, t.provider).Inc()
+	rpcDuration.WithLabelValues(t.chain, t.provider).Observe(time.Since(start).Seconds())
+
+	ok := true
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// Cancelled by our own deadline or the client, not the provider's fault
+		rpcErrorsTotal.WithLabelValues(t.chain, t.provider, "cancelled").Inc()
+		return resp, err
+	case err != nil:
+		rpcErrorsTotal.WithLabelValues(t.chain, t.provider, "transport").Inc()
+		ok = false
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		rpcErrorsTotal.WithLabelValues(t.chain, t.provider, "http_"+strconv.Itoa(resp.StatusCode)).Inc()
+		ok = false
+	}
+	if t.onResult != nil {
+		t.onResult(ok)
+	}
+
+	return resp, err
+}
+
+func ObserveMulticall(calls int, failedCalls int, err error) {
+	multicallBatchSize.Observe(float64(calls))
+	if err != nil {
+		multicallFailures.Inc()
+		return
+	}
+	multicallSubcallFailures.Add(float64(failedCalls))
+}
+
+func CacheHit(cache string) {
+	cacheLookups.WithLabelValues(cache, "hit").Inc()
+}
+
+func CacheMiss(cache string) {
+	cacheLookups.WithLabelValues(cache, "miss").Inc()
+}
+
+func RateLimited(tier string) {
+	rateLimited.WithLabelValues(tier).Inc()
+}
+
+func SetProviderHealth(chain string, rpcURL string, healthy bool) {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	providerHealthy.WithLabelValues(chain, ProviderLabel(rpcURL)).Set(value)
+}
+
+func SetPoolHealth(chain string, healthyProviders int) {
+	poolHealthyProviders.WithLabelValues(chain).Set(float64(healthyProviders))
+}
+
+func SetStreamSubscribers(subscribers int) {
+	streamSubscribers.Set(float64(subscribers))
+}
+
+func StreamUpdate(query string) {
+	streamUpdates.WithLabelValues(query).Inc()
+}
+
+func AlertTriggered(kind string) {
+	alertsTriggered.WithLabelValues(kind).Inc()
+}
+
+func WebhookAttempt(outcome string) {
+	webhookAttempts.WithLabelValues(outcome).Inc()
+}
@@ -0,0 +1,1342 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.1
+// 	protoc        (unknown)
+// source: valhalla/v1/info.proto
+
+// Info service of the Valhalla API. It runs the same queries as the HTTP API;
+// every uint256 amount is a decimal string and every address is checksummed
+// hex, so no precision is lost.
+
+package valhallav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetVersionRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetVersionRequest) Reset() {
+	*x = GetVersionRequest{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetVersionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVersionRequest) ProtoMessage() {}
+
+func (x *GetVersionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVersionRequest.ProtoReflect.Descriptor instead.
+func (*GetVersionRequest) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{0}
+}
+
+type GetVersionResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *GetVersionResponse) Reset() {
+	*x = GetVersionResponse{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetVersionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetVersionResponse) ProtoMessage() {}
+
+func (x *GetVersionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetVersionResponse.ProtoReflect.Descriptor instead.
+func (*GetVersionResponse) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetVersionResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type ListGenesisPoolsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChainId string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Genesis string `protobuf:"bytes,2,opt,name=genesis,proto3" json:"genesis,omitempty"`
+}
+
+func (x *ListGenesisPoolsRequest) Reset() {
+	*x = ListGenesisPoolsRequest{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGenesisPoolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGenesisPoolsRequest) ProtoMessage() {}
+
+func (x *ListGenesisPoolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGenesisPoolsRequest.ProtoReflect.Descriptor instead.
+func (*ListGenesisPoolsRequest) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListGenesisPoolsRequest) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *ListGenesisPoolsRequest) GetGenesis() string {
+	if x != nil {
+		return x.Genesis
+	}
+	return ""
+}
+
+type ListGenesisPoolsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pools []*GenesisPool `protobuf:"bytes,1,rep,name=pools,proto3" json:"pools,omitempty"`
+}
+
+func (x *ListGenesisPoolsResponse) Reset() {
+	*x = ListGenesisPoolsResponse{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGenesisPoolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGenesisPoolsResponse) ProtoMessage() {}
+
+func (x *ListGenesisPoolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGenesisPoolsResponse.ProtoReflect.Descriptor instead.
+func (*ListGenesisPoolsResponse) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListGenesisPoolsResponse) GetPools() []*GenesisPool {
+	if x != nil {
+		return x.Pools
+	}
+	return nil
+}
+
+type GenesisPool struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pid                 uint64         `protobuf:"varint,1,opt,name=pid,proto3" json:"pid,omitempty"`
+	Token               string         `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	DepFee              string         `protobuf:"bytes,3,opt,name=dep_fee,json=depFee,proto3" json:"dep_fee,omitempty"`
+	AllocPoint          string         `protobuf:"bytes,4,opt,name=alloc_point,json=allocPoint,proto3" json:"alloc_point,omitempty"`
+	LastRewardTime      string         `protobuf:"bytes,5,opt,name=last_reward_time,json=lastRewardTime,proto3" json:"last_reward_time,omitempty"`
+	AccValhallaPerShare string         `protobuf:"bytes,6,opt,name=acc_valhalla_per_share,json=accValhallaPerShare,proto3" json:"acc_valhalla_per_share,omitempty"`
+	IsStarted           bool           `protobuf:"varint,7,opt,name=is_started,json=isStarted,proto3" json:"is_started,omitempty"`
+	IsGauge             bool           `protobuf:"varint,8,opt,name=is_gauge,json=isGauge,proto3" json:"is_gauge,omitempty"`
+	Gauge               string         `protobuf:"bytes,9,opt,name=gauge,proto3" json:"gauge,omitempty"`
+	RewardTokens        []string       `protobuf:"bytes,10,rep,name=reward_tokens,json=rewardTokens,proto3" json:"reward_tokens,omitempty"`
+	PoolValhallaPerSec  string         `protobuf:"bytes,11,opt,name=pool_valhalla_per_sec,json=poolValhallaPerSec,proto3" json:"pool_valhalla_per_sec,omitempty"`
+	TokenMetadata       *TokenMetadata `protobuf:"bytes,12,opt,name=token_metadata,json=tokenMetadata,proto3" json:"token_metadata,omitempty"`
+}
+
+func (x *GenesisPool) Reset() {
+	*x = GenesisPool{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GenesisPool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GenesisPool) ProtoMessage() {}
+
+func (x *GenesisPool) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GenesisPool.ProtoReflect.Descriptor instead.
+func (*GenesisPool) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GenesisPool) GetPid() uint64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *GenesisPool) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *GenesisPool) GetDepFee() string {
+	if x != nil {
+		return x.DepFee
+	}
+	return ""
+}
+
+func (x *GenesisPool) GetAllocPoint() string {
+	if x != nil {
+		return x.AllocPoint
+	}
+	return ""
+}
+
+func (x *GenesisPool) GetLastRewardTime() string {
+	if x != nil {
+		return x.LastRewardTime
+	}
+	return ""
+}
+
+func (x *GenesisPool) GetAccValhallaPerShare() string {
+	if x != nil {
+		return x.AccValhallaPerShare
+	}
+	return ""
+}
+
+func (x *GenesisPool) GetIsStarted() bool {
+	if x != nil {
+		return x.IsStarted
+	}
+	return false
+}
+
+func (x *GenesisPool) GetIsGauge() bool {
+	if x != nil {
+		return x.IsGauge
+	}
+	return false
+}
+
+func (x *GenesisPool) GetGauge() string {
+	if x != nil {
+		return x.Gauge
+	}
+	return ""
+}
+
+func (x *GenesisPool) GetRewardTokens() []string {
+	if x != nil {
+		return x.RewardTokens
+	}
+	return nil
+}
+
+func (x *GenesisPool) GetPoolValhallaPerSec() string {
+	if x != nil {
+		return x.PoolValhallaPerSec
+	}
+	return ""
+}
+
+func (x *GenesisPool) GetTokenMetadata() *TokenMetadata {
+	if x != nil {
+		return x.TokenMetadata
+	}
+	return nil
+}
+
+// Token name, symbol and decimals with the local overrides applied. Fields a
+// token does not answer are empty, decimals is unset.
+type TokenMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address     string  `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Name        string  `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Symbol      string  `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Decimals    *uint32 `protobuf:"varint,4,opt,name=decimals,proto3,oneof" json:"decimals,omitempty"`
+	TotalSupply string  `protobuf:"bytes,5,opt,name=total_supply,json=totalSupply,proto3" json:"total_supply,omitempty"`
+	DisplayName string  `protobuf:"bytes,6,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Logo        string  `protobuf:"bytes,7,opt,name=logo,proto3" json:"logo,omitempty"`
+}
+
+func (x *TokenMetadata) Reset() {
+	*x = TokenMetadata{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenMetadata) ProtoMessage() {}
+
+func (x *TokenMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenMetadata.ProtoReflect.Descriptor instead.
+func (*TokenMetadata) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TokenMetadata) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *TokenMetadata) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TokenMetadata) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *TokenMetadata) GetDecimals() uint32 {
+	if x != nil && x.Decimals != nil {
+		return *x.Decimals
+	}
+	return 0
+}
+
+func (x *TokenMetadata) GetTotalSupply() string {
+	if x != nil {
+		return x.TotalSupply
+	}
+	return ""
+}
+
+func (x *TokenMetadata) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *TokenMetadata) GetLogo() string {
+	if x != nil {
+		return x.Logo
+	}
+	return ""
+}
+
+type PoolRef struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Staked token, looked up from the genesis when empty.
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Pid     uint64 `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
+}
+
+func (x *PoolRef) Reset() {
+	*x = PoolRef{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoolRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolRef) ProtoMessage() {}
+
+func (x *PoolRef) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolRef.ProtoReflect.Descriptor instead.
+func (*PoolRef) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PoolRef) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *PoolRef) GetPid() uint64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+type GetGenesisBalancesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChainId string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Genesis string `protobuf:"bytes,2,opt,name=genesis,proto3" json:"genesis,omitempty"`
+	// Every pool of the genesis when empty.
+	Pools []*PoolRef `protobuf:"bytes,3,rep,name=pools,proto3" json:"pools,omitempty"`
+	// Optional, user amounts are zero without it.
+	User string `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`
+}
+
+func (x *GetGenesisBalancesRequest) Reset() {
+	*x = GetGenesisBalancesRequest{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGenesisBalancesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGenesisBalancesRequest) ProtoMessage() {}
+
+func (x *GetGenesisBalancesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGenesisBalancesRequest.ProtoReflect.Descriptor instead.
+func (*GetGenesisBalancesRequest) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetGenesisBalancesRequest) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *GetGenesisBalancesRequest) GetGenesis() string {
+	if x != nil {
+		return x.Genesis
+	}
+	return ""
+}
+
+func (x *GetGenesisBalancesRequest) GetPools() []*PoolRef {
+	if x != nil {
+		return x.Pools
+	}
+	return nil
+}
+
+func (x *GetGenesisBalancesRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type PoolBalance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token          string         `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Pid            uint64         `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
+	GenesisBalance string         `protobuf:"bytes,3,opt,name=genesis_balance,json=genesisBalance,proto3" json:"genesis_balance,omitempty"`
+	UserBalance    string         `protobuf:"bytes,4,opt,name=user_balance,json=userBalance,proto3" json:"user_balance,omitempty"`
+	UserStake      string         `protobuf:"bytes,5,opt,name=user_stake,json=userStake,proto3" json:"user_stake,omitempty"`
+	UserReward     string         `protobuf:"bytes,6,opt,name=user_reward,json=userReward,proto3" json:"user_reward,omitempty"`
+	TokenMetadata  *TokenMetadata `protobuf:"bytes,7,opt,name=token_metadata,json=tokenMetadata,proto3" json:"token_metadata,omitempty"`
+}
+
+func (x *PoolBalance) Reset() {
+	*x = PoolBalance{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PoolBalance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolBalance) ProtoMessage() {}
+
+func (x *PoolBalance) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolBalance.ProtoReflect.Descriptor instead.
+func (*PoolBalance) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PoolBalance) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *PoolBalance) GetPid() uint64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *PoolBalance) GetGenesisBalance() string {
+	if x != nil {
+		return x.GenesisBalance
+	}
+	return ""
+}
+
+func (x *PoolBalance) GetUserBalance() string {
+	if x != nil {
+		return x.UserBalance
+	}
+	return ""
+}
+
+func (x *PoolBalance) GetUserStake() string {
+	if x != nil {
+		return x.UserStake
+	}
+	return ""
+}
+
+func (x *PoolBalance) GetUserReward() string {
+	if x != nil {
+		return x.UserReward
+	}
+	return ""
+}
+
+func (x *PoolBalance) GetTokenMetadata() *TokenMetadata {
+	if x != nil {
+		return x.TokenMetadata
+	}
+	return nil
+}
+
+type GetGenesisBalancesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pools []*PoolBalance `protobuf:"bytes,1,rep,name=pools,proto3" json:"pools,omitempty"`
+}
+
+func (x *GetGenesisBalancesResponse) Reset() {
+	*x = GetGenesisBalancesResponse{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGenesisBalancesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGenesisBalancesResponse) ProtoMessage() {}
+
+func (x *GetGenesisBalancesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGenesisBalancesResponse.ProtoReflect.Descriptor instead.
+func (*GetGenesisBalancesResponse) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetGenesisBalancesResponse) GetPools() []*PoolBalance {
+	if x != nil {
+		return x.Pools
+	}
+	return nil
+}
+
+type GetGenesisPairRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChainId string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Genesis string `protobuf:"bytes,2,opt,name=genesis,proto3" json:"genesis,omitempty"`
+	Pair    string `protobuf:"bytes,3,opt,name=pair,proto3" json:"pair,omitempty"`
+	// Read from the pair's token0 and token1 when empty.
+	Base  string `protobuf:"bytes,4,opt,name=base,proto3" json:"base,omitempty"`
+	Quote string `protobuf:"bytes,5,opt,name=quote,proto3" json:"quote,omitempty"`
+	// Looked up from the genesis when unset.
+	Pid  *uint64 `protobuf:"varint,6,opt,name=pid,proto3,oneof" json:"pid,omitempty"`
+	User string  `protobuf:"bytes,7,opt,name=user,proto3" json:"user,omitempty"`
+}
+
+func (x *GetGenesisPairRequest) Reset() {
+	*x = GetGenesisPairRequest{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGenesisPairRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGenesisPairRequest) ProtoMessage() {}
+
+func (x *GetGenesisPairRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGenesisPairRequest.ProtoReflect.Descriptor instead.
+func (*GetGenesisPairRequest) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetGenesisPairRequest) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *GetGenesisPairRequest) GetGenesis() string {
+	if x != nil {
+		return x.Genesis
+	}
+	return ""
+}
+
+func (x *GetGenesisPairRequest) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *GetGenesisPairRequest) GetBase() string {
+	if x != nil {
+		return x.Base
+	}
+	return ""
+}
+
+func (x *GetGenesisPairRequest) GetQuote() string {
+	if x != nil {
+		return x.Quote
+	}
+	return ""
+}
+
+func (x *GetGenesisPairRequest) GetPid() uint64 {
+	if x != nil && x.Pid != nil {
+		return *x.Pid
+	}
+	return 0
+}
+
+func (x *GetGenesisPairRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type GetGenesisPairResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Pair             string         `protobuf:"bytes,1,opt,name=pair,proto3" json:"pair,omitempty"`
+	TotalSupply      string         `protobuf:"bytes,2,opt,name=total_supply,json=totalSupply,proto3" json:"total_supply,omitempty"`
+	Pid              uint64         `protobuf:"varint,3,opt,name=pid,proto3" json:"pid,omitempty"`
+	BaseBalance      string         `protobuf:"bytes,4,opt,name=base_balance,json=baseBalance,proto3" json:"base_balance,omitempty"`
+	QuoteBalance     string         `protobuf:"bytes,5,opt,name=quote_balance,json=quoteBalance,proto3" json:"quote_balance,omitempty"`
+	GenesisBalance   string         `protobuf:"bytes,6,opt,name=genesis_balance,json=genesisBalance,proto3" json:"genesis_balance,omitempty"`
+	UserBalance      string         `protobuf:"bytes,7,opt,name=user_balance,json=userBalance,proto3" json:"user_balance,omitempty"`
+	UserStake        string         `protobuf:"bytes,8,opt,name=user_stake,json=userStake,proto3" json:"user_stake,omitempty"`
+	UserReward       string         `protobuf:"bytes,9,opt,name=user_reward,json=userReward,proto3" json:"user_reward,omitempty"`
+	UserBaseBalance  string         `protobuf:"bytes,10,opt,name=user_base_balance,json=userBaseBalance,proto3" json:"user_base_balance,omitempty"`
+	UserQuoteBalance string         `protobuf:"bytes,11,opt,name=user_quote_balance,json=userQuoteBalance,proto3" json:"user_quote_balance,omitempty"`
+	TokenMetadata    *TokenMetadata `protobuf:"bytes,12,opt,name=token_metadata,json=tokenMetadata,proto3" json:"token_metadata,omitempty"`
+	BaseMetadata     *TokenMetadata `protobuf:"bytes,13,opt,name=base_metadata,json=baseMetadata,proto3" json:"base_metadata,omitempty"`
+	QuoteMetadata    *TokenMetadata `protobuf:"bytes,14,opt,name=quote_metadata,json=quoteMetadata,proto3" json:"quote_metadata,omitempty"`
+}
+
+func (x *GetGenesisPairResponse) Reset() {
+	*x = GetGenesisPairResponse{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGenesisPairResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGenesisPairResponse) ProtoMessage() {}
+
+func (x *GetGenesisPairResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGenesisPairResponse.ProtoReflect.Descriptor instead.
+func (*GetGenesisPairResponse) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetGenesisPairResponse) GetPair() string {
+	if x != nil {
+		return x.Pair
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetTotalSupply() string {
+	if x != nil {
+		return x.TotalSupply
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetPid() uint64 {
+	if x != nil {
+		return x.Pid
+	}
+	return 0
+}
+
+func (x *GetGenesisPairResponse) GetBaseBalance() string {
+	if x != nil {
+		return x.BaseBalance
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetQuoteBalance() string {
+	if x != nil {
+		return x.QuoteBalance
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetGenesisBalance() string {
+	if x != nil {
+		return x.GenesisBalance
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetUserBalance() string {
+	if x != nil {
+		return x.UserBalance
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetUserStake() string {
+	if x != nil {
+		return x.UserStake
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetUserReward() string {
+	if x != nil {
+		return x.UserReward
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetUserBaseBalance() string {
+	if x != nil {
+		return x.UserBaseBalance
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetUserQuoteBalance() string {
+	if x != nil {
+		return x.UserQuoteBalance
+	}
+	return ""
+}
+
+func (x *GetGenesisPairResponse) GetTokenMetadata() *TokenMetadata {
+	if x != nil {
+		return x.TokenMetadata
+	}
+	return nil
+}
+
+func (x *GetGenesisPairResponse) GetBaseMetadata() *TokenMetadata {
+	if x != nil {
+		return x.BaseMetadata
+	}
+	return nil
+}
+
+func (x *GetGenesisPairResponse) GetQuoteMetadata() *TokenMetadata {
+	if x != nil {
+		return x.QuoteMetadata
+	}
+	return nil
+}
+
+type GetUserPortfolioRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChainId string `protobuf:"bytes,1,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	Genesis string `protobuf:"bytes,2,opt,name=genesis,proto3" json:"genesis,omitempty"`
+	User    string `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+}
+
+func (x *GetUserPortfolioRequest) Reset() {
+	*x = GetUserPortfolioRequest{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserPortfolioRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserPortfolioRequest) ProtoMessage() {}
+
+func (x *GetUserPortfolioRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserPortfolioRequest.ProtoReflect.Descriptor instead.
+func (*GetUserPortfolioRequest) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetUserPortfolioRequest) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *GetUserPortfolioRequest) GetGenesis() string {
+	if x != nil {
+		return x.Genesis
+	}
+	return ""
+}
+
+func (x *GetUserPortfolioRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+type GetUserPortfolioResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	User      string         `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Positions []*PoolBalance `protobuf:"bytes,2,rep,name=positions,proto3" json:"positions,omitempty"`
+	// Sum of the pending rewards of every position.
+	TotalReward string `protobuf:"bytes,3,opt,name=total_reward,json=totalReward,proto3" json:"total_reward,omitempty"`
+}
+
+func (x *GetUserPortfolioResponse) Reset() {
+	*x = GetUserPortfolioResponse{}
+	mi := &file_valhalla_v1_info_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserPortfolioResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserPortfolioResponse) ProtoMessage() {}
+
+func (x *GetUserPortfolioResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_valhalla_v1_info_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserPortfolioResponse.ProtoReflect.Descriptor instead.
+func (*GetUserPortfolioResponse) Descriptor() ([]byte, []int) {
+	return file_valhalla_v1_info_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetUserPortfolioResponse) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *GetUserPortfolioResponse) GetPositions() []*PoolBalance {
+	if x != nil {
+		return x.Positions
+	}
+	return nil
+}
+
+func (x *GetUserPortfolioResponse) GetTotalReward() string {
+	if x != nil {
+		return x.TotalReward
+	}
+	return ""
+}
+
+var File_valhalla_v1_info_proto protoreflect.FileDescriptor
+
+var file_valhalla_v1_info_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2f, 0x76, 0x31, 0x2f, 0x69, 0x6e,
+	0x66, 0x6f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c,
+	0x6c, 0x61, 0x2e, 0x76, 0x31, 0x22, 0x13, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2e, 0x0a, 0x12, 0x47, 0x65,
+	0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x4e, 0x0a, 0x17, 0x4c, 0x69,
+	0x73, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x50, 0x6f, 0x6f, 0x6c, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x64,
+	0x12, 0x18, 0x0a, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x22, 0x4a, 0x0a, 0x18, 0x4c, 0x69,
+	0x73, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x50, 0x6f, 0x6f, 0x6c, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x05, 0x70, 0x6f, 0x6f, 0x6c, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61,
+	0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x50, 0x6f, 0x6f, 0x6c, 0x52,
+	0x05, 0x70, 0x6f, 0x6f, 0x6c, 0x73, 0x22, 0xb9, 0x03, 0x0a, 0x0b, 0x47, 0x65, 0x6e, 0x65, 0x73,
+	0x69, 0x73, 0x50, 0x6f, 0x6f, 0x6c, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x03, 0x70, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x17,
+	0x0a, 0x07, 0x64, 0x65, 0x70, 0x5f, 0x66, 0x65, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x64, 0x65, 0x70, 0x46, 0x65, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x63,
+	0x5f, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x61, 0x6c,
+	0x6c, 0x6f, 0x63, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x6c, 0x61, 0x73, 0x74,
+	0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0e, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x54, 0x69,
+	0x6d, 0x65, 0x12, 0x33, 0x0a, 0x16, 0x61, 0x63, 0x63, 0x5f, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c,
+	0x6c, 0x61, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73, 0x68, 0x61, 0x72, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x13, 0x61, 0x63, 0x63, 0x56, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x50,
+	0x65, 0x72, 0x53, 0x68, 0x61, 0x72, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x69, 0x73, 0x5f, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x65, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x69, 0x73, 0x53,
+	0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x69, 0x73, 0x5f, 0x67, 0x61, 0x75,
+	0x67, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69, 0x73, 0x47, 0x61, 0x75, 0x67,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x61, 0x75, 0x67, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x67, 0x61, 0x75, 0x67, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x77, 0x61, 0x72,
+	0x64, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c,
+	0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x73, 0x12, 0x31, 0x0a, 0x15,
+	0x70, 0x6f, 0x6f, 0x6c, 0x5f, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x5f, 0x70, 0x65,
+	0x72, 0x5f, 0x73, 0x65, 0x63, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x70, 0x6f, 0x6f,
+	0x6c, 0x56, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x12,
+	0x41, 0x0a, 0x0e, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c,
+	0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x4d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x52, 0x0d, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x22, 0xdd, 0x01, 0x0a, 0x0d, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x4d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12,
+	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x73, 0x79, 0x6d, 0x62, 0x6f, 0x6c, 0x12, 0x1f, 0x0a, 0x08, 0x64, 0x65,
+	0x63, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x48, 0x00, 0x52, 0x08,
+	0x64, 0x65, 0x63, 0x69, 0x6d, 0x61, 0x6c, 0x73, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a, 0x0c, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x73, 0x75, 0x70, 0x70, 0x6c, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x75, 0x70, 0x70, 0x6c, 0x79, 0x12, 0x21,
+	0x0a, 0x0c, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x4e, 0x61, 0x6d,
+	0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x67, 0x6f, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6c, 0x6f, 0x67, 0x6f, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x64, 0x65, 0x63, 0x69, 0x6d, 0x61,
+	0x6c, 0x73, 0x22, 0x35, 0x0a, 0x07, 0x50, 0x6f, 0x6f, 0x6c, 0x52, 0x65, 0x66, 0x12, 0x18, 0x0a,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x70, 0x69, 0x64, 0x22, 0x90, 0x01, 0x0a, 0x19, 0x47, 0x65,
+	0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x69, 0x6e,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e,
+	0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x12, 0x2a, 0x0a, 0x05,
+	0x70, 0x6f, 0x6f, 0x6c, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x76, 0x61,
+	0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x52, 0x65,
+	0x66, 0x52, 0x05, 0x70, 0x6f, 0x6f, 0x6c, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x84, 0x02, 0x0a,
+	0x0b, 0x50, 0x6f, 0x6f, 0x6c, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x6f, 0x6b,
+	0x65, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x03, 0x70, 0x69, 0x64, 0x12, 0x27, 0x0a, 0x0f, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x5f,
+	0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x67,
+	0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a,
+	0x0c, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x0b, 0x75, 0x73, 0x65, 0x72, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65,
+	0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x75, 0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x6b, 0x65, 0x12,
+	0x1f, 0x0a, 0x0b, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x75, 0x73, 0x65, 0x72, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64,
+	0x12, 0x41, 0x0a, 0x0e, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61,
+	0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x4d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x52, 0x0d, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x4d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x22, 0x4c, 0x0a, 0x1a, 0x47, 0x65, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69,
+	0x73, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x2e, 0x0a, 0x05, 0x70, 0x6f, 0x6f, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x18, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x50,
+	0x6f, 0x6f, 0x6c, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x05, 0x70, 0x6f, 0x6f, 0x6c,
+	0x73, 0x22, 0xbd, 0x01, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73,
+	0x50, 0x61, 0x69, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63,
+	0x68, 0x61, 0x69, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63,
+	0x68, 0x61, 0x69, 0x6e, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x69, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x70, 0x61, 0x69, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x62, 0x61, 0x73, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x62, 0x61, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x71, 0x75, 0x6f, 0x74,
+	0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x12, 0x15,
+	0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x03, 0x70,
+	0x69, 0x64, 0x88, 0x01, 0x01, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x70, 0x69,
+	0x64, 0x22, 0xd6, 0x04, 0x0a, 0x16, 0x47, 0x65, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73,
+	0x50, 0x61, 0x69, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x70, 0x61, 0x69, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x69, 0x72,
+	0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x73, 0x75, 0x70, 0x70, 0x6c, 0x79,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x53, 0x75, 0x70,
+	0x70, 0x6c, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x03, 0x70, 0x69, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x62, 0x61, 0x73, 0x65, 0x5f, 0x62, 0x61,
+	0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x62, 0x61, 0x73,
+	0x65, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x71, 0x75, 0x6f, 0x74,
+	0x65, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x71, 0x75, 0x6f, 0x74, 0x65, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x27, 0x0a,
+	0x0f, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x42,
+	0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x62,
+	0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x75, 0x73,
+	0x65, 0x72, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x73, 0x65,
+	0x72, 0x5f, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x75,
+	0x73, 0x65, 0x72, 0x53, 0x74, 0x61, 0x6b, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x75, 0x73, 0x65, 0x72,
+	0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x75,
+	0x73, 0x65, 0x72, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x75, 0x73, 0x65,
+	0x72, 0x5f, 0x62, 0x61, 0x73, 0x65, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x75, 0x73, 0x65, 0x72, 0x42, 0x61, 0x73, 0x65, 0x42, 0x61,
+	0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x2c, 0x0a, 0x12, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x71, 0x75,
+	0x6f, 0x74, 0x65, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x10, 0x75, 0x73, 0x65, 0x72, 0x51, 0x75, 0x6f, 0x74, 0x65, 0x42, 0x61, 0x6c, 0x61,
+	0x6e, 0x63, 0x65, 0x12, 0x41, 0x0a, 0x0e, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x5f, 0x6d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x76, 0x61,
+	0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x4d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x0d, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x4d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x3f, 0x0a, 0x0d, 0x62, 0x61, 0x73, 0x65, 0x5f, 0x6d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x0c, 0x62, 0x61, 0x73, 0x65, 0x4d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x41, 0x0a, 0x0e, 0x71, 0x75, 0x6f, 0x74, 0x65,
+	0x5f, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x6f,
+	0x6b, 0x65, 0x6e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x0d, 0x71, 0x75, 0x6f,
+	0x74, 0x65, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x22, 0x62, 0x0a, 0x17, 0x47, 0x65,
+	0x74, 0x55, 0x73, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x66, 0x6f, 0x6c, 0x69, 0x6f, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x64,
+	0x12, 0x18, 0x0a, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x67, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73,
+	0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x22, 0x89,
+	0x01, 0x0a, 0x18, 0x47, 0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x66, 0x6f,
+	0x6c, 0x69, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x75,
+	0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12,
+	0x36, 0x0a, 0x09, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x18, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31,
+	0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x09, 0x70, 0x6f,
+	0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c,
+	0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x32, 0xe0, 0x03, 0x0a, 0x0b, 0x49,
+	0x6e, 0x66, 0x6f, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4d, 0x0a, 0x0a, 0x47, 0x65,
+	0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61,
+	0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61,
+	0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5f, 0x0a, 0x10, 0x4c, 0x69, 0x73,
+	0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x50, 0x6f, 0x6f, 0x6c, 0x73, 0x12, 0x24, 0x2e,
+	0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x50, 0x6f, 0x6f, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76,
+	0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x50, 0x6f, 0x6f,
+	0x6c, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x65, 0x0a, 0x12, 0x47, 0x65,
+	0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x73,
+	0x12, 0x26, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x65, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x27, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61,
+	0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69,
+	0x73, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x59, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x50,
+	0x61, 0x69, 0x72, 0x12, 0x22, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73, 0x50, 0x61, 0x69, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c,
+	0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x47, 0x65, 0x6e, 0x65, 0x73, 0x69, 0x73,
+	0x50, 0x61, 0x69, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5f, 0x0a, 0x10,
+	0x47, 0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x66, 0x6f, 0x6c, 0x69, 0x6f,
+	0x12, 0x24, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74, 0x66, 0x6f, 0x6c, 0x69, 0x6f, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x25, 0x2e, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c,
+	0x61, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x55, 0x73, 0x65, 0x72, 0x50, 0x6f, 0x72, 0x74,
+	0x66, 0x6f, 0x6c, 0x69, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x40, 0x5a,
+	0x3e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x46, 0x75, 0x64, 0x67,
+	0x79, 0x44, 0x52, 0x53, 0x2f, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x2d, 0x61, 0x70,
+	0x69, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x70, 0x62, 0x2f, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c,
+	0x61, 0x2f, 0x76, 0x31, 0x3b, 0x76, 0x61, 0x6c, 0x68, 0x61, 0x6c, 0x6c, 0x61, 0x76, 0x31, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_valhalla_v1_info_proto_rawDescOnce sync.Once
+	file_valhalla_v1_info_proto_rawDescData = file_valhalla_v1_info_proto_rawDesc
+)
+
+func file_valhalla_v1_info_proto_rawDescGZIP() []byte {
+	file_valhalla_v1_info_proto_rawDescOnce.Do(func() {
+		file_valhalla_v1_info_proto_rawDescData = protoimpl.X.CompressGZIP(file_valhalla_v1_info_proto_rawDescData)
+	})
+	return file_valhalla_v1_info_proto_rawDescData
+}
+
+var file_valhalla_v1_info_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_valhalla_v1_info_proto_goTypes = []any{
+	(*GetVersionRequest)(nil),          // 0: valhalla.v1.GetVersionRequest
+	(*GetVersionResponse)(nil),         // 1: valhalla.v1.GetVersionResponse
+	(*ListGenesisPoolsRequest)(nil),    // 2: valhalla.v1.ListGenesisPoolsRequest
+	(*ListGenesisPoolsResponse)(nil),   // 3: valhalla.v1.ListGenesisPoolsResponse
+	(*GenesisPool)(nil),                // 4: valhalla.v1.GenesisPool
+	(*TokenMetadata)(nil),              // 5: valhalla.v1.TokenMetadata
+	(*PoolRef)(nil),                    // 6: valhalla.v1.PoolRef
+	(*GetGenesisBalancesRequest)(nil),  // 7: valhalla.v1.GetGenesisBalancesRequest
+	(*PoolBalance)(nil),                // 8: valhalla.v1.PoolBalance
+	(*GetGenesisBalancesResponse)(nil), // 9: valhalla.v1.GetGenesisBalancesResponse
+	(*GetGenesisPairRequest)(nil),      // 10: valhalla.v1.GetGenesisPairRequest
+	(*GetGenesisPairResponse)(nil),     // 11: valhalla.v1.GetGenesisPairResponse
+	(*GetUserPortfolioRequest)(nil),    // 12: valhalla.v1.GetUserPortfolioRequest
+	(*GetUserPortfolioResponse)(nil),   // 13: valhalla.v1.GetUserPortfolioResponse
+}
+var file_valhalla_v1_info_proto_depIdxs = []int32{
+	4,  // 0: valhalla.v1.ListGenesisPoolsResponse.pools:type_name -> valhalla.v1.GenesisPool
+	5,  // 1: valhalla.v1.GenesisPool.token_metadata:type_name -> valhalla.v1.TokenMetadata
+	6,  // 2: valhalla.v1.GetGenesisBalancesRequest.pools:type_name -> valhalla.v1.PoolRef
+	5,  // 3: valhalla.v1.PoolBalance.token_metadata:type_name -> valhalla.v1.TokenMetadata
+	8,  // 4: valhalla.v1.GetGenesisBalancesResponse.pools:type_name -> valhalla.v1.PoolBalance
+	5,  // 5: valhalla.v1.GetGenesisPairResponse.token_metadata:type_name -> valhalla.v1.TokenMetadata
+	5,  // 6: valhalla.v1.GetGenesisPairResponse.base_metadata:type_name -> valhalla.v1.TokenMetadata
+	5,  // 7: valhalla.v1.GetGenesisPairResponse.quote_metadata:type_name -> valhalla.v1.TokenMetadata
+	8,  // 8: valhalla.v1.GetUserPortfolioResponse.positions:type_name -> valhalla.v1.PoolBalance
+	0,  // 9: valhalla.v1.InfoService.GetVersion:input_type -> valhalla.v1.GetVersionRequest
+	2,  // 10: valhalla.v1.InfoService.ListGenesisPools:input_type -> valhalla.v1.ListGenesisPoolsRequest
+	7,  // 11: valhalla.v1.InfoService.GetGenesisBalances:input_type -> valhalla.v1.GetGenesisBalancesRequest
+	10, // 12: valhalla.v1.InfoService.GetGenesisPair:input_type -> valhalla.v1.GetGenesisPairRequest
+	12, // 13: valhalla.v1.InfoService.GetUserPortfolio:input_type -> valhalla.v1.GetUserPortfolioRequest
+	1,  // 14: valhalla.v1.InfoService.GetVersion:output_type -> valhalla.v1.GetVersionResponse
+	3,  // 15: valhalla.v1.InfoService.ListGenesisPools:output_type -> valhalla.v1.ListGenesisPoolsResponse
+	9,  // 16: valhalla.v1.InfoService.GetGenesisBalances:output_type -> valhalla.v1.GetGenesisBalancesResponse
+	11, // 17: valhalla.v1.InfoService.GetGenesisPair:output_type -> valhalla.v1.GetGenesisPairResponse
+	13, // 18: valhalla.v1.InfoService.GetUserPortfolio:output_type -> valhalla.v1.GetUserPortfolioResponse
+	14, // [14:19] is the sub-list for method output_type
+	9,  // [9:14] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_valhalla_v1_info_proto_init() }
+func file_valhalla_v1_info_proto_init() {
+	if File_valhalla_v1_info_proto != nil {
+		return
+	}
+	file_valhalla_v1_info_proto_msgTypes[5].OneofWrappers = []any{}
+	file_valhalla_v1_info_proto_msgTypes[10].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_valhalla_v1_info_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_valhalla_v1_info_proto_goTypes,
+		DependencyIndexes: file_valhalla_v1_info_proto_depIdxs,
+		MessageInfos:      file_valhalla_v1_info_proto_msgTypes,
+	}.Build()
+	File_valhalla_v1_info_proto = out.File
+	file_valhalla_v1_info_proto_rawDesc = nil
+	file_valhalla_v1_info_proto_goTypes = nil
+	file_valhalla_v1_info_proto_depIdxs = nil
+}
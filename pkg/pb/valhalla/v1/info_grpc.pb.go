@@ -0,0 +1,289 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: valhalla/v1/info.proto
+
+// Info service of the Valhalla API. It runs the same queries as the HTTP API;
+// every uint256 amount is a decimal string and every address is checksummed
+// hex, so no precision is lost.
+
+package valhallav1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	InfoService_GetVersion_FullMethodName         = "/valhalla.v1.InfoService/GetVersion"
+	InfoService_ListGenesisPools_FullMethodName   = "/valhalla.v1.InfoService/ListGenesisPools"
+	InfoService_GetGenesisBalances_FullMethodName = "/valhalla.v1.InfoService/GetGenesisBalances"
+	InfoService_GetGenesisPair_FullMethodName     = "/valhalla.v1.InfoService/GetGenesisPair"
+	InfoService_GetUserPortfolio_FullMethodName   = "/valhalla.v1.InfoService/GetUserPortfolio"
+)
+
+// InfoServiceClient is the client API for InfoService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type InfoServiceClient interface {
+	// API version.
+	GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error)
+	// Configuration of every pool of a genesis contract.
+	ListGenesisPools(ctx context.Context, in *ListGenesisPoolsRequest, opts ...grpc.CallOption) (*ListGenesisPoolsResponse, error)
+	// Genesis and user balances, stakes and pending rewards for a set of pools.
+	GetGenesisBalances(ctx context.Context, in *GetGenesisBalancesRequest, opts ...grpc.CallOption) (*GetGenesisBalancesResponse, error)
+	// Pair reserves, genesis stake and user position for a single pool.
+	GetGenesisPair(ctx context.Context, in *GetGenesisPairRequest, opts ...grpc.CallOption) (*GetGenesisPairResponse, error)
+	// Every pool of a genesis in which the user has a stake, a pending reward
+	// or a wallet balance of the staked token.
+	GetUserPortfolio(ctx context.Context, in *GetUserPortfolioRequest, opts ...grpc.CallOption) (*GetUserPortfolioResponse, error)
+}
+
+type infoServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInfoServiceClient(cc grpc.ClientConnInterface) InfoServiceClient {
+	return &infoServiceClient{cc}
+}
+
+func (c *infoServiceClient) GetVersion(ctx context.Context, in *GetVersionRequest, opts ...grpc.CallOption) (*GetVersionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetVersionResponse)
+	err := c.cc.Invoke(ctx, InfoService_GetVersion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *infoServiceClient) ListGenesisPools(ctx context.Context, in *ListGenesisPoolsRequest, opts ...grpc.CallOption) (*ListGenesisPoolsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGenesisPoolsResponse)
+	err := c.cc.Invoke(ctx, InfoService_ListGenesisPools_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *infoServiceClient) GetGenesisBalances(ctx context.Context, in *GetGenesisBalancesRequest, opts ...grpc.CallOption) (*GetGenesisBalancesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetGenesisBalancesResponse)
+	err := c.cc.Invoke(ctx, InfoService_GetGenesisBalances_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *infoServiceClient) GetGenesisPair(ctx context.Context, in *GetGenesisPairRequest, opts ...grpc.CallOption) (*GetGenesisPairResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetGenesisPairResponse)
+	err := c.cc.Invoke(ctx, InfoService_GetGenesisPair_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *infoServiceClient) GetUserPortfolio(ctx context.Context, in *GetUserPortfolioRequest, opts ...grpc.CallOption) (*GetUserPortfolioResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserPortfolioResponse)
+	err := c.cc.Invoke(ctx, InfoService_GetUserPortfolio_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// InfoServiceServer is the server API for InfoService service.
+// All implementations must embed UnimplementedInfoServiceServer
+// for forward compatibility.
+type InfoServiceServer interface {
+	// API version.
+	GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error)
+	// Configuration of every pool of a genesis contract.
+	ListGenesisPools(context.Context, *ListGenesisPoolsRequest) (*ListGenesisPoolsResponse, error)
+	// Genesis and user balances, stakes and pending rewards for a set of pools.
+	GetGenesisBalances(context.Context, *GetGenesisBalancesRequest) (*GetGenesisBalancesResponse, error)
+	// Pair reserves, genesis stake and user position for a single pool.
+	GetGenesisPair(context.Context, *GetGenesisPairRequest) (*GetGenesisPairResponse, error)
+	// Every pool of a genesis in which the user has a stake, a pending reward
+	// or a wallet balance of the staked token.
+	GetUserPortfolio(context.Context, *GetUserPortfolioRequest) (*GetUserPortfolioResponse, error)
+	mustEmbedUnimplementedInfoServiceServer()
+}
+
+// UnimplementedInfoServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedInfoServiceServer struct{}
+
+func (UnimplementedInfoServiceServer) GetVersion(context.Context, *GetVersionRequest) (*GetVersionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVersion not implemented")
+}
+func (UnimplementedInfoServiceServer) ListGenesisPools(context.Context, *ListGenesisPoolsRequest) (*ListGenesisPoolsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGenesisPools not implemented")
+}
+func (UnimplementedInfoServiceServer) GetGenesisBalances(context.Context, *GetGenesisBalancesRequest) (*GetGenesisBalancesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGenesisBalances not implemented")
+}
+func (UnimplementedInfoServiceServer) GetGenesisPair(context.Context, *GetGenesisPairRequest) (*GetGenesisPairResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGenesisPair not implemented")
+}
+func (UnimplementedInfoServiceServer) GetUserPortfolio(context.Context, *GetUserPortfolioRequest) (*GetUserPortfolioResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserPortfolio not implemented")
+}
+func (UnimplementedInfoServiceServer) mustEmbedUnimplementedInfoServiceServer() {}
+func (UnimplementedInfoServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeInfoServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InfoServiceServer will
+// result in compilation errors.
+type UnsafeInfoServiceServer interface {
+	mustEmbedUnimplementedInfoServiceServer()
+}
+
+func RegisterInfoServiceServer(s grpc.ServiceRegistrar, srv InfoServiceServer) {
+	// If the following call pancis, it indicates UnimplementedInfoServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&InfoService_ServiceDesc, srv)
+}
+
+func _InfoService_GetVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InfoServiceServer).GetVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InfoService_GetVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InfoServiceServer).GetVersion(ctx, req.(*GetVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InfoService_ListGenesisPools_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGenesisPoolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InfoServiceServer).ListGenesisPools(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InfoService_ListGenesisPools_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InfoServiceServer).ListGenesisPools(ctx, req.(*ListGenesisPoolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InfoService_GetGenesisBalances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGenesisBalancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InfoServiceServer).GetGenesisBalances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InfoService_GetGenesisBalances_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InfoServiceServer).GetGenesisBalances(ctx, req.(*GetGenesisBalancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InfoService_GetGenesisPair_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGenesisPairRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InfoServiceServer).GetGenesisPair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InfoService_GetGenesisPair_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InfoServiceServer).GetGenesisPair(ctx, req.(*GetGenesisPairRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InfoService_GetUserPortfolio_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserPortfolioRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InfoServiceServer).GetUserPortfolio(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InfoService_GetUserPortfolio_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InfoServiceServer).GetUserPortfolio(ctx, req.(*GetUserPortfolioRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// InfoService_ServiceDesc is the grpc.ServiceDesc for InfoService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var InfoService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "valhalla.v1.InfoService",
+	HandlerType: (*InfoServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetVersion",
+			Handler:    _InfoService_GetVersion_Handler,
+		},
+		{
+			MethodName: "ListGenesisPools",
+			Handler:    _InfoService_ListGenesisPools_Handler,
+		},
+		{
+			MethodName: "GetGenesisBalances",
+			Handler:    _InfoService_GetGenesisBalances_Handler,
+		},
+		{
+			MethodName: "GetGenesisPair",
+			Handler:    _InfoService_GetGenesisPair_Handler,
+		},
+		{
+			MethodName: "GetUserPortfolio",
+			Handler:    _InfoService_GetUserPortfolio_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "valhalla/v1/info.proto",
+}
@@ -0,0 +1,307 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const AnonymousTier = "anonymous"
+
+// sweepInterval is how often buckets that refilled completely are dropped.
+const sweepInterval = time.Minute
+
+var (
+	ErrMissingKey = errors.New("an API key is required, send it in X-API-Key or Authorization: Bearer")
+	ErrInvalidKey = errors.New("unknown API key")
+)
+
+// Tier is a token bucket of Burst tokens refilled at Rate tokens per second.
+// A request spends as many tokens as it costs. A Rate of 0 is unlimited.
+type Tier struct {
+	Rate  float64 `json:"rate"`
+	Burst float64 `json:"burst"`
+}
+
+// Key is an API key. Name identifies the client in logs and metrics, the key
+// itself is never logged.
+type Key struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Tier string `json:"tier"`
+}
+
+// Config is read from the JSON file named by RATE_LIMIT_CONFIG. Requests
+// without a key use the anonymous tier and are limited per IP, requests with
+// a key use the key's tier and are limited per key.
+//
+// With TrustProxy the client IP is read from X-Forwarded-For. Every proxy
+// appends the address it received the request from, so only the last
+// ProxyHops entries were written by trusted proxies, anything before them
+// comes from the client. ProxyHops defaults to 1.
+type Config struct {
+	Disabled   bool            `json:"disabled"`
+	RequireKey bool            `json:"requireKey"`
+	TrustProxy bool            `json:"trustProxy"`
+	ProxyHops  int             `json:"proxyHops"`
+	Tiers      map[string]Tier `json:"tiers"`
+	Keys       []Key           `json:"keys"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Tiers: map[string]Tier{
+			AnonymousTier: {Rate: 2, Burst: 60},
+			"standard":    {Rate: 10, Burst: 300},
+			"unlimited":   {Rate: 0},
+		},
+	}
+}
+
+// LoadConfig reads RATE_LIMIT_CONFIG over the defaults, then appends the keys
+// of API_KEYS, a comma separated list of name:tier:key, so keys can live in
+// the environment rather than in a file. RATE_LIMIT_DISABLED=true turns
+// limiting off.
+func LoadConfig() (Config, error) {
+	config := DefaultConfig()
+
+	if path := os.Getenv("RATE_LIMIT_CONFIG"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read rate limit config: %v", err)
+		}
+		var file Config
+		if err := json.Unmarshal(data, &file); err != nil {
+			return Config{}, fmt.Errorf("failed to parse rate limit config: %v", err)
+		}
+		for name, tier := range config.Tiers {
+			if _, ok := file.Tiers[name]; !ok {
+				if file.Tiers == nil {
+					file.Tiers = make(map[string]Tier)
+				}
+				file.Tiers[name] = tier
+			}
+		}
+		config = file
+	}
+
+	if value := os.Getenv("API_KEYS"); value != "" {
+		for _, entry := range strings.Split(value, ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+			if len(parts) != 3 {
+				return Config{}, fmt.Errorf("invalid API_KEYS entry, expected name:tier:key")
+			}
+			config.Keys = append(config.Keys, Key{Name: parts[0], Tier: parts[1], Key: parts[2]})
+		}
+	}
+
+	if disabled, err := strconv.ParseBool(os.Getenv("RATE_LIMIT_DISABLED")); err == nil {
+		config.Disabled = disabled
+	}
+
+	return config, nil
+}
+
+// Client is who a request is charged to.
+type Client struct {
+	Name   string
+	Tier   string
+	bucket string
+}
+
+// Bucket is what the client is limited as, its key or, without one, its IP.
+func (c Client) Bucket() string {
+	return c.bucket
+}
+
+// Decision is the outcome of Allow. Limit and Remaining are in tokens, Reset
+// is when the bucket is full again and RetryAfter when the request would fit.
+type Decision struct {
+	Allowed    bool
+	Unlimited  bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// WriteHeaders sets the X-RateLimit-* headers, and Retry-After when denied.
+func (d Decision) WriteHeaders(header http.Header) {
+	if d.Unlimited {
+		return
+	}
+	header.Set("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.Itoa(seconds(d.Reset)))
+	if !d.Allowed {
+		header.Set("Retry-After", strconv.Itoa(max(1, seconds(d.RetryAfter))))
+	}
+}
+
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+type bucket struct {
+	tier   Tier
+	tokens float64
+	last   time.Time
+}
+
+type Limiter struct {
+	config Config
+	keys   map[[sha256.Size]byte]Key
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// New checks that every key names a known tier and indexes keys by hash.
+func New(config Config) (*Limiter, error) {
+	if _, ok := config.Tiers[AnonymousTier]; !ok {
+		return nil, fmt.Errorf("rate limit config has no %s tier", AnonymousTier)
+	}
+
+	keys := make(map[[sha256.Size]byte]Key, len(config.Keys))
+	for i, key := range config.Keys {
+		if key.Key == "" {
+			return nil, fmt.Errorf("API key %d (%s) is empty", i, key.Name)
+		}
+		if _, ok := config.Tiers[key.Tier]; !ok {
+			return nil, fmt.Errorf("API key %s uses unknown tier %s", key.Name, key.Tier)
+		}
+		if key.Name == "" {
+			key.Name = fmt.Sprintf("key-%d", i)
+		}
+		keys[sha256.Sum256([]byte(key.Key))] = key
+	}
+
+	return &Limiter{
+		config:  config,
+		keys:    keys,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}, nil
+}
+
+// RequiresKey reports whether requests without an API key are refused.
+func (l *Limiter) RequiresKey() bool {
+	return l.config.RequireKey
+}
+
+// Identify resolves the API key of r, from X-API-Key or a bearer token, or
+// falls back to the client IP. Keys are compared by hash.
+func (l *Limiter) Identify(r *http.Request) (Client, error) {
+	apiKey := r.Header.Get("X-API-Key")
+	if apiKey == "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			apiKey = strings.TrimSpace(token)
+		}
+	}
+
+	if apiKey == "" {
+		if l.config.RequireKey {
+			return Client{}, ErrMissingKey
+		}
+		ip := l.clientIP(r)
+		return Client{Name: ip, Tier: AnonymousTier, bucket: "ip:" + ip}, nil
+	}
+
+	key, ok := l.keys[sha256.Sum256([]byte(apiKey))]
+	if !ok {
+		return Client{}, ErrInvalidKey
+	}
+	return Client{Name: key.Name, Tier: key.Tier, bucket: "key:" + key.Name}, nil
+}
+
+// clientIP is the X-Forwarded-For entry added by the outermost trusted proxy
+// when the proxies in front are trusted, the connection's address otherwise.
+// Entries left of it are set by the client and ignored.
+func (l *Limiter) clientIP(r *http.Request) string {
+	if l.config.TrustProxy {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(header, ",") {
+				if hop = strings.TrimSpace(hop); hop != "" {
+					hops = append(hops, hop)
+				}
+			}
+		}
+		if len(hops) > 0 {
+			return hops[max(0, len(hops)-max(1, l.config.ProxyHops))]
+		}
+		if realIP := r.Header.Get("X-Real-Ip"); realIP != "" {
+			return realIP
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Allow spends cost tokens from the client's bucket. A cost above the burst
+// is charged as the whole burst, so large requests drain the bucket rather
+// than being refused forever.
+func (l *Limiter) Allow(client Client, cost float64) Decision {
+	tier := l.config.Tiers[client.Tier]
+	if l.config.Disabled || tier.Rate <= 0 {
+		return Decision{Allowed: true, Unlimited: true}
+	}
+	cost = math.Min(math.Max(cost, 1), tier.Burst)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[client.bucket]
+	if !ok {
+		b = &bucket{tier: tier, tokens: tier.Burst, last: now}
+		l.buckets[client.bucket] = b
+	}
+	b.tokens = math.Min(tier.Burst, b.tokens+now.Sub(b.last).Seconds()*tier.Rate)
+	b.last = now
+
+	decision := Decision{Limit: int(tier.Burst)}
+	if b.tokens >= cost {
+		b.tokens -= cost
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = refill(cost-b.tokens, tier.Rate)
+	}
+	decision.Remaining = int(b.tokens)
+	decision.Reset = refill(tier.Burst-b.tokens, tier.Rate)
+	return decision
+}
+
+func refill(tokens float64, rate float64) time.Duration {
+	return time.Duration(tokens / rate * float64(time.Second))
+}
+
+// sweep drops buckets that would be full by now, they are recreated full.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for id, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.tier.Rate >= b.tier.Burst {
+			delete(l.buckets, id)
+		}
+	}
+}
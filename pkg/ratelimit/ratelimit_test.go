@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		forwarded []string
+		want      string
+	}{
+		{"untrusted proxy", Config{}, []string{"203.0.113.7"}, "10.0.0.1"},
+		{"no header", Config{TrustProxy: true}, nil, "10.0.0.1"},
+		{"single hop", Config{TrustProxy: true}, []string{"203.0.113.7"}, "203.0.113.7"},
+		{"spoofed entries", Config{TrustProxy: true}, []string{"1.2.3.4, 5.6.7.8, 203.0.113.7"}, "203.0.113.7"},
+		{"repeated headers", Config{TrustProxy: true}, []string{"1.2.3.4", "203.0.113.7"}, "203.0.113.7"},
+		{"two proxies", Config{TrustProxy: true, ProxyHops: 2}, []string{"1.2.3.4, 203.0.113.7, 10.0.0.2"}, "203.0.113.7"},
+		{"fewer entries than hops", Config{TrustProxy: true, ProxyHops: 3}, []string{"203.0.113.7, 10.0.0.2"}, "203.0.113.7"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = "10.0.0.1:4321"
+			for _, value := range test.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			limiter := &Limiter{config: test.config}
+			if got := limiter.clientIP(r); got != test.want {
+				t.Errorf("clientIP = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+// A client rotating a made up X-Forwarded-For entry stays in its own bucket.
+func TestSpoofedForwardedForKeepsTheLimit(t *testing.T) {
+	config := DefaultConfig()
+	config.TrustProxy = true
+	limiter, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	burst := int(config.Tiers[AnonymousTier].Burst)
+	for i := 0; i <= burst; i++ {
+		r := httptest.NewRequest("GET", "/", nil)
+		// The proxy appends the address the client connected from
+		r.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d, 203.0.113.7", i))
+		client, err := limiter.Identify(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decision := limiter.Allow(client, 1)
+		if allowed := i < burst; decision.Allowed != allowed {
+			t.Fatalf("request %d: allowed %v, want %v", i, decision.Allowed, allowed)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config is read from the environment by LoadConfig.
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
+	HookTimeout       time.Duration
+	MaxHeaderBytes    int
+	MaxBodyBytes      int64
+	TLSCertFile       string
+	TLSKeyFile        string
+}
+
+// LoadConfig reads, with their defaults:
+//
+//	LISTEN_ADDR          :8080, or :$PORT when PORT is set
+//	READ_HEADER_TIMEOUT  5s
+//	READ_TIMEOUT         15s
+//	WRITE_TIMEOUT        60s, longer than the slowest query timeout
+//	IDLE_TIMEOUT         120s
+//	SHUTDOWN_TIMEOUT     30s to drain in-flight requests
+//	HOOK_TIMEOUT         10s for the shutdown hooks, after draining
+//	MAX_HEADER_BYTES     65536
+//	MAX_BODY_BYTES       1048576
+//	TLS_CERT_FILE and TLS_KEY_FILE to serve HTTPS, reloaded when they change
+func LoadConfig() (Config, error) {
+	config := Config{
+		Addr:              ":8080",
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      60 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ShutdownTimeout:   30 * time.Second,
+		HookTimeout:       10 * time.Second,
+		MaxHeaderBytes:    64 << 10,
+		MaxBodyBytes:      1 << 20,
+		TLSCertFile:       os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:        os.Getenv("TLS_KEY_FILE"),
+	}
+
+	if port := os.Getenv("PORT"); port != "" {
+		config.Addr = ":" + port
+	}
+	if addr := os.Getenv("LISTEN_ADDR"); addr != "" {
+		config.Addr = addr
+	}
+
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"READ_HEADER_TIMEOUT", &config.ReadHeaderTimeout},
+		{"READ_TIMEOUT", &config.ReadTimeout},
+		{"WRITE_TIMEOUT", &config.WriteTimeout},
+		{"IDLE_TIMEOUT", &config.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", &config.ShutdownTimeout},
+		{"HOOK_TIMEOUT", &config.HookTimeout},
+	}
+	for _, d := range durations {
+		if value := os.Getenv(d.key); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration < 0 {
+				return Config{}, fmt.Errorf("invalid %s %q, expected a duration such as 30s", d.key, value)
+			}
+			*d.target = duration
+		}
+	}
+
+	if value := os.Getenv("MAX_HEADER_BYTES"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return Config{}, fmt.Errorf("invalid MAX_HEADER_BYTES %q", value)
+		}
+		config.MaxHeaderBytes = n
+	}
+	if value := os.Getenv("MAX_BODY_BYTES"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			return Config{}, fmt.Errorf("invalid MAX_BODY_BYTES %q", value)
+		}
+		config.MaxBodyBytes = n
+	}
+
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return Config{}, fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	return config, nil
+}
+
+// Hooks let the application take part in shutdown. OnDrain runs as soon as
+// the server stops accepting connections and should end long-lived requests
+// such as event streams, OnShutdown runs once every request has finished and
+// releases pools and workers.
+type Hooks struct {
+	OnDrain    func()
+	OnShutdown func(ctx context.Context) error
+}
+
+// Run serves handler until ctx is cancelled, then stops accepting
+// connections and waits up to ShutdownTimeout for in-flight requests before
+// running the shutdown hooks. The hooks get HookTimeout of their own, so they
+// still run when draining used up ShutdownTimeout.
+func Run(ctx context.Context, config Config, handler http.Handler, hooks Hooks) error {
+	srv := &http.Server{
+		Addr:              config.Addr,
+		Handler:           http.MaxBytesHandler(handler, config.MaxBodyBytes),
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
+	if hooks.OnDrain != nil {
+		srv.RegisterOnShutdown(hooks.OnDrain)
+	}
+
+	if config.TLSCertFile != "" {
+		certs, err := newCertReloader(config.TLSCertFile, config.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.getCertificate,
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logrus.WithFields(logrus.Fields{"addr": config.Addr, "tls": srv.TLSConfig != nil}).Info("starting server")
+		if srv.TLSConfig != nil {
+			serveErr <- srv.ListenAndServeTLS("", "")
+		} else {
+			serveErr <- srv.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+	}
+
+	logrus.WithField("timeout", config.ShutdownTimeout.String()).Info("shutting down, draining in-flight requests")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		logrus.WithError(err).Warn("in-flight requests did not finish in time")
+		srv.Close()
+	}
+	if hooks.OnShutdown != nil {
+		hookCtx, cancel := context.WithTimeout(context.Background(), config.HookTimeout)
+		defer cancel()
+		err = errors.Join(err, hooks.OnShutdown(hookCtx))
+	}
+	return err
+}
+
+// certReloadInterval bounds how often the certificate files are checked.
+const certReloadInterval = 30 * time.Second
+
+// certReloader serves the key pair from disk and loads it again when either
+// file changes, so renewed certificates are picked up without a restart.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func newCertReloader(certFile string, keyFile string) (*certReloader, error) {
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+func (c *certReloader) load() error {
+	modTime, err := c.latestModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %v", err)
+	}
+	c.cert = &cert
+	c.modTime = modTime
+	return nil
+}
+
+func (c *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat TLS file: %v", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// getCertificate keeps serving the previous pair when a reload fails, e.g.
+// while the files are being replaced.
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.lastCheck) < certReloadInterval {
+		return c.cert, nil
+	}
+	c.lastCheck = time.Now()
+
+	modTime, err := c.latestModTime()
+	if err != nil || !modTime.After(c.modTime) {
+		return c.cert, nil
+	}
+	if err := c.load(); err != nil {
+		logrus.WithError(err).Warn("keeping previous TLS certificate")
+		return c.cert, nil
+	}
+	logrus.WithField("file", c.certFile).Info("reloaded TLS certificate")
+	return c.cert, nil
+}
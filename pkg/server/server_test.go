@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// The shutdown hooks run with a live context even when in-flight requests
+// outlast ShutdownTimeout.
+func TestShutdownHooksAfterDrainTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	hookErr := make(chan error, 1)
+	hooks := Hooks{OnShutdown: func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		switch {
+		case ctx.Err() != nil:
+			hookErr <- ctx.Err()
+		case !ok || time.Until(deadline) > time.Second:
+			hookErr <- context.DeadlineExceeded
+		default:
+			hookErr <- nil
+		}
+		return nil
+	}}
+
+	config := Config{Addr: addr, ShutdownTimeout: 50 * time.Millisecond, HookTimeout: time.Second, MaxBodyBytes: 1 << 10}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- Run(ctx, config, handler, hooks) }()
+
+	go func() {
+		for {
+			if _, err := http.Get("http://" + addr); err == nil || ctx.Err() != nil {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not start")
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Run did not report the drain timeout")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+	if err := <-hookErr; err != nil {
+		t.Errorf("OnShutdown got a context that is done or unbounded: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	serviceName     = "valhalla-api"
+	instrumentation = "github.com/FudgyDRS/valhalla-api"
+)
+
+var (
+	setupOnce sync.Once
+	shutdown  = func(context.Context) error { return nil }
+	setupErr  error
+
+	propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+)
+
+// Setup installs the exporter chosen by TRACING_EXPORTER:
+//
+//	none    spans are not recorded (default)
+//	stdout  pretty printed JSON on stdout
+//	file    one JSON span per line appended to TRACING_FILE (traces.jsonl)
+//	otlp    OTLP over HTTP, configured by the standard OTEL_EXPORTER_OTLP_* variables
+//
+// TRACING_SAMPLE_RATIO (0 to 1, default 1) samples new traces, incoming
+// traceparent sampling decisions are always honoured. Only the first call has
+// an effect. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	setupOnce.Do(func() {
+		otel.SetTextMapPropagator(propagator)
+
+		exporter, err := newExporter(ctx, strings.ToLower(os.Getenv("TRACING_EXPORTER")))
+		if err != nil || exporter == nil {
+			setupErr = err
+			return
+		}
+
+		ratio := 1.0
+		if value := os.Getenv("TRACING_SAMPLE_RATIO"); value != "" {
+			ratio, err = strconv.ParseFloat(value, 64)
+			if err != nil || ratio < 0 || ratio > 1 {
+				setupErr = fmt.Errorf("invalid TRACING_SAMPLE_RATIO %q", value)
+				return
+			}
+		}
+
+		res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(serviceName)))
+		if err != nil {
+			setupErr = err
+			return
+		}
+
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+			sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		)
+		otel.SetTracerProvider(provider)
+		shutdown = provider.Shutdown
+	})
+
+	return shutdown, setupErr
+}
+
+func newExporter(ctx context.Context, kind string) (sdktrace.SpanExporter, error) {
+	switch kind {
+	case "", "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "file":
+		path := os.Getenv("TRACING_FILE")
+		if path == "" {
+			path = "traces.jsonl"
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %v", err)
+		}
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	case "otlp":
+		return otlptracehttp.New(ctx)
+	}
+	return nil, fmt.Errorf("unknown TRACING_EXPORTER %q, expected none, stdout, file or otlp", kind)
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Start opens a child span of whatever span ctx carries.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Annotate adds attributes to the span carried by ctx.
+func Annotate(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
+
+// TraceId returns the trace id carried by ctx, empty when not sampled.
+func TraceId(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware continues the trace of an incoming W3C traceparent header, or
+// starts one, and wraps the request in a server span named after label.
+// The trace id is echoed in X-Trace-Id so users can quote it.
+func Middleware(label func(r *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, label(r),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if traceId := TraceId(ctx); traceId != "" {
+			w.Header().Set("X-Trace-Id", traceId)
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// RoundTripper opens a client span for every upstream RPC attempt, named after
+// the JSON-RPC method, with the chain and provider host as attributes.
+func RoundTripper(chain string, rpcURL string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	provider := "invalid"
+	if parsed, err := url.Parse(rpcURL); err == nil && parsed.Host != "" {
+		provider = parsed.Host
+	}
+	return &rpcTransport{chain: chain, provider: provider, next: next}
+}
+
+type rpcTransport struct {
+	chain    string
+	provider string
+	next     http.RoundTripper
+}
+
+func (t *rpcTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !trace.SpanFromContext(req.Context()).IsRecording() {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx, span := tracer().Start(req.Context(), "rpc",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.provider", t.provider),
+			attribute.String("chain.id", t.chain),
+		),
+	)
+	defer span.End()
+
+	req = req.WithContext(ctx)
+	method := rpcMethod(req)
+	span.SetName("rpc " + method)
+	span.SetAttributes(attribute.String("rpc.method", method))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// rpcMethod reads the JSON-RPC method from the request body, "batch" for
+// batched requests, and puts the body back for the next transport.
+// go-ethereum writes method before params, so only the start is searched.
+func rpcMethod(req *http.Request) string {
+	if req.Body == nil {
+		return "unknown"
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "unknown"
+	}
+
+	head := body[:min(len(body), 512)]
+	if bytes.HasPrefix(bytes.TrimSpace(head), []byte("[")) {
+		return "batch"
+	}
+	_, rest, found := bytes.Cut(head, []byte(`"method":"`))
+	if !found {
+		return "unknown"
+	}
+	method, _, found := bytes.Cut(rest, []byte(`"`))
+	if !found {
+		return "unknown"
+	}
+	return string(method)
+}
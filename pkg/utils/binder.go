@@ -0,0 +1,452 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Validator checks a single raw query value, see the `validate` struct tag.
+type Validator func(value string) error
+
+var (
+	validatorsMu sync.RWMutex
+	validators   = map[string]Validator{
+		"address":  validateAddress,
+		"checksum": validateChecksum,
+		"hash":     validateHash,
+		"hex":      validateHex,
+	}
+
+	addressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	hashRegex    = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+	hexRegex     = regexp.MustCompile(`^0x([0-9a-fA-F]{2})*$`)
+
+	addressType = reflect.TypeOf(common.Address{})
+	hashType    = reflect.TypeOf(common.Hash{})
+	bigIntType  = reflect.TypeOf(&big.Int{})
+)
+
+// RegisterValidator makes a named validator available to `validate` tags,
+// e.g. RegisterValidator("chain", ...) for `validate:"chain"`.
+func RegisterValidator(name string, validator Validator) {
+	validatorsMu.Lock()
+	defer validatorsMu.Unlock()
+	validators[name] = validator
+}
+
+type bindErrors struct {
+	missing []string
+	invalid []string
+	codes   map[ErrorCode]struct{}
+}
+
+// addInvalid records a field that failed to bind, keeping the ErrorCode of
+// typed validator errors such as INVALID_ADDRESS or CHAIN_UNSUPPORTED.
+func (errs *bindErrors) addInvalid(key string, err error) {
+	code, message := CodeMalformedRequest, err.Error()
+	var apiErr Error
+	if errors.As(err, &apiErr) {
+		code, message = apiErr.Type, apiErr.Details
+	}
+	errs.invalid = append(errs.invalid, fmt.Sprintf("%s: %s", key, message))
+	errs.codes[code] = struct{}{}
+}
+
+// code is the shared ErrorCode of every invalid field, or MALFORMED_REQUEST
+// when fields are missing or failed for different reasons.
+func (errs *bindErrors) code() ErrorCode {
+	if len(errs.missing) > 0 || len(errs.codes) != 1 {
+		return CodeMalformedRequest
+	}
+	for code := range errs.codes {
+		return code
+	}
+	return CodeMalformedRequest
+}
+
+// ParseAndValidateParams binds the request query string into params, a
+// pointer to a struct, using the field tags:
+//
+//	query:"name"        query parameter name, slices of structs bind "name.sub"
+//	optional:"true"     the parameter may be omitted
+//	default:"value"     value used when the parameter is omitted
+//	validate:"a,b=c"    address, checksum, hash, hex, uint256, range=min:max,
+//	                    maxlen=n (characters), maxitems=n (slice length) or
+//	                    any validator added with RegisterValidator
+//	base:"0"            integers may be 0x prefixed hex, otherwise they are
+//	                    decimal only
+//
+// Supported field types are strings, bools, ints, uints, common.Address,
+// common.Hash, *big.Int, slices of those and slices of structs. Parameters
+// not declared by params (other than "query") are rejected.
+func ParseAndValidateParams(r *http.Request, params interface{}) (err error) {
+	val := reflect.ValueOf(params).Elem() // Dereference the pointer to access the underlying struct
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	typ := val.Type()
+
+	_, span := tracing.Start(r.Context(), "params.bind", attribute.String("params.type", typ.Name()))
+	defer func() { tracing.End(span, err) }()
+
+	Log(r.Context()).WithField("params", typ.String()).Debug("binding params")
+
+	query := r.URL.Query()
+	allowedFields := map[string]struct{}{"query": {}}
+	errs := &bindErrors{codes: make(map[ErrorCode]struct{})}
+	bindStruct(query, val, allowedFields, errs)
+
+	var unknownFields []string
+	for key := range query {
+		if _, ok := allowedFields[key]; !ok {
+			unknownFields = append(unknownFields, key)
+		}
+	}
+	sort.Strings(unknownFields)
+
+	var details []string
+	if len(errs.missing) > 0 {
+		details = append(details, "Missing fields: "+strings.Join(errs.missing, ", "))
+	}
+	if len(errs.invalid) > 0 {
+		details = append(details, "Invalid fields: "+strings.Join(errs.invalid, "; "))
+	}
+	if len(unknownFields) > 0 {
+		details = append(details, "Unknown fields: "+strings.Join(unknownFields, ", "))
+	}
+	if len(details) > 0 {
+		code := errs.code()
+		if len(unknownFields) > 0 {
+			code = CodeMalformedRequest
+		}
+		return newError(code, strings.Join(details, ". "), nil, GetOrigin())
+	}
+
+	return nil
+}
+
+func bindStruct(query url.Values, val reflect.Value, allowedFields map[string]struct{}, errs *bindErrors) {
+	typ := val.Type()
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		fieldType := typ.Field(i)
+		queryTag := fieldType.Tag.Get("query")
+		optional := fieldType.Tag.Get("optional") == "true"
+
+		if queryTag == "" {
+			// Recursively parse nested struct fields
+			if field.Kind() == reflect.Struct && !isBinderScalar(field.Type()) {
+				bindStruct(query, field, allowedFields, errs)
+			}
+			continue
+		}
+
+		switch {
+		case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct && !isBinderScalar(field.Type().Elem()):
+			bindStructSlice(query, field, queryTag, fieldType.Tag.Get("validate"), optional, allowedFields, errs)
+
+		case field.Kind() == reflect.Slice && field.Type() != reflect.TypeOf([]byte{}):
+			allowedFields[queryTag] = struct{}{}
+			values := query[queryTag]
+			if len(values) == 0 && !optional {
+				errs.missing = append(errs.missing, queryTag)
+				continue
+			}
+			if err := checkMaxLen(fieldType.Tag.Get("validate"), len(values)); err != nil {
+				errs.addInvalid(queryTag, err)
+				continue
+			}
+			slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+			for j, value := range values {
+				if err := bindValue(slice.Index(j), value, fieldType.Tag.Get("validate"), intBase(fieldType)); err != nil {
+					errs.addInvalid(fmt.Sprintf("%s[%d]", queryTag, j), err)
+				}
+			}
+			field.Set(slice)
+
+		default:
+			allowedFields[queryTag] = struct{}{}
+			value := query.Get(queryTag)
+			if value == "" {
+				value = fieldType.Tag.Get("default")
+			}
+			if value == "" {
+				// If the field is required (i.e., optional is not set to "true")
+				if !optional {
+					errs.missing = append(errs.missing, queryTag)
+				}
+				continue
+			}
+			if err := bindValue(field, value, fieldType.Tag.Get("validate"), intBase(fieldType)); err != nil {
+				errs.addInvalid(queryTag, err)
+			}
+		}
+	}
+}
+
+// bindStructSlice zips "name.sub" parameters into a slice of structs. Every
+// provided sub list must have the same length; optional sub fields may be
+// left out entirely.
+func bindStructSlice(query url.Values, field reflect.Value, queryTag string, validateTag string, optional bool, allowedFields map[string]struct{}, errs *bindErrors) {
+	elemType := field.Type().Elem()
+
+	count := 0
+	var subFields []int
+	lists := make(map[int][]string)
+	for j := 0; j < elemType.NumField(); j++ {
+		subTag := elemType.Field(j).Tag.Get("query")
+		if subTag == "" {
+			continue
+		}
+		key := queryTag + "." + subTag
+		allowedFields[key] = struct{}{}
+		subFields = append(subFields, j)
+		lists[j] = query[key]
+		if len(lists[j]) > count {
+			count = len(lists[j])
+		}
+	}
+
+	if count == 0 {
+		if !optional {
+			errs.missing = append(errs.missing, queryTag)
+		}
+		return
+	}
+
+	if err := checkMaxLen(validateTag, count); err != nil {
+		errs.addInvalid(queryTag, err)
+		return
+	}
+
+	slice := reflect.MakeSlice(field.Type(), count, count)
+	for _, j := range subFields {
+		values := lists[j]
+		subField := elemType.Field(j)
+		key := queryTag + "." + subField.Tag.Get("query")
+		if len(values) == 0 && subField.Tag.Get("optional") == "true" {
+			continue
+		}
+		if len(values) != count {
+			errs.addInvalid(key, fmt.Errorf("expected %d values, got %d", count, len(values)))
+			continue
+		}
+		for k, value := range values {
+			if err := bindValue(slice.Index(k).Field(j), value, subField.Tag.Get("validate"), intBase(subField)); err != nil {
+				errs.addInvalid(fmt.Sprintf("%s[%d]", key, k), err)
+			}
+		}
+	}
+	field.Set(slice)
+}
+
+func isBinderScalar(t reflect.Type) bool {
+	return t == addressType || t == hashType || t == bigIntType
+}
+
+// intBase is the base integers of field are parsed in, see the `base` tag.
+func intBase(field reflect.StructField) int {
+	if field.Tag.Get("base") == "0" {
+		return 0
+	}
+	return 10
+}
+
+func bindValue(field reflect.Value, value string, validateTag string, base int) error {
+	if err := runValidators(value, validateTag, base); err != nil {
+		return err
+	}
+
+	switch field.Type() {
+	case addressType:
+		if !addressRegex.MatchString(value) {
+			return ErrInvalidAddress(fmt.Sprintf("invalid address %s", value))
+		}
+		field.Set(reflect.ValueOf(common.HexToAddress(value)))
+		return nil
+	case hashType:
+		if !hashRegex.MatchString(value) {
+			return fmt.Errorf("invalid hash %s", value)
+		}
+		field.Set(reflect.ValueOf(common.HexToHash(value)))
+		return nil
+	case bigIntType:
+		n, ok := new(big.Int).SetString(value, base)
+		if !ok {
+			return fmt.Errorf("invalid integer %s", value)
+		}
+		field.Set(reflect.ValueOf(n))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid bool %s", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, base, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %s", value)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, base, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %s", value)
+		}
+		field.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
+
+func runValidators(value string, validateTag string, base int) error {
+	if validateTag == "" {
+		return nil
+	}
+
+	for _, rule := range strings.Split(validateTag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "uint256":
+			if err := validateUint256(value, base); err != nil {
+				return err
+			}
+		case "range":
+			if err := validateRange(value, arg, base); err != nil {
+				return err
+			}
+		case "maxlen":
+			limit, err := strconv.Atoi(arg)
+			if err != nil {
+				return fmt.Errorf("bad maxlen rule %q", rule)
+			}
+			if len(value) > limit {
+				return fmt.Errorf("longer than %d characters", limit)
+			}
+		case "maxitems":
+			// checked against the slice length by checkMaxLen
+		default:
+			validatorsMu.RLock()
+			validator, ok := validators[name]
+			validatorsMu.RUnlock()
+			if !ok {
+				return fmt.Errorf("unknown validator %q", name)
+			}
+			if err := validator(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkMaxLen(validateTag string, count int) error {
+	for _, rule := range strings.Split(validateTag, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name != "maxitems" {
+			continue
+		}
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("bad maxitems rule %q", rule)
+		}
+		if count > limit {
+			return fmt.Errorf("at most %d values allowed, got %d", limit, count)
+		}
+	}
+	return nil
+}
+
+func validateAddress(value string) error {
+	if !addressRegex.MatchString(value) {
+		return ErrInvalidAddress(fmt.Sprintf("invalid address %s", value))
+	}
+	return nil
+}
+
+// validateChecksum accepts all lower or all upper case addresses, mixed case
+// ones must carry a valid EIP-55 checksum.
+func validateChecksum(value string) error {
+	if err := validateAddress(value); err != nil {
+		return err
+	}
+	body := value[2:]
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return nil
+	}
+	if common.HexToAddress(value).Hex() != value {
+		return ErrInvalidAddress(fmt.Sprintf("invalid EIP-55 checksum for %s", value))
+	}
+	return nil
+}
+
+func validateHash(value string) error {
+	if !hashRegex.MatchString(value) {
+		return fmt.Errorf("invalid hash %s", value)
+	}
+	return nil
+}
+
+func validateHex(value string) error {
+	if !hexRegex.MatchString(value) {
+		return fmt.Errorf("invalid 0x prefixed hex %s", value)
+	}
+	return nil
+}
+
+func validateUint256(value string, base int) error {
+	n, ok := new(big.Int).SetString(value, base)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return fmt.Errorf("invalid uint256 %s", value)
+	}
+	return nil
+}
+
+// validateRange checks "min:max", either bound may be left empty.
+func validateRange(value string, bounds string, base int) error {
+	n, ok := new(big.Int).SetString(value, base)
+	if !ok {
+		return fmt.Errorf("invalid integer %s", value)
+	}
+
+	lower, upper, found := strings.Cut(bounds, ":")
+	if !found {
+		return fmt.Errorf("bad range rule %q", bounds)
+	}
+	if lower != "" {
+		min, ok := new(big.Int).SetString(lower, 10)
+		if ok && n.Cmp(min) < 0 {
+			return fmt.Errorf("%s is below the minimum %s", value, lower)
+		}
+	}
+	if upper != "" {
+		max, ok := new(big.Int).SetString(upper, 10)
+		if ok && n.Cmp(max) > 0 {
+			return fmt.Errorf("%s is above the maximum %s", value, upper)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type binderPool struct {
+	Address common.Address `query:"address" validate:"checksum"`
+	PoolId  *big.Int       `query:"pid" validate:"uint256"`
+}
+
+type binderParams struct {
+	Genesis common.Address `query:"genesis" validate:"checksum"`
+	Pools   []binderPool   `query:"pools" optional:"true" validate:"maxitems=2"`
+	Count   int            `query:"count" optional:"true" default:"3" validate:"range=1:10"`
+	Limit   uint64         `query:"limit" optional:"true"`
+	Amount  *big.Int       `query:"amount" optional:"true" validate:"uint256"`
+	Slots   []*big.Int     `query:"slot" optional:"true" validate:"uint256,maxitems=2" base:"0"`
+	Flag    bool           `query:"flag" optional:"true"`
+}
+
+const (
+	binderGenesis = "0x23Ee13d49e78811d063722D9228547a7dF73E42E"
+	binderToken   = "0xAC60849b0456baD97E75E8f84C245Bd9C2Fc9766"
+)
+
+func TestParseAndValidateParams(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		check func(t *testing.T, params binderParams)
+		err   []string
+		code  ErrorCode
+	}{
+		{
+			name:  "defaults",
+			query: "genesis=" + binderGenesis,
+			check: func(t *testing.T, params binderParams) {
+				if params.Count != 3 || params.Pools != nil || params.Amount != nil {
+					t.Errorf("params = %+v, want defaults", params)
+				}
+			},
+		},
+		{
+			name:  "struct slice",
+			query: "genesis=" + binderGenesis + "&pools.address=" + binderToken + "&pools.pid=7&pools.address=" + binderToken + "&pools.pid=8",
+			check: func(t *testing.T, params binderParams) {
+				if len(params.Pools) != 2 || params.Pools[1].PoolId.Int64() != 8 || params.Pools[0].Address.Hex() != binderToken {
+					t.Errorf("pools = %+v", params.Pools)
+				}
+			},
+		},
+		{
+			name:  "decimal with leading zero",
+			query: "genesis=" + binderGenesis + "&amount=010&count=010&limit=010",
+			check: func(t *testing.T, params binderParams) {
+				if params.Amount.Int64() != 10 || params.Count != 10 || params.Limit != 10 {
+					t.Errorf("amount %s, count %d, limit %d, want 10", params.Amount, params.Count, params.Limit)
+				}
+			},
+		},
+		{
+			name:  "hex where base 0 is allowed",
+			query: "genesis=" + binderGenesis + "&slot=0x10&slot=3",
+			check: func(t *testing.T, params binderParams) {
+				if len(params.Slots) != 2 || params.Slots[0].Int64() != 16 || params.Slots[1].Int64() != 3 {
+					t.Errorf("slots = %v, want [16 3]", params.Slots)
+				}
+			},
+		},
+		{
+			name:  "hex amount",
+			query: "genesis=" + binderGenesis + "&amount=0x10",
+			err:   []string{"amount: invalid uint256 0x10"},
+		},
+		{
+			name:  "hex pid",
+			query: "genesis=" + binderGenesis + "&pools.address=" + binderToken + "&pools.pid=0x1",
+			err:   []string{"pools.pid[0]: invalid uint256 0x1"},
+		},
+		{
+			name:  "hex int",
+			query: "genesis=" + binderGenesis + "&limit=0x10",
+			err:   []string{"limit: invalid unsigned integer 0x10"},
+		},
+		{
+			name:  "negative uint256",
+			query: "genesis=" + binderGenesis + "&amount=-1",
+			err:   []string{"amount: invalid uint256 -1"},
+		},
+		{
+			name:  "out of range",
+			query: "genesis=" + binderGenesis + "&count=11",
+			err:   []string{"count: 11 is above the maximum 10"},
+		},
+		{
+			name:  "missing",
+			query: "",
+			err:   []string{"Missing fields: genesis"},
+		},
+		{
+			name:  "unknown fields",
+			query: "genesis=" + binderGenesis + "&query=x&pools.extra=1&bogus=2",
+			err:   []string{"Unknown fields: bogus, pools.extra"},
+		},
+		{
+			name:  "maxitems of a struct slice",
+			query: "genesis=" + binderGenesis + strings.Repeat("&pools.address="+binderToken+"&pools.pid=1", 3),
+			err:   []string{"pools: at most 2 values allowed, got 3"},
+		},
+		{
+			name:  "maxitems of a slice",
+			query: "genesis=" + binderGenesis + "&slot=1&slot=2&slot=3",
+			err:   []string{"slot: at most 2 values allowed, got 3"},
+		},
+		{
+			name:  "uneven struct slice",
+			query: "genesis=" + binderGenesis + "&pools.address=" + binderToken + "&pools.pid=1&pools.pid=2",
+			err:   []string{"pools.address: expected 2 values, got 1"},
+		},
+		{
+			name:  "bad checksum keeps its code",
+			query: "genesis=0x23ee13d49e78811d063722D9228547a7dF73E42E",
+			err:   []string{"invalid EIP-55 checksum"},
+			code:  CodeInvalidAddress,
+		},
+		{
+			name:  "invalid bool",
+			query: "genesis=" + binderGenesis + "&flag=maybe",
+			err:   []string{"flag: invalid bool maybe"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var params binderParams
+			err := ParseAndValidateParams(httptest.NewRequest("GET", "/api/info?"+test.query, nil), &params)
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				test.check(t, params)
+				return
+			}
+
+			var apiErr Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want an Error", err)
+			}
+			for _, want := range test.err {
+				if !strings.Contains(apiErr.Details, want) {
+					t.Errorf("details %q do not contain %q", apiErr.Details, want)
+				}
+			}
+			code := test.code
+			if code == "" {
+				code = CodeMalformedRequest
+			}
+			if apiErr.Type != code {
+				t.Errorf("type = %s, want %s", apiErr.Type, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package utils
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CORSConfig is the browser access policy of a deployment. AllowedOrigins
+// entries are exact origins (https://app.example.com), wildcard subdomains
+// (https://*.example.com, which does not match example.com itself) or "*".
+// Credentials are only allowed for origins matched by an exact or wildcard
+// entry, never through "*".
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           int
+	AllowCredentials bool
+}
+
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodOptions},
+		AllowedHeaders: []string{
+			"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization",
+			"X-API-Key", "X-Request-Id", "traceparent", "tracestate",
+		},
+		ExposedHeaders: []string{
+			"X-Request-Id", "X-Trace-Id", "Retry-After",
+			"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+		},
+		MaxAge: 600,
+	}
+}
+
+// LoadCORSConfig overrides the defaults with the comma separated lists
+// CORS_ALLOWED_ORIGINS, CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS and
+// CORS_EXPOSED_HEADERS, CORS_MAX_AGE in seconds and CORS_ALLOW_CREDENTIALS.
+func LoadCORSConfig() CORSConfig {
+	config := DefaultCORSConfig()
+
+	envList := func(key string, target *[]string) {
+		if value := os.Getenv(key); value != "" {
+			var list []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, item)
+				}
+			}
+			*target = list
+		}
+	}
+	envList("CORS_ALLOWED_ORIGINS", &config.AllowedOrigins)
+	envList("CORS_ALLOWED_METHODS", &config.AllowedMethods)
+	envList("CORS_ALLOWED_HEADERS", &config.AllowedHeaders)
+	envList("CORS_EXPOSED_HEADERS", &config.ExposedHeaders)
+
+	if value := os.Getenv("CORS_MAX_AGE"); value != "" {
+		if maxAge, err := strconv.Atoi(value); err == nil && maxAge >= 0 {
+			config.MaxAge = maxAge
+		} else {
+			logrus.WithField("value", value).Warn("ignoring invalid CORS_MAX_AGE, expected seconds")
+		}
+	}
+	if value := os.Getenv("CORS_ALLOW_CREDENTIALS"); value != "" {
+		if credentials, err := strconv.ParseBool(value); err == nil {
+			config.AllowCredentials = credentials
+		} else {
+			logrus.WithField("value", value).Warn("ignoring invalid CORS_ALLOW_CREDENTIALS, expected true or false")
+		}
+	}
+
+	return config
+}
+
+// matchOrigin reports whether origin is allowed, and whether it was matched
+// by an explicit entry rather than "*".
+func (c CORSConfig) matchOrigin(origin string) (allowed bool, explicit bool) {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return false, false
+	}
+
+	for _, entry := range c.AllowedOrigins {
+		switch {
+		case entry == "*":
+			allowed = true
+		case strings.EqualFold(entry, origin):
+			return true, true
+		case strings.Contains(entry, "://*."):
+			scheme, suffix, _ := strings.Cut(entry, "://*")
+			if strings.EqualFold(parsed.Scheme, scheme) && len(parsed.Host) > len(suffix) &&
+				strings.HasSuffix(strings.ToLower(parsed.Host), strings.ToLower(suffix)) {
+				return true, true
+			}
+		}
+	}
+	return allowed, false
+}
+
+// Handler applies the policy to next. Allowed origins are echoed with
+// Vary: Origin so caches keep one response per origin, preflights are
+// answered here and never reach next.
+func (c CORSConfig) Handler(next http.Handler) http.Handler {
+	methods := strings.Join(c.AllowedMethods, ", ")
+	headers := strings.Join(c.AllowedHeaders, ", ")
+	exposed := strings.Join(c.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(c.MaxAge)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions
+
+		if origin != "" {
+			allowed, explicit := c.matchOrigin(origin)
+			if !allowed {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				// Served without CORS headers, the browser hides the response
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if c.AllowCredentials && explicit {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if exposed != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposed)
+			}
+		}
+
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			w.Header().Set("Access-Control-Max-Age", maxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+var corsPolicy = sync.OnceValue(LoadCORSConfig)
+
+// EnableCORS applies the deployment's CORS policy, see LoadCORSConfig, and
+// logs the request.
+func EnableCORS(next http.Handler) http.Handler {
+	return corsPolicy().Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Log(r.Context()).WithFields(logrus.Fields{"method": r.Method, "url": r.URL.String()}).Info("api request")
+
+		next.ServeHTTP(w, r)
+	}))
+}
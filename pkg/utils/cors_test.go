@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSOrigins(t *testing.T) {
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"https://app.example.com", "https://*.valhalla.fi", "*"}
+	config.AllowCredentials = true
+
+	tests := []struct {
+		origin      string
+		allowed     bool
+		credentials bool
+	}{
+		{"https://app.example.com", true, true},
+		{"https://APP.example.com", true, true},
+		{"https://beta.valhalla.fi", true, true},
+		// The wildcard does not cover the bare domain, nor another scheme
+		{"https://valhalla.fi", true, false},
+		{"http://beta.valhalla.fi", true, false},
+		{"https://other.org", true, false},
+		{"not an origin", false, false},
+	}
+	for _, test := range tests {
+		allowed, explicit := config.matchOrigin(test.origin)
+		if allowed != test.allowed || explicit != test.credentials {
+			t.Errorf("%s: allowed %v explicit %v, want %v %v", test.origin, allowed, explicit, test.allowed, test.credentials)
+		}
+	}
+}
+
+func serveCORS(config CORSConfig, method, origin string) (*httptest.ResponseRecorder, bool) {
+	reached := false
+	handler := config.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	r := httptest.NewRequest(method, "/api/info", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w, reached
+}
+
+func TestCORSHandler(t *testing.T) {
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"https://app.example.com", "*"}
+	config.AllowCredentials = true
+
+	w, reached := serveCORS(config, http.MethodGet, "https://app.example.com")
+	if !reached || w.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Errorf("explicit origin: reached %v, headers %v", reached, w.Header())
+	}
+	if w.Header().Get("Vary") != "Origin" {
+		t.Errorf("Vary = %q, want Origin", w.Header().Get("Vary"))
+	}
+
+	// "*" allows any origin, never with credentials
+	w, _ = serveCORS(config, http.MethodGet, "https://other.org")
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://other.org" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("wildcard origin: headers %v", w.Header())
+	}
+
+	w, reached = serveCORS(config, http.MethodOptions, "https://app.example.com")
+	if reached || w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Methods") == "" || w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("preflight: reached %v, status %d, headers %v", reached, w.Code, w.Header())
+	}
+}
+
+func TestCORSRefusedOrigin(t *testing.T) {
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = []string{"https://app.example.com"}
+
+	w, reached := serveCORS(config, http.MethodOptions, "https://evil.example")
+	if reached || w.Code != http.StatusForbidden {
+		t.Errorf("refused preflight: reached %v, status %d", reached, w.Code)
+	}
+
+	// A simple request is served, the browser hides it without the headers
+	w, reached = serveCORS(config, http.MethodGet, "https://evil.example")
+	if !reached || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("refused request: reached %v, headers %v", reached, w.Header())
+	}
+
+	w, reached = serveCORS(config, http.MethodGet, "")
+	if !reached || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("same origin request: reached %v, headers %v", reached, w.Header())
+	}
+}
+
+func TestLoadCORSConfig(t *testing.T) {
+	t.Setenv("CORS_ALLOWED_ORIGINS", " https://a.example , ,https://*.b.example")
+	t.Setenv("CORS_MAX_AGE", "-1")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+
+	config := LoadCORSConfig()
+	if len(config.AllowedOrigins) != 2 || config.AllowedOrigins[0] != "https://a.example" || config.AllowedOrigins[1] != "https://*.b.example" {
+		t.Errorf("origins = %q", config.AllowedOrigins)
+	}
+	if config.MaxAge != DefaultCORSConfig().MaxAge {
+		t.Errorf("invalid max age was used: %d", config.MaxAge)
+	}
+	if !config.AllowCredentials {
+		t.Error("credentials are not allowed")
+	}
+}
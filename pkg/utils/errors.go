@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrorCode is the stable, machine-readable kind of an Error. Clients should
+// branch on it rather than on Message or Details.
+type ErrorCode string
+
+const (
+	CodeMalformedRequest ErrorCode = "MALFORMED_REQUEST"
+	CodeInvalidAddress   ErrorCode = "INVALID_ADDRESS"
+	CodeChainUnsupported ErrorCode = "CHAIN_UNSUPPORTED"
+	CodeNotFound         ErrorCode = "NOT_FOUND"
+	CodeUnauthorized     ErrorCode = "UNAUTHORIZED"
+	CodeRateLimited      ErrorCode = "RATE_LIMITED"
+	CodeCallReverted     ErrorCode = "CALL_REVERTED"
+	CodeRpcUnavailable   ErrorCode = "RPC_UNAVAILABLE"
+	CodeTimeout          ErrorCode = "TIMEOUT"
+	CodeInternal         ErrorCode = "INTERNAL"
+)
+
+// ErrorKind is one entry of the error catalogue.
+type ErrorKind struct {
+	Type        ErrorCode `json:"type"`
+	Status      uint64    `json:"status"`
+	Message     string    `json:"message"`
+	Retryable   bool      `json:"retryable"`
+	Description string    `json:"description"`
+}
+
+var errorKinds = map[ErrorCode]ErrorKind{
+	CodeMalformedRequest: {CodeMalformedRequest, 400, "Malformed request", false, "Parameters are missing, unknown or fail validation"},
+	CodeInvalidAddress:   {CodeInvalidAddress, 400, "Invalid address", false, "An address is not 20 bytes of hex or has a bad EIP-55 checksum"},
+	CodeChainUnsupported: {CodeChainUnsupported, 404, "Chain not supported", false, "The chain ID is not served by this API"},
+	CodeNotFound:         {CodeNotFound, 404, "Not found", false, "The requested pool, transaction or resource does not exist"},
+	CodeUnauthorized:     {CodeUnauthorized, 401, "Unauthorized", false, "The API key is missing or unknown"},
+	CodeRateLimited:      {CodeRateLimited, 429, "Rate limited", true, "The request budget of the API key or IP is spent, retry after Retry-After seconds"},
+	CodeCallReverted:     {CodeCallReverted, 400, "Call reverted", false, "The contract call reverted, retrying the same call will revert again"},
+	CodeRpcUnavailable:   {CodeRpcUnavailable, 502, "RPC unavailable", true, "Every RPC provider for the chain failed or returned an error"},
+	CodeTimeout:          {CodeTimeout, 504, "Timeout", true, "The upstream RPC did not answer in time"},
+	CodeInternal:         {CodeInternal, 500, "Internal server error", false, "Unexpected failure inside the API"},
+}
+
+// ErrorCatalogue lists every error kind the API can return, sorted by type.
+func ErrorCatalogue() []ErrorKind {
+	catalogue := make([]ErrorKind, 0, len(errorKinds))
+	for _, kind := range errorKinds {
+		catalogue = append(catalogue, kind)
+	}
+	sort.Slice(catalogue, func(i, j int) bool { return catalogue[i].Type < catalogue[j].Type })
+	return catalogue
+}
+
+func newError(code ErrorCode, details string, cause error, origin string) Error {
+	kind, ok := errorKinds[code]
+	if !ok {
+		kind = errorKinds[CodeInternal]
+	}
+
+	return Error{
+		Code:      kind.Status,
+		Type:      kind.Type,
+		Message:   kind.Message,
+		Details:   details,
+		Origin:    origin,
+		Retryable: kind.Retryable,
+		cause:     cause,
+	}
+}
+
+// NewError builds an Error of the given kind. cause stays reachable through
+// errors.Is and errors.As but is not serialized.
+func NewError(code ErrorCode, details string, cause error) Error {
+	return newError(code, details, cause, GetOrigin())
+}
+
+func ErrInvalidAddress(message string) error {
+	return newError(CodeInvalidAddress, message, nil, GetOrigin())
+}
+
+func ErrChainUnsupported(chainId string) error {
+	return newError(CodeChainUnsupported, fmt.Sprintf("chain ID %v not supported", chainId), nil, GetOrigin())
+}
+
+func ErrNotFound(message string) error {
+	return newError(CodeNotFound, message, nil, GetOrigin())
+}
+
+func ErrUnauthorized(message string) error {
+	return newError(CodeUnauthorized, message, nil, GetOrigin())
+}
+
+func ErrRateLimited(message string) error {
+	return newError(CodeRateLimited, message, nil, GetOrigin())
+}
+
+func ErrCallReverted(err error) error {
+	return newError(CodeCallReverted, err.Error(), err, GetOrigin())
+}
+
+func ErrRpcUnavailable(err error) error {
+	return newError(CodeRpcUnavailable, err.Error(), err, GetOrigin())
+}
+
+func ErrTimeout(err error) error {
+	return newError(CodeTimeout, err.Error(), err, GetOrigin())
+}
+
+func (e Error) Unwrap() error {
+	return e.cause
+}
+
+// AsError resolves any error to an Error. An Error anywhere in the chain
+// (fmt.Errorf with %w) wins, otherwise the chain is classified: deadlines
+// become TIMEOUT, reverts CALL_REVERTED, transport and JSON-RPC failures
+// RPC_UNAVAILABLE and everything else INTERNAL. Classified errors keep the
+// full wrapped message as Details.
+func AsError(err error) Error {
+	var apiErr Error
+	if errors.As(err, &apiErr) {
+		if apiErr.Details == "" {
+			apiErr.Details = err.Error()
+		}
+		return apiErr
+	}
+
+	origin := GetOrigin()
+	switch {
+	case isTimeout(err):
+		return newError(CodeTimeout, err.Error(), err, origin)
+	case IsRevert(err):
+		return newError(CodeCallReverted, err.Error(), err, origin)
+	case isRpcFailure(err):
+		return newError(CodeRpcUnavailable, err.Error(), err, origin)
+	}
+	return newError(CodeInternal, err.Error(), err, origin)
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsRevert reports whether err is an execution revert returned by eth_call.
+// Nodes use code 3 when revert data is attached and -32000 otherwise, so the
+// message is checked as well.
+func IsRevert(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == 3 {
+		return true
+	}
+	return strings.Contains(err.Error(), "execution reverted")
+}
+
+func isRpcFailure(err error) bool {
+	var rpcErr rpc.Error
+	var httpErr rpc.HTTPError
+	var urlErr *url.Error
+	var netErr net.Error
+	return errors.As(err, &rpcErr) ||
+		errors.As(err, &httpErr) ||
+		errors.As(err, &urlErr) ||
+		errors.As(err, &netErr)
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"context"
 	"crypto/ecdsa"
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -9,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"reflect"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -26,57 +29,6 @@ func WriteJSONResponse(w http.ResponseWriter, r *http.Request, message string) {
 	}
 }
 
-func ParseAndValidateParams(r *http.Request, params interface{}) error {
-	val := reflect.ValueOf(params).Elem() // Dereference the pointer to access the underlying struct
-	if val.Kind() == reflect.Ptr && !val.IsNil() {
-		val = val.Elem()
-	}
-	typ := val.Type()
-
-	missingFields := []string{}
-	allowedFields := make(map[string]struct{})
-
-	LogInfo("query", fmt.Sprint(typ))
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
-		queryTag := fieldType.Tag.Get("query")
-		optionalTag := fieldType.Tag.Get("optional")
-
-		if queryTag != "" {
-			allowedFields[queryTag] = struct{}{}
-		}
-
-		if _, exists := typ.FieldByName(fieldType.Name); exists {
-			if field.Kind() == reflect.Struct {
-				// Recursively parse nested struct fields
-				nestedParams := reflect.New(fieldType.Type).Interface()
-				if err := ParseAndValidateParams(r, nestedParams); err != nil {
-					return err
-				}
-				// After recursion, set the original struct's field value
-				field.Set(reflect.ValueOf(nestedParams).Elem())
-			} else if queryTag != "" {
-				queryValue := r.URL.Query().Get(queryTag)
-
-				// If the field is required (i.e., optional is not set to "true")
-				if queryValue == "" && optionalTag != "true" {
-					missingFields = append(missingFields, queryTag)
-				} else if queryValue != "" {
-					field.SetString(queryValue)
-				}
-			}
-		}
-	}
-
-	// If there are missing fields, return an error response
-	if len(missingFields) > 0 {
-		return ErrMalformedRequest(fmt.Sprint("Missing fields: " + strings.Join(missingFields, ", ")))
-	}
-
-	return nil
-}
-
 func StringifyStructFields(params interface{}, indent string) string {
 	var result strings.Builder
 	val := reflect.ValueOf(params)
@@ -137,38 +89,8 @@ func StringifyStructFields(params interface{}, indent string) string {
 	return result.String()
 }
 
-func PrintStructFields(params interface{}) {
-	val := reflect.ValueOf(params)
-
-	// Ensure the value is a struct
-	if val.Kind() == reflect.Ptr {
-		val = val.Elem()
-	}
-	if val.Kind() != reflect.Struct {
-		fmt.Println("Expected a struct")
-		return
-	}
-
-	typ := val.Type()
-
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		fieldType := typ.Field(i)
-		fieldName := fieldType.Name
-
-		// Check if it's a nested struct
-		if field.Kind() == reflect.Struct {
-			fmt.Printf("\n%s:\n", fieldName)
-			PrintStructFields(field.Interface()) // Recursively print nested struct fields
-			fmt.Println()
-		} else {
-			fmt.Printf("\n%s: %v", fieldName, field.Interface()) // Print field value
-		}
-	}
-}
-
 func (e Error) Error() string {
-	return fmt.Sprintf("Error (Code: %d, Message: %s)", e.Code, e.Message)
+	return fmt.Sprintf("Error (Code: %d, Type: %s, Message: %s, Details: %s)", e.Code, e.Type, e.Message, e.Details)
 }
 
 // func ErrMalformedRequest(w http.ResponseWriter, message string) {
@@ -198,23 +120,13 @@ func GetOrigin() string {
 func ErrMalformedRequest(message string) error {
 	origin := GetOrigin()
 
-	return Error{
-		Code:    400,
-		Message: "Malformed request",
-		Details: message,
-		Origin:  origin,
-	}
+	return newError(CodeMalformedRequest, message, nil, origin)
 }
 
 func ErrInternal(message string) Error {
 	origin := GetOrigin()
 
-	return Error{
-		Code:    500,
-		Message: "Internal server error",
-		Details: message,
-		Origin:  origin,
-	}
+	return newError(CodeInternal, message, nil, origin)
 }
 
 func EnvKey2Ecdsa() (*ecdsa.PrivateKey, common.Address, error) {
@@ -271,27 +183,33 @@ func HasInt(inputArray []int, input int) error {
 	return fmt.Errorf("int not found in input array")
 }
 
-func EnableCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		// Handle preflight requests
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+type requestIdKey struct{}
+
+var requestIdRegex = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
 
-		LogInfo("API Request", FormatKeyValueLogs([][2]string{
-			{"Method", r.Method},
-			{"URL", fmt.Sprintf("%v", r.URL)},
-		}))
+// WithRequestId keeps a well formed X-Request-Id from the caller or makes a
+// new one, echoes it in the response headers and stores it on the context.
+func WithRequestId(w http.ResponseWriter, r *http.Request) *http.Request {
+	ctx := ContextWithRequestId(r.Context(), r.Header.Get("X-Request-Id"))
+	w.Header().Set("X-Request-Id", RequestId(ctx))
 
-		next.ServeHTTP(w, r)
-	})
+	return r.WithContext(ctx)
+}
+
+// ContextWithRequestId stores requestId in ctx, or a new one when it is not a
+// valid id, for callers that are not HTTP requests.
+func ContextWithRequestId(ctx context.Context, requestId string) context.Context {
+	if !requestIdRegex.MatchString(requestId) {
+		id := make([]byte, 8)
+		rand.Read(id)
+		requestId = hex.EncodeToString(id)
+	}
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
 }
 
 // Helper function to convert []byte to hex string prefixed with "0x".
@@ -331,6 +249,25 @@ func Uint256ToBytes(value *big.Int) []byte {
 	return bytes
 }
 
+// FormatUnits scales an amount in token units by decimals, e.g. 1500000 with
+// 6 decimals is "1.5". Trailing zeros of the fraction are dropped.
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	digits := new(big.Int).Abs(amount).String()
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + fraction
+}
+
 func RemoveHex0xPrefix(hex string) string {
 	if strings.HasPrefix(hex, "0x") || strings.HasPrefix(hex, "0X") {
 		return hex[2:]
@@ -1,94 +1,164 @@
 package utils
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"reflect"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/FudgyDRS/valhalla-api/pkg/tracing"
 	"github.com/sirupsen/logrus"
 )
 
-func FormatKeyValueLogs(data [][2]string) string {
-	var builder strings.Builder
-	builder.Grow(len(data) * 10)
+// SetupLogging configures the global logger from the environment:
+//
+//	LOG_FORMAT  json or text, defaults to defaultFormat
+//	LOG_LEVEL   trace, debug, info, warn, error or fatal, defaults to info
+//
+// DEBUG_MODE_ENABLED is still honoured as LOG_LEVEL=debug when LOG_LEVEL is
+// unset. Every line goes through Redact whatever the format.
+func SetupLogging(defaultFormat string) error {
+	level := logrus.InfoLevel
+	if value := os.Getenv("LOG_LEVEL"); value != "" {
+		parsed, err := logrus.ParseLevel(value)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_LEVEL %q: %v", value, err)
+		}
+		level = parsed
+	} else if debug := os.Getenv("DEBUG_MODE_ENABLED"); debug == "true" || debug == "1" {
+		level = logrus.DebugLevel
+	}
 
-	for _, entry := range data {
-		builder.WriteString(fmt.Sprintf("  %s: %s\n", entry[0], entry[1]))
+	format := strings.ToLower(os.Getenv("LOG_FORMAT"))
+	if format == "" {
+		format = defaultFormat
+	}
+	var formatter logrus.Formatter
+	switch format {
+	case "json":
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: time.RFC3339Nano,
+			FieldMap:        logrus.FieldMap{logrus.FieldKeyMsg: "message"},
+		}
+	case "text":
+		formatter = &TextFormatter{}
+	default:
+		return fmt.Errorf("invalid LOG_FORMAT %q, expected json or text", format)
 	}
 
-	return builder.String()
+	logrus.SetOutput(os.Stdout)
+	logrus.SetLevel(level)
+	logrus.SetFormatter(&contextFormatter{next: formatter})
+	return nil
 }
 
-func LogInfo(title string, message string) {
-	if logrus.GetLevel() < logrus.InfoLevel {
-		return
-	}
-
-	logrus.Info(fmt.Sprintf(
-		"\033[1m%s\033[0m:\n%s",
-		title,
-		message,
-	))
+// Log returns a logger for ctx. Lines written through it carry the request
+// and trace ids of ctx, if any.
+func Log(ctx context.Context) *logrus.Entry {
+	return logrus.WithContext(ctx)
 }
 
-func LogError(message string, errStr string) {
-	logrus.Error(fmt.Sprintf(
-		"%s: \033[38;5;197m%s\033[0m",
-		message,
-		errStr,
-	))
+// contextFormatter adds the ids carried by the entry's context and redacts
+// the message and fields before handing the entry to next.
+type contextFormatter struct {
+	next logrus.Formatter
 }
 
-func LogResponse(url string, response interface{}) {
-	if logrus.GetLevel() < logrus.InfoLevel {
-		return
+func (f *contextFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	redacted := *entry
+	redacted.Message = Redact(entry.Message)
+	redacted.Data = make(logrus.Fields, len(entry.Data)+2)
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			value = Redact(v)
+		case error:
+			value = Redact(v.Error())
+		case fmt.Stringer:
+			value = Redact(v.String())
+		}
+		redacted.Data[key] = value
 	}
 
-	keyValueData := ParseStructToKeyValue(response, "")
-	message := FormatKeyValueLogs(keyValueData)
+	if entry.Context != nil {
+		if requestId := RequestId(entry.Context); requestId != "" {
+			redacted.Data["request_id"] = requestId
+		}
+		if traceId := tracing.TraceId(entry.Context); traceId != "" {
+			redacted.Data["trace_id"] = traceId
+		}
+	}
 
-	logrus.Info(fmt.Sprintf(
-		"URL request: \033[1m%s\033[0m:\n%s",
-		url,
-		message,
-	))
+	return f.next.Format(&redacted)
 }
 
-func ParseStructToKeyValue(response interface{}, prefix string) [][2]string {
-	var keyValuePairs [][2]string
+var urlRegex = regexp.MustCompile(`(?i)\b(?:https?|wss?)://[^\s"'<>]+`)
 
-	val := reflect.ValueOf(response)
-	if val.Kind() == reflect.Ptr {
-		val = val.Elem() // Dereference pointer if necessary.
+// Redact strips the credentials providers put in RPC URLs. Any absolute URL
+// with user info, a path or a query is reduced to its scheme and host, so
+// https://rpc.ankr.com/sonic/<key> is logged as https://rpc.ankr.com/REDACTED.
+func Redact(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
 	}
+	return urlRegex.ReplaceAllStringFunc(s, func(match string) string {
+		parsed, err := url.Parse(match)
+		if err != nil || parsed.Host == "" {
+			return "REDACTED"
+		}
+		if parsed.User == nil && strings.Trim(parsed.Path, "/") == "" && parsed.RawQuery == "" {
+			return match
+		}
+		return parsed.Scheme + "://" + parsed.Host + "/REDACTED"
+	})
+}
 
-	if val.Kind() != reflect.Struct {
-		return keyValuePairs // Return empty for non-struct types.
+// TextFormatter writes colourised single lines for local use, fields sorted
+// by key after the message.
+type TextFormatter struct{}
+
+func (f *TextFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	// Set log colors, using 256-color ANSI escape code
+	var levelColor string
+	switch entry.Level {
+	case logrus.InfoLevel:
+		levelColor = "\033[38;5;45m"
+	case logrus.DebugLevel, logrus.TraceLevel:
+		levelColor = "\033[34m"
+	case logrus.WarnLevel:
+		levelColor = "\033[33m"
+	case logrus.ErrorLevel:
+		levelColor = "\033[31m"
+	case logrus.FatalLevel:
+		levelColor = "\033[35m"
+	case logrus.PanicLevel:
+		levelColor = "\033[36m"
+	default:
+		levelColor = "\033[0m"
 	}
 
-	typ := val.Type()
-	for i := 0; i < val.NumField(); i++ {
-		field := typ.Field(i)
-		fieldName := field.Name
-		fieldValue := val.Field(i)
-
-		// Construct the full key name (including prefix for nested structs).
-		fullName := fieldName
-		if prefix != "" {
-			fullName = fmt.Sprintf("%s.%s", prefix, fieldName)
-		}
-
-		// Check if the field is a nested struct.
-		if fieldValue.Kind() == reflect.Struct {
-			// Recursively process the nested struct.
-			nestedKeyValuePairs := ParseStructToKeyValue(fieldValue.Interface(), fullName)
-			keyValuePairs = append(keyValuePairs, nestedKeyValuePairs...)
-		} else {
-			// Convert the field value to a string representation.
-			fieldValueStr := fmt.Sprintf("%v", fieldValue.Interface())
-			keyValuePairs = append(keyValuePairs, [2]string{fullName, fieldValueStr})
-		}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "\033[38;5;180m%s\033[0m [%s%s\033[0m] %s",
+		entry.Time.Format("2006-01-02 15:04:05"), // Timestamp in cream color
+		levelColor,
+		entry.Level.String(),
+		entry.Message,
+	)
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " \033[2m%s=\033[0m%v", key, entry.Data[key])
 	}
+	b.WriteByte('\n')
 
-	return keyValuePairs
+	return b.Bytes(), nil
 }
@@ -4,11 +4,17 @@ type VersionResponse struct {
 	Version string `json:"version"`
 }
 
+// Error is the body of every failed response. Code is the HTTP status and
+// Type the stable ErrorCode clients should branch on.
 type Error struct {
-	Code    uint64 `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details"`
-	Origin  string `json:"origin"`
+	Code      uint64    `json:"code"`
+	Type      ErrorCode `json:"type"`
+	Message   string    `json:"message"`
+	Details   string    `json:"details"`
+	Origin    string    `json:"origin"`
+	Retryable bool      `json:"retryable"`
+	RequestId string    `json:"requestId,omitempty"`
+	cause     error
 }
 
 type Parameter struct {
@@ -0,0 +1,475 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Headers sent with every delivery. The signature is the hex HMAC-SHA256,
+// keyed by the subscription secret, of the timestamp, a dot and the body.
+const (
+	HeaderEvent     = "X-Valhalla-Event"
+	HeaderDelivery  = "X-Valhalla-Delivery"
+	HeaderTimestamp = "X-Valhalla-Timestamp"
+	HeaderSignature = "X-Valhalla-Signature"
+)
+
+var ErrClosed = errors.New("webhook dispatcher is closed")
+
+// Target is where and how a delivery is sent.
+type Target struct {
+	Subscription string
+	URL          string
+	Secret       string
+}
+
+// Delivery is one event sent to a target, with the outcome of its last
+// attempt.
+type Delivery struct {
+	Id           string `json:"id"`
+	Subscription string `json:"subscription"`
+	Event        string `json:"event"`
+	Status       string `json:"status"`
+	Attempts     int    `json:"attempts"`
+	StatusCode   int    `json:"status-code,omitempty"`
+	Error        string `json:"error,omitempty"`
+	CreatedAt    string `json:"created-at"`
+	LastAttempt  string `json:"last-attempt,omitempty"`
+	NextAttempt  string `json:"next-attempt,omitempty"`
+}
+
+// Config is read from the environment by LoadConfig.
+type Config struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+	Workers        int
+	LogSize        int
+	AllowPrivate   bool
+	// OnResult is called after every attempt, e.g. for metrics.
+	OnResult func(Delivery)
+}
+
+// LoadConfig reads, with their defaults:
+//
+//	WEBHOOK_MAX_ATTEMPTS     6
+//	WEBHOOK_INITIAL_BACKOFF  10s, doubled after every failed attempt
+//	WEBHOOK_MAX_BACKOFF      10m
+//	WEBHOOK_TIMEOUT          10s per attempt
+//	WEBHOOK_WORKERS          4 concurrent attempts
+//	WEBHOOK_LOG_SIZE         100 deliveries kept per subscription
+//	WEBHOOK_ALLOW_PRIVATE    false, refuse loopback and private addresses
+func LoadConfig() (Config, error) {
+	config := Config{
+		MaxAttempts:    6,
+		InitialBackoff: 10 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+		Timeout:        10 * time.Second,
+		Workers:        4,
+		LogSize:        100,
+	}
+
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"WEBHOOK_INITIAL_BACKOFF", &config.InitialBackoff},
+		{"WEBHOOK_MAX_BACKOFF", &config.MaxBackoff},
+		{"WEBHOOK_TIMEOUT", &config.Timeout},
+	}
+	for _, d := range durations {
+		if value := os.Getenv(d.key); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration <= 0 {
+				return Config{}, fmt.Errorf("invalid %s %q, expected a duration such as 30s", d.key, value)
+			}
+			*d.target = duration
+		}
+	}
+
+	ints := []struct {
+		key    string
+		target *int
+	}{
+		{"WEBHOOK_MAX_ATTEMPTS", &config.MaxAttempts},
+		{"WEBHOOK_WORKERS", &config.Workers},
+		{"WEBHOOK_LOG_SIZE", &config.LogSize},
+	}
+	for _, i := range ints {
+		if value := os.Getenv(i.key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return Config{}, fmt.Errorf("invalid %s %q", i.key, value)
+			}
+			*i.target = n
+		}
+	}
+
+	if value := os.Getenv("WEBHOOK_ALLOW_PRIVATE"); value != "" {
+		allow, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid WEBHOOK_ALLOW_PRIVATE %q, expected true or false", value)
+		}
+		config.AllowPrivate = allow
+	}
+
+	return config, nil
+}
+
+// Sign returns the signature of body sent at timestamp, as in
+// X-Valhalla-Signature.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body at timestamp.
+// Receivers should also reject timestamps too far from their clock.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	return randomHex(32)
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ValidateURL accepts absolute http and https URLs. Whether the host is a
+// private address is checked when connecting, after DNS resolution.
+func ValidateURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %v", err)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return fmt.Errorf("webhook url must be http or https")
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("webhook url has no host")
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("webhook url must not contain credentials")
+	}
+	return nil
+}
+
+type delivery struct {
+	target  Target
+	body    []byte
+	record  Delivery
+	attempt int
+}
+
+// Dispatcher sends deliveries from a fixed pool of workers and retries
+// failures with exponential backoff. Deliveries are kept in memory only,
+// retries still pending at Close are dropped.
+type Dispatcher struct {
+	config Config
+	client *http.Client
+	queue  chan *delivery
+
+	mu      sync.Mutex
+	closed  bool
+	timers  map[*delivery]*time.Timer
+	log     map[string][]Delivery
+	workers sync.WaitGroup
+}
+
+func New(config Config) *Dispatcher {
+	dialer := &net.Dialer{Timeout: config.Timeout}
+	if !config.AllowPrivate {
+		dialer.Control = refusePrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	d := &Dispatcher{
+		config: config,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   config.Timeout,
+			// A redirect could point the delivery at a private address
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		queue:  make(chan *delivery, 1024),
+		timers: make(map[*delivery]*time.Timer),
+		log:    make(map[string][]Delivery),
+	}
+	for i := 0; i < config.Workers; i++ {
+		d.workers.Add(1)
+		go d.work()
+	}
+	return d
+}
+
+// refusePrivate runs on the resolved address, so DNS cannot point an allowed
+// name at an internal service.
+func refusePrivate(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+	return nil
+}
+
+// Send queues payload, encoded as JSON, for target and returns the pending
+// delivery.
+func (d *Dispatcher) Send(target Target, event string, payload interface{}) (Delivery, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+	id, err := randomHex(16)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	item := &delivery{
+		target: target,
+		body:   body,
+		record: Delivery{
+			Id:           id,
+			Subscription: target.Subscription,
+			Event:        event,
+			Status:       StatusPending,
+			CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return Delivery{}, ErrClosed
+	}
+	d.record(item.record)
+	select {
+	case d.queue <- item:
+	default:
+		item.record.Status = StatusFailed
+		item.record.Error = "delivery queue is full"
+		d.record(item.record)
+		return item.record, fmt.Errorf("webhook delivery queue is full")
+	}
+	return item.record, nil
+}
+
+// Deliveries returns the delivery log of a subscription, newest first.
+func (d *Dispatcher) Deliveries(subscription string) []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	log := d.log[subscription]
+	deliveries := make([]Delivery, len(log))
+	for i, record := range log {
+		deliveries[len(log)-1-i] = record
+	}
+	return deliveries
+}
+
+// Forget drops the delivery log of a deleted subscription.
+func (d *Dispatcher) Forget(subscription string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.log, subscription)
+}
+
+// record stores or updates a delivery in the log, d.mu must be held.
+func (d *Dispatcher) record(record Delivery) {
+	log := d.log[record.Subscription]
+	for i := len(log) - 1; i >= 0; i-- {
+		if log[i].Id == record.Id {
+			log[i] = record
+			return
+		}
+	}
+	log = append(log, record)
+	if len(log) > d.config.LogSize {
+		log = log[len(log)-d.config.LogSize:]
+	}
+	d.log[record.Subscription] = log
+}
+
+func (d *Dispatcher) work() {
+	defer d.workers.Done()
+	for item := range d.queue {
+		d.attempt(item)
+	}
+}
+
+func (d *Dispatcher) attempt(item *delivery) {
+	item.attempt++
+	statusCode, err := d.post(item)
+
+	record := item.record
+	record.Attempts = item.attempt
+	record.StatusCode = statusCode
+	record.LastAttempt = time.Now().UTC().Format(time.RFC3339)
+	record.NextAttempt = ""
+	record.Error = ""
+
+	retry := false
+	switch {
+	case err == nil && statusCode >= 200 && statusCode < 300:
+		record.Status = StatusDelivered
+	default:
+		if err != nil {
+			record.Error = err.Error()
+		} else {
+			record.Error = fmt.Sprintf("endpoint answered %d", statusCode)
+		}
+		// Other 4xx mean the endpoint rejects this delivery for good
+		retry = err != nil || statusCode >= 500 || statusCode == http.StatusTooManyRequests || statusCode == http.StatusRequestTimeout
+		if retry && item.attempt < d.config.MaxAttempts {
+			record.Status = StatusPending
+		} else {
+			record.Status = StatusFailed
+			retry = false
+		}
+	}
+
+	d.mu.Lock()
+	if retry && !d.closed {
+		backoff := d.backoff(item.attempt)
+		record.NextAttempt = time.Now().Add(backoff).UTC().Format(time.RFC3339)
+		d.timers[item] = time.AfterFunc(backoff, func() { d.retry(item) })
+	} else if retry {
+		record.Status = StatusFailed
+		record.Error += ", retries dropped at shutdown"
+	}
+	item.record = record
+	d.record(record)
+	d.mu.Unlock()
+
+	fields := logrus.Fields{"delivery": record.Id, "subscription": record.Subscription, "event": record.Event, "attempt": record.Attempts, "status": record.Status}
+	if record.Status == StatusFailed {
+		logrus.WithFields(fields).WithField("error", record.Error).Warn("webhook delivery failed")
+	} else {
+		logrus.WithFields(fields).Debug("webhook delivery attempt")
+	}
+	if d.config.OnResult != nil {
+		d.config.OnResult(record)
+	}
+}
+
+func (d *Dispatcher) retry(item *delivery) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.timers, item)
+	if d.closed {
+		return
+	}
+	select {
+	case d.queue <- item:
+	default:
+		item.record.Status = StatusFailed
+		item.record.Error = "delivery queue is full"
+		d.record(item.record)
+	}
+}
+
+// backoff doubles from InitialBackoff up to MaxBackoff, with 20% jitter so
+// retries to a recovering endpoint are spread out.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	backoff := float64(d.config.InitialBackoff) * math.Pow(2, float64(attempt-1))
+	backoff = math.Min(backoff, float64(d.config.MaxBackoff))
+	backoff *= 0.8 + 0.4*mathrand.Float64()
+	return time.Duration(backoff)
+}
+
+func (d *Dispatcher) post(item *delivery) (int, error) {
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequest(http.MethodPost, item.target.URL, bytes.NewReader(item.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "valhalla-api-webhooks")
+	req.Header.Set(HeaderEvent, item.record.Event)
+	req.Header.Set(HeaderDelivery, item.record.Id)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(item.target.Secret, timestamp, item.body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+// Close stops accepting deliveries, drops scheduled retries and waits for
+// attempts in flight until ctx is done.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	for item, timer := range d.timers {
+		timer.Stop()
+		item.record.Status = StatusFailed
+		item.record.NextAttempt = ""
+		item.record.Error += ", retries dropped at shutdown"
+		d.record(item.record)
+	}
+	d.timers = nil
+	close(d.queue)
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("webhook deliveries still in flight: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	return Config{
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		Timeout:        time.Second,
+		Workers:        1,
+		LogSize:        10,
+		AllowPrivate:   true,
+	}
+}
+
+func newDispatcher(t *testing.T, config Config) *Dispatcher {
+	t.Helper()
+	d := New(config)
+	t.Cleanup(func() { d.Close(context.Background()) })
+	return d
+}
+
+// settled waits until the delivery is no longer pending.
+func settled(t *testing.T, d *Dispatcher, subscription string, id string) Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, delivery := range d.Deliveries(subscription) {
+			if delivery.Id == id && delivery.Status != StatusPending {
+				return delivery
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("delivery %s is still pending", id)
+	return Delivery{}
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"event":"ping"}`)
+	signature := Sign("secret", 1700000000, body)
+	if !strings.HasPrefix(signature, "sha256=") || !Verify("secret", 1700000000, body, signature) {
+		t.Fatalf("signature %s does not verify", signature)
+	}
+	if Verify("other", 1700000000, body, signature) || Verify("secret", 1700000001, body, signature) || Verify("secret", 1700000000, []byte(`{}`), signature) {
+		t.Error("a changed secret, timestamp or body verified")
+	}
+}
+
+// A delivery is signed over its timestamp and body, as receivers check it.
+func TestDeliveryIsSigned(t *testing.T) {
+	verified := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		verified <- r.Header.Get(HeaderEvent) == "ping" && Verify("secret", timestamp, body, r.Header.Get(HeaderSignature))
+	}))
+	defer server.Close()
+
+	d := newDispatcher(t, testConfig())
+	delivery, err := d.Send(Target{Subscription: "sub", URL: server.URL, Secret: "secret"}, "ping", map[string]string{"event": "ping"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !<-verified {
+		t.Error("the delivery signature does not verify")
+	}
+	if delivery := settled(t, d, "sub", delivery.Id); delivery.Status != StatusDelivered || delivery.StatusCode != http.StatusOK {
+		t.Errorf("delivery = %+v", delivery)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	d := newDispatcher(t, testConfig())
+	delivery, _ := d.Send(Target{Subscription: "sub", URL: server.URL}, "ping", nil)
+	if delivery := settled(t, d, "sub", delivery.Id); delivery.Status != StatusDelivered || delivery.Attempts != 2 || delivery.Error != "" {
+		t.Errorf("delivery = %+v, want delivered on the second attempt", delivery)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	tests := []struct {
+		status   int
+		attempts int
+	}{
+		{http.StatusInternalServerError, 3},
+		{http.StatusTooManyRequests, 3},
+		// The endpoint rejects the delivery for good
+		{http.StatusBadRequest, 1},
+	}
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			d := newDispatcher(t, testConfig())
+			delivery, _ := d.Send(Target{Subscription: "sub", URL: server.URL}, "ping", nil)
+			delivery = settled(t, d, "sub", delivery.Id)
+			if delivery.Status != StatusFailed || delivery.Attempts != test.attempts || delivery.StatusCode != test.status {
+				t.Errorf("delivery = %+v, want failed after %d attempts", delivery, test.attempts)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{config: Config{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+	for _, test := range tests {
+		for i := 0; i < 20; i++ {
+			backoff := d.backoff(test.attempt)
+			if backoff < test.want*8/10 || backoff > test.want*12/10 {
+				t.Errorf("attempt %d: backoff %s, want %s with 20%% jitter", test.attempt, backoff, test.want)
+				break
+			}
+		}
+	}
+}
+
+// Loopback endpoints are refused unless WEBHOOK_ALLOW_PRIVATE is set.
+func TestRefusePrivate(t *testing.T) {
+	var reached atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached.Store(true)
+	}))
+	defer server.Close()
+
+	for _, allow := range []string{"", "true"} {
+		t.Run("allow="+allow, func(t *testing.T) {
+			reached.Store(false)
+			t.Setenv("WEBHOOK_ALLOW_PRIVATE", allow)
+			t.Setenv("WEBHOOK_MAX_ATTEMPTS", "1")
+			config, err := LoadConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d := newDispatcher(t, config)
+			delivery, _ := d.Send(Target{Subscription: "sub", URL: server.URL}, "ping", nil)
+			delivery = settled(t, d, "sub", delivery.Id)
+			if allow == "" && (delivery.Status != StatusFailed || !strings.Contains(delivery.Error, "is not public") || reached.Load()) {
+				t.Errorf("delivery = %+v, reached %v, want refused", delivery, reached.Load())
+			}
+			if allow != "" && (delivery.Status != StatusDelivered || !reached.Load()) {
+				t.Errorf("delivery = %+v, want delivered", delivery)
+			}
+		})
+	}
+}
+
+func TestRefusePrivateAddresses(t *testing.T) {
+	for _, address := range []string{"127.0.0.1:443", "[::1]:443", "10.0.0.1:443", "192.168.1.1:80", "169.254.169.254:80", "0.0.0.0:80"} {
+		if err := refusePrivate("tcp", address, nil); err == nil {
+			t.Errorf("%s was allowed", address)
+		}
+	}
+	if err := refusePrivate("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("public address refused: %v", err)
+	}
+}
+
+// The log keeps the last LogSize deliveries of a subscription, newest first.
+func TestDeliveryLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	config := testConfig()
+	config.LogSize = 2
+	d := newDispatcher(t, config)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		delivery, err := d.Send(Target{Subscription: "sub", URL: server.URL}, "ping", i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		settled(t, d, "sub", delivery.Id)
+		ids = append(ids, delivery.Id)
+	}
+	d.Send(Target{Subscription: "other", URL: server.URL}, "ping", nil)
+
+	deliveries := d.Deliveries("sub")
+	if len(deliveries) != 2 || deliveries[0].Id != ids[2] || deliveries[1].Id != ids[1] {
+		t.Errorf("deliveries = %+v, want the last two newest first", deliveries)
+	}
+
+	d.Forget("sub")
+	if deliveries := d.Deliveries("sub"); len(deliveries) != 0 {
+		t.Errorf("deliveries after forget = %+v", deliveries)
+	}
+	if deliveries := d.Deliveries("other"); len(deliveries) != 1 {
+		t.Errorf("forget dropped another subscription: %+v", deliveries)
+	}
+}
+
+func TestSendAfterClose(t *testing.T) {
+	d := New(testConfig())
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Send(Target{Subscription: "sub", URL: "https://example.com"}, "ping", nil); err != ErrClosed {
+		t.Errorf("send after close: %v, want ErrClosed", err)
+	}
+}